@@ -0,0 +1,64 @@
+package api
+
+import (
+	"github.com/kwo/rakewire/api/msg"
+	"github.com/kwo/rakewire/auth"
+	"golang.org/x/net/context"
+)
+
+// Activity reports the live state of the feed pipeline, for admins only.
+func (z *API) Activity(ctx context.Context, req *msg.ActivityRequest) (*msg.ActivityResponse, error) {
+
+	user := ctx.Value("user").(*auth.User)
+
+	rsp := &msg.ActivityResponse{}
+
+	if !user.HasRole(auth.RoleAdmin) {
+		rsp.Status = msg.StatusErr
+		rsp.Message = "Only admins may view activity"
+		return rsp, nil
+	}
+
+	if z.monitor == nil {
+		rsp.Status = msg.StatusErr
+		rsp.Message = "Activity not available"
+		return rsp, nil
+	}
+
+	activity := z.monitor.Activity()
+
+	if poll := activity.Poll; poll != nil {
+		rsp.Polling = poll.Polling
+		rsp.LastPoll = poll.LastPoll
+		rsp.PollQueue = poll.Queued
+	}
+
+	if fetch := activity.Fetch; fetch != nil {
+		rsp.ReapQueue = fetch.Queued
+		for _, worker := range fetch.Workers {
+			rsp.Workers = append(rsp.Workers, &msg.WorkerActivity{
+				ID:     worker.ID,
+				State:  worker.State,
+				Since:  worker.Since,
+				FeedID: worker.FeedID,
+				URL:    worker.URL,
+			})
+		}
+	}
+
+	if reap := activity.Reap; reap != nil {
+		rsp.LastReaped = reap.LastReaped
+		rsp.PerMinute = reap.PerMinute
+		rsp.Reaped = reap.Total
+		rsp.Batches = reap.Batches
+		rsp.LastBatch = reap.LastBatch
+		rsp.AvgBatch = reap.AvgBatch
+		rsp.LastCommit = reap.LastCommit.Seconds() * 1000
+		rsp.AvgCommit = reap.AvgCommit.Seconds() * 1000
+		rsp.Isolated = reap.Isolated
+		rsp.Failed = reap.Failed
+	}
+
+	return rsp, nil
+
+}
@@ -8,6 +8,7 @@ import (
 	"time"
 
 	"github.com/kwo/rakewire/api/msg"
+	"github.com/kwo/rakewire/feedparser"
 	"github.com/kwo/rakewire/logger"
 	"github.com/kwo/rakewire/model"
 	"golang.org/x/net/context"
@@ -25,6 +26,9 @@ var (
 // API top level struct
 type API struct {
 	db        model.Database
+	fetcher   Fetcher
+	monitor   Monitor
+	limiter   *rateLimiter
 	mountPath string
 	handlers  map[string]map[string]Handler // handlers mapped by path then method
 	version   string
@@ -36,13 +40,31 @@ type API struct {
 // Handler handles API requests
 type Handler func(context.Context, http.ResponseWriter, *http.Request)
 
-// New creates a new REST API instance
-func New(database model.Database, mountPath, versionString string, appStart int64) *API {
+// Fetcher fetches feeds on demand
+type Fetcher interface {
+	Discover(ctx context.Context, url string) ([]*feedparser.FeedLink, error)
+	Preview(ctx context.Context, feed *model.Feed) (*model.Harvest, error)
+	Refresh(ctx context.Context, feed *model.Feed) (*model.Transmission, error)
+	Validate(ctx context.Context, feed *model.Feed) (*model.Harvest, error)
+	Backfill(feed *model.Feed, userID string, maxItems int) error
+	Background(task func(ctx context.Context)) error
+}
+
+// Monitor reports the live state of the poll, fetch and reap pipeline
+type Monitor interface {
+	Activity() *model.Activity
+}
+
+// New creates a new REST API instance, the activity endpoint is disabled if monitor is nil.
+func New(database model.Database, fetcher Fetcher, monitor Monitor, mountPath, versionString string, appStart int64) *API {
 
 	version, buildTime, buildHash := parseVersionString(versionString)
 
 	z := &API{
 		db:        database,
+		fetcher:   fetcher,
+		monitor:   monitor,
+		limiter:   newRateLimiter(refreshLimit, refreshLimitPeriod),
 		mountPath: mountPath,
 		handlers:  make(map[string]map[string]Handler),
 		version:   version,
@@ -54,6 +76,22 @@ func New(database model.Database, mountPath, versionString string, appStart int6
 	// register handlers
 	// TODO: handle more errRequest errors: auth
 
+	z.handlers["activity"] = make(map[string]Handler)
+	z.handlers["activity"][http.MethodPost] = func(ctx context.Context, w http.ResponseWriter, r *http.Request) {
+		req := &msg.ActivityRequest{}
+		if errRequest := readRequest(ctx, r, req); errRequest == nil {
+			if rsp, errResponse := z.Activity(ctx, req); errResponse == nil {
+				sendResponse(ctx, w, rsp)
+			} else {
+				http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+			}
+		} else if errRequest == ErrEmptyRequest {
+			http.Error(w, http.StatusText(http.StatusBadRequest), http.StatusBadRequest)
+		} else {
+			http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		}
+	}
+
 	z.handlers["entries/list"] = make(map[string]Handler)
 	z.handlers["entries/list"][http.MethodPost] = func(ctx context.Context, w http.ResponseWriter, r *http.Request) {
 		req := &msg.EntryListRequest{}
@@ -87,6 +125,137 @@ func New(database model.Database, mountPath, versionString string, appStart int6
 		}
 	}
 
+	z.handlers["feeds/details"] = make(map[string]Handler)
+	z.handlers["feeds/details"][http.MethodPost] = func(ctx context.Context, w http.ResponseWriter, r *http.Request) {
+		req := &msg.FeedDetailsRequest{}
+		if errRequest := readRequest(ctx, r, req); errRequest == nil {
+			if rsp, errResponse := z.FeedDetails(ctx, req); errResponse == nil {
+				sendResponse(ctx, w, rsp)
+			} else {
+				http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+			}
+		} else if errRequest == ErrEmptyRequest {
+			http.Error(w, http.StatusText(http.StatusBadRequest), http.StatusBadRequest)
+		} else {
+			http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		}
+	}
+
+	z.handlers["feeds/favicon"] = make(map[string]Handler)
+	z.handlers["feeds/favicon"][http.MethodPost] = func(ctx context.Context, w http.ResponseWriter, r *http.Request) {
+		req := &msg.FeedFaviconRequest{}
+		if errRequest := readRequest(ctx, r, req); errRequest == nil {
+			if rsp, errResponse := z.FeedFavicon(ctx, req); errResponse == nil {
+				sendResponse(ctx, w, rsp)
+			} else {
+				http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+			}
+		} else if errRequest == ErrEmptyRequest {
+			http.Error(w, http.StatusText(http.StatusBadRequest), http.StatusBadRequest)
+		} else {
+			http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		}
+	}
+
+	z.handlers["feeds/frequency"] = make(map[string]Handler)
+	z.handlers["feeds/frequency"][http.MethodPost] = func(ctx context.Context, w http.ResponseWriter, r *http.Request) {
+		req := &msg.FeedFrequencyRequest{}
+		if errRequest := readRequest(ctx, r, req); errRequest == nil {
+			if rsp, errResponse := z.FeedFrequency(ctx, req); errResponse == nil {
+				sendResponse(ctx, w, rsp)
+			} else {
+				http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+			}
+		} else if errRequest == ErrEmptyRequest {
+			http.Error(w, http.StatusText(http.StatusBadRequest), http.StatusBadRequest)
+		} else {
+			http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		}
+	}
+
+	z.handlers["feeds/refresh"] = make(map[string]Handler)
+	z.handlers["feeds/refresh"][http.MethodPost] = func(ctx context.Context, w http.ResponseWriter, r *http.Request) {
+		req := &msg.FeedRefreshRequest{}
+		if errRequest := readRequest(ctx, r, req); errRequest == nil {
+			if rsp, errResponse := z.FeedRefresh(ctx, req); errResponse == nil {
+				sendResponse(ctx, w, rsp)
+			} else {
+				log.Debugf("feeds/refresh error: %s", errResponse.Error())
+				http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+			}
+		} else if errRequest == ErrEmptyRequest {
+			http.Error(w, http.StatusText(http.StatusBadRequest), http.StatusBadRequest)
+		} else {
+			http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		}
+	}
+
+	z.handlers["feeds/scrape/preview"] = make(map[string]Handler)
+	z.handlers["feeds/scrape/preview"][http.MethodPost] = func(ctx context.Context, w http.ResponseWriter, r *http.Request) {
+		req := &msg.FeedScrapePreviewRequest{}
+		if errRequest := readRequest(ctx, r, req); errRequest == nil {
+			if rsp, errResponse := z.FeedScrapePreview(ctx, req); errResponse == nil {
+				sendResponse(ctx, w, rsp)
+			} else {
+				log.Debugf("feeds/scrape/preview error: %s", errResponse.Error())
+				http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+			}
+		} else if errRequest == ErrEmptyRequest {
+			http.Error(w, http.StatusText(http.StatusBadRequest), http.StatusBadRequest)
+		} else {
+			http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		}
+	}
+
+	z.handlers["feeds/schedule"] = make(map[string]Handler)
+	z.handlers["feeds/schedule"][http.MethodPost] = func(ctx context.Context, w http.ResponseWriter, r *http.Request) {
+		req := &msg.FeedScheduleRequest{}
+		if errRequest := readRequest(ctx, r, req); errRequest == nil {
+			if rsp, errResponse := z.FeedSchedule(ctx, req); errResponse == nil {
+				sendResponse(ctx, w, rsp)
+			} else {
+				http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+			}
+		} else if errRequest == ErrEmptyRequest {
+			http.Error(w, http.StatusText(http.StatusBadRequest), http.StatusBadRequest)
+		} else {
+			http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		}
+	}
+
+	z.handlers["feeds/schedule/update"] = make(map[string]Handler)
+	z.handlers["feeds/schedule/update"][http.MethodPost] = func(ctx context.Context, w http.ResponseWriter, r *http.Request) {
+		req := &msg.FeedScheduleUpdateRequest{}
+		if errRequest := readRequest(ctx, r, req); errRequest == nil {
+			if rsp, errResponse := z.FeedScheduleUpdate(ctx, req); errResponse == nil {
+				sendResponse(ctx, w, rsp)
+			} else {
+				http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+			}
+		} else if errRequest == ErrEmptyRequest {
+			http.Error(w, http.StatusText(http.StatusBadRequest), http.StatusBadRequest)
+		} else {
+			http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		}
+	}
+
+	z.handlers["feeds/validate"] = make(map[string]Handler)
+	z.handlers["feeds/validate"][http.MethodPost] = func(ctx context.Context, w http.ResponseWriter, r *http.Request) {
+		req := &msg.FeedValidateRequest{}
+		if errRequest := readRequest(ctx, r, req); errRequest == nil {
+			if rsp, errResponse := z.FeedValidate(ctx, req); errResponse == nil {
+				sendResponse(ctx, w, rsp)
+			} else {
+				log.Debugf("feeds/validate error: %s", errResponse.Error())
+				http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+			}
+		} else if errRequest == ErrEmptyRequest {
+			http.Error(w, http.StatusText(http.StatusBadRequest), http.StatusBadRequest)
+		} else {
+			http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		}
+	}
+
 	z.handlers["groups/list"] = make(map[string]Handler)
 	z.handlers["groups/list"][http.MethodPost] = func(ctx context.Context, w http.ResponseWriter, r *http.Request) {
 		req := &msg.GroupListRequest{}
@@ -19,11 +19,11 @@ func (z *API) EntryList(ctx context.Context, req *msg.EntryListRequest) (*msg.En
 		if feed := model.F.GetByURL(tx, req.Subscription); feed != nil {
 			if sub := model.S.GetForUser(tx, user.ID).ByFeedID()[feed.GetID()]; sub != nil {
 
-				entries := model.E.Query(tx, user.ID).Feed(feed.ID).Get()
+				entries := model.E.Query(tx, user.ID).Feed(feed.ID).Category(req.Category).Get()
 				itemsByID := model.I.GetByEntries(tx, entries).ByID()
 
 				for _, entry := range entries {
-					rsp.Entries = append(rsp.Entries, toEntry(entry, itemsByID[entry.ItemID], feed))
+					rsp.Entries = append(rsp.Entries, toEntry(entry, itemsByID[entry.ItemID], feed, sub.HasFullText()))
 				}
 
 			}
@@ -75,7 +75,7 @@ func (z *API) EntryUpdate(ctx context.Context, req *msg.EntryUpdateRequest) (*ms
 
 }
 
-func toEntry(entry *model.Entry, item *model.Item, feed *model.Feed) *msg.Entry {
+func toEntry(entry *model.Entry, item *model.Item, feed *model.Feed, fullText bool) *msg.Entry {
 
 	e := &msg.Entry{}
 
@@ -85,8 +85,20 @@ func toEntry(entry *model.Entry, item *model.Item, feed *model.Feed) *msg.Entry
 	e.GUID = item.GUID
 	e.Title = item.Title
 	e.Updated = item.Updated
+	e.Categories = item.Categories
 	e.Read = entry.Read
 	e.Star = entry.Star
+	e.Content = item.BestContent(fullText)
+	e.Episode = item.Episode
+	e.Image = item.Image
+	for _, enclosure := range item.Enclosures {
+		e.Enclosures = append(e.Enclosures, &msg.Enclosure{
+			URL:          enclosure.URL,
+			Type:         enclosure.Type,
+			Length:       enclosure.Length,
+			DurationSecs: int(enclosure.Duration.Seconds()),
+		})
+	}
 
 	return e
 
@@ -0,0 +1,529 @@
+package api
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/kwo/rakewire/api/msg"
+	"github.com/kwo/rakewire/auth"
+	"github.com/kwo/rakewire/fetch"
+	"github.com/kwo/rakewire/model"
+	"github.com/kwo/rakewire/scraper"
+	"golang.org/x/net/context"
+)
+
+const (
+	// refreshLimit is the number of feeds a user may refresh on demand within refreshLimitPeriod.
+	refreshLimit       = 10
+	refreshLimitPeriod = time.Minute
+	// detailsLookback is how far back to look for the last parsed transmission of a feed.
+	detailsLookback = 7 * 24 * time.Hour
+)
+
+// FeedDetails returns the metadata and publisher hints of a subscribed feed.
+func (z *API) FeedDetails(ctx context.Context, req *msg.FeedDetailsRequest) (*msg.FeedDetailsResponse, error) {
+
+	user := ctx.Value("user").(*auth.User)
+
+	rsp := &msg.FeedDetailsResponse{}
+
+	err := z.db.Select(func(tx model.Transaction) error {
+
+		feed := model.F.GetByURL(tx, req.URL)
+		if feed == nil || model.S.GetForUser(tx, user.ID).ByFeedID()[feed.ID] == nil {
+			rsp.Status = msg.StatusNotFound
+			return nil
+		}
+
+		details := &msg.FeedDetails{
+			URL:            feed.URL,
+			SiteURL:        feed.SiteURL,
+			SelfURL:        feed.SelfURL,
+			Title:          feed.Title,
+			Icon:           feed.Icon,
+			Language:       feed.Language,
+			Categories:     feed.Categories,
+			ItemCategories: model.I.GetCategories(tx, feed.ID),
+			Status:         feed.Status,
+			StatusMessage:  feed.StatusMessage,
+			StatusSince:    feed.StatusSince,
+			LastUpdated:    feed.LastUpdated,
+			NextFetch:      feed.NextFetch,
+		}
+
+		if push := feed.Push; push != nil {
+			details.Hub = push.Hub
+			details.HubActive = push.Active(time.Now())
+			details.HubVerified = push.Verified
+		}
+
+		if hints := feed.Hints; hints != nil {
+			details.TTLSecs = int(hints.TTL / time.Second)
+			details.UpdatePeriodSecs = int(hints.UpdatePeriod / time.Second)
+			details.SkipHours = hints.SkipHours
+			for _, day := range hints.SkipDays {
+				details.SkipDays = append(details.SkipDays, day.String())
+			}
+		}
+
+		// unchanged bodies are not parsed, find the last transmission which was
+		for _, transmission := range model.T.GetForFeed(tx, feed.ID, detailsLookback) {
+			if transmission.Flavor != "" {
+				details.Flavor = transmission.Flavor
+				details.Generator = transmission.Generator
+				details.Published = transmission.Published
+				details.BadDates = transmission.BadDates
+				break
+			}
+		}
+
+		rsp.Details = details
+
+		return nil
+
+	})
+
+	return rsp, err
+
+}
+
+// FeedFavicon returns the icon of a subscribed feed.
+func (z *API) FeedFavicon(ctx context.Context, req *msg.FeedFaviconRequest) (*msg.FeedFaviconResponse, error) {
+
+	user := ctx.Value("user").(*auth.User)
+
+	rsp := &msg.FeedFaviconResponse{}
+
+	err := z.db.Select(func(tx model.Transaction) error {
+
+		feed := model.F.GetByURL(tx, req.URL)
+		if feed == nil || model.S.GetForUser(tx, user.ID).ByFeedID()[feed.ID] == nil {
+			rsp.Status = msg.StatusNotFound
+			return nil
+		}
+
+		favicon := model.V.Get(tx, feed.ID)
+		if favicon == nil || !favicon.HasData() {
+			rsp.Status = msg.StatusNotFound
+			rsp.Message = "no icon found for feed"
+			return nil
+		}
+
+		rsp.Type = favicon.Type
+		rsp.Data = favicon.Data
+		rsp.Source = favicon.URL
+		rsp.Updated = favicon.Updated
+
+		return nil
+
+	})
+
+	return rsp, err
+
+}
+
+// FeedFrequency returns the learned publishing frequency of a subscribed feed.
+func (z *API) FeedFrequency(ctx context.Context, req *msg.FeedFrequencyRequest) (*msg.FeedFrequencyResponse, error) {
+
+	user := ctx.Value("user").(*auth.User)
+
+	rsp := &msg.FeedFrequencyResponse{}
+
+	err := z.db.Select(func(tx model.Transaction) error {
+
+		feed := model.F.GetByURL(tx, req.URL)
+		if feed == nil || model.S.GetForUser(tx, user.ID).ByFeedID()[feed.ID] == nil {
+			rsp.Status = msg.StatusNotFound
+			return nil
+		}
+
+		now := time.Now()
+		rsp.NextFetch = feed.NextFetch
+		if feed.Frequency != nil {
+			rsp.Histogram = feed.Frequency.Rates(now)
+			rsp.Since = feed.Frequency.Since
+			if next, ok := feed.Frequency.Predict(now); ok {
+				rsp.NextUpdate = next.Truncate(time.Second)
+			}
+		}
+
+		return nil
+
+	})
+
+	return rsp, err
+
+}
+
+// FeedSchedule returns the manual fetch settings of a subscribed feed.
+func (z *API) FeedSchedule(ctx context.Context, req *msg.FeedScheduleRequest) (*msg.FeedScheduleResponse, error) {
+
+	user := ctx.Value("user").(*auth.User)
+
+	rsp := &msg.FeedScheduleResponse{}
+
+	err := z.db.Select(func(tx model.Transaction) error {
+
+		feed := model.F.GetByURL(tx, req.URL)
+		if feed == nil || model.S.GetForUser(tx, user.ID).ByFeedID()[feed.ID] == nil {
+			rsp.Status = msg.StatusNotFound
+			return nil
+		}
+
+		rsp.Schedule = toFeedSchedule(feed)
+
+		return nil
+
+	})
+
+	return rsp, err
+
+}
+
+// FeedScheduleUpdate replaces the manual fetch settings of a subscribed feed.
+func (z *API) FeedScheduleUpdate(ctx context.Context, req *msg.FeedScheduleUpdateRequest) (*msg.FeedScheduleUpdateResponse, error) {
+
+	user := ctx.Value("user").(*auth.User)
+
+	rsp := &msg.FeedScheduleUpdateResponse{}
+
+	if req.Schedule == nil {
+		rsp.Status = msg.StatusErr
+		rsp.Message = "Missing schedule"
+		return rsp, nil
+	}
+
+	interval := time.Duration(req.Schedule.IntervalSecs) * time.Second
+	if interval < 0 {
+		rsp.Status = msg.StatusErr
+		rsp.Message = "Interval cannot be negative"
+		return rsp, nil
+	}
+
+	windows := model.FetchWindows{}
+	for _, value := range req.Schedule.Windows {
+		window, err := model.ParseFetchWindow(value)
+		if err != nil {
+			rsp.Status = msg.StatusErr
+			rsp.Message = err.Error()
+			return rsp, nil
+		}
+		windows = append(windows, window)
+	}
+
+	for _, zone := range []string{req.Schedule.TimeZone, req.Schedule.DateZone} {
+		if zone != "" {
+			if _, err := time.LoadLocation(zone); err != nil {
+				rsp.Status = msg.StatusErr
+				rsp.Message = fmt.Sprintf("Unknown time zone: %s", zone)
+				return rsp, nil
+			}
+		}
+	}
+
+	err := z.db.Update(func(tx model.Transaction) error {
+
+		feed := model.F.GetByURL(tx, req.Schedule.URL)
+		if feed == nil || model.S.GetForUser(tx, user.ID).ByFeedID()[feed.ID] == nil {
+			rsp.Status = msg.StatusNotFound
+			return nil
+		}
+
+		// feeds are shared by all subscribers, only admins may stop, delay or restrict fetching or change the zones
+		rescheduled := interval != feed.Interval ||
+			strings.Join(windows.Strings(), ";") != strings.Join(feed.Windows.Strings(), ";") ||
+			req.Schedule.TimeZone != feed.TimeZone || req.Schedule.DateZone != feed.DateZone
+		if (rescheduled || req.Schedule.Paused != feed.Paused) && !user.HasRole(auth.RoleAdmin) {
+			rsp.Status = msg.StatusErr
+			rsp.Message = "Only admins may pause a feed or change its fetch interval, windows or time zones"
+			return errEscape
+		}
+
+		feed.Interval = interval
+		feed.Windows = windows
+		feed.TimeZone = req.Schedule.TimeZone
+		feed.DateZone = req.Schedule.DateZone
+		feed.Paused = req.Schedule.Paused
+
+		// the proxy sees the requests of all subscribers, the unchanged proxy is sent back without credentials
+		if req.Schedule.Proxy != fetch.RedactProxy(feed.Proxy) {
+			if !user.HasRole(auth.RoleAdmin) {
+				rsp.Status = msg.StatusErr
+				rsp.Message = "Only admins may change the proxy of a feed"
+				return errEscape
+			}
+			if err := fetch.CheckProxy(req.Schedule.Proxy); err != nil {
+				rsp.Status = msg.StatusErr
+				rsp.Message = err.Error()
+				return errEscape
+			}
+			feed.Proxy = req.Schedule.Proxy
+		}
+
+		if req.Schedule.Insecure != feed.Insecure {
+			if !user.HasRole(auth.RoleAdmin) {
+				rsp.Status = msg.StatusErr
+				rsp.Message = "Only admins may change the certificate verification of a feed"
+				return errEscape
+			}
+			feed.Insecure = req.Schedule.Insecure
+		}
+
+		// reschedule at the next permissible time, an unchanged schedule does not bypass the refresh limit
+		if rescheduled {
+			feed.AdjustFetchTime(0)
+			feed.ApplyFetchWindows()
+		}
+
+		if err := model.F.Save(tx, feed); err != nil {
+			return err
+		}
+
+		rsp.Schedule = toFeedSchedule(feed)
+
+		return nil
+
+	})
+
+	if err != nil && err != errEscape {
+		rsp.Status = msg.StatusErr
+		rsp.Message = err.Error()
+	}
+
+	return rsp, nil
+
+}
+
+// FeedRefresh fetches a subscribed feed, or all subscribed feeds in error, immediately.
+func (z *API) FeedRefresh(ctx context.Context, req *msg.FeedRefreshRequest) (*msg.FeedRefreshResponse, error) {
+
+	user := ctx.Value("user").(*auth.User)
+
+	rsp := &msg.FeedRefreshResponse{}
+
+	if z.fetcher == nil {
+		rsp.Status = msg.StatusErr
+		rsp.Message = "Fetcher not available"
+		return rsp, nil
+	}
+
+	if req.URL == "" && !req.AllErrors {
+		rsp.Status = msg.StatusErr
+		rsp.Message = "Missing feed URL"
+		return rsp, nil
+	}
+
+	var feeds model.Feeds
+	err := z.db.Select(func(tx model.Transaction) error {
+		subscriptions := model.S.GetForUser(tx, user.ID)
+		if req.AllErrors {
+			for _, feed := range model.F.GetBySubscriptions(tx, subscriptions) {
+				if feed.Status != "" && feed.Status != model.FetchResultOK {
+					feeds = append(feeds, feed)
+				}
+			}
+		} else if feed := model.F.GetByURL(tx, req.URL); feed != nil && subscriptions.ByFeedID()[feed.ID] != nil {
+			feeds = append(feeds, feed)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if len(feeds) == 0 && !req.AllErrors {
+		rsp.Status = msg.StatusNotFound
+		return rsp, nil
+	}
+
+	results := make([]*msg.FeedRefreshResult, len(feeds))
+	var wg sync.WaitGroup
+	for i, feed := range feeds {
+		result := &msg.FeedRefreshResult{URL: feed.URL}
+		results[i] = result
+		if !z.limiter.Allow(user.ID) {
+			rsp.Status = msg.StatusTooMany
+			rsp.Message = fmt.Sprintf("Limit of %d refreshes per %s exceeded", refreshLimit, refreshLimitPeriod.String())
+			result.Error = msg.StatusText(msg.StatusTooMany)
+			continue
+		}
+		wg.Add(1)
+		go func(feed *model.Feed, result *msg.FeedRefreshResult) {
+			defer wg.Done()
+			transmission, err := z.fetcher.Refresh(ctx, feed)
+			if err != nil {
+				result.Error = err.Error()
+				return
+			}
+			result.Result = transmission.Result
+			result.ResultMessage = transmission.ResultMessage
+			result.StatusCode = transmission.StatusCode
+			result.Duration = transmission.Duration
+			result.ItemCount = transmission.ItemCount
+			result.NewItems = transmission.NewItems
+		}(feed, result)
+	}
+	wg.Wait()
+
+	rsp.Results = results
+
+	return rsp, nil
+
+}
+
+// FeedScrapePreview fetches a web page and returns the items extracted by the given rules, without saving anything.
+func (z *API) FeedScrapePreview(ctx context.Context, req *msg.FeedScrapePreviewRequest) (*msg.FeedScrapePreviewResponse, error) {
+
+	rsp := &msg.FeedScrapePreviewResponse{}
+
+	if z.fetcher == nil {
+		rsp.Status = msg.StatusErr
+		rsp.Message = "Fetcher not available"
+		return rsp, nil
+	}
+
+	// only web pages are scraped, never local files or commands
+	if !isWebURL(req.URL) {
+		rsp.Status = msg.StatusErr
+		rsp.Message = fmt.Sprintf("Not a web page: %s", req.URL)
+		return rsp, nil
+	}
+
+	rules := toScrapeRules(req.Rules)
+	if _, err := scraper.New(rules); err != nil {
+		rsp.Status = msg.StatusErr
+		rsp.Message = err.Error()
+		return rsp, nil
+	}
+
+	feed := model.F.New(req.URL)
+	feed.Scrape = rules
+
+	harvest, err := z.fetcher.Preview(ctx, feed)
+	if err != nil {
+		rsp.Status = msg.StatusErr
+		rsp.Message = err.Error()
+		return rsp, nil
+	}
+
+	rsp.Result = harvest.Transmission.Result
+	rsp.ResultMessage = harvest.Transmission.ResultMessage
+	rsp.StatusCode = harvest.Transmission.StatusCode
+	rsp.Title = harvest.Feed.Title
+	for _, item := range harvest.Items {
+		rsp.Items = append(rsp.Items, &msg.ScrapedItem{
+			GUID:    item.GUID,
+			URL:     item.URL,
+			Title:   item.Title,
+			Created: item.Created,
+			Content: item.Content,
+		})
+	}
+
+	return rsp, nil
+
+}
+
+// FeedValidate fetches a subscribed feed and reports the problems found parsing it, without saving anything.
+func (z *API) FeedValidate(ctx context.Context, req *msg.FeedValidateRequest) (*msg.FeedValidateResponse, error) {
+
+	user := ctx.Value("user").(*auth.User)
+
+	rsp := &msg.FeedValidateResponse{}
+
+	if z.fetcher == nil {
+		rsp.Status = msg.StatusErr
+		rsp.Message = "Fetcher not available"
+		return rsp, nil
+	}
+
+	var feed *model.Feed
+	err := z.db.Select(func(tx model.Transaction) error {
+		if f := model.F.GetByURL(tx, req.URL); f != nil && model.S.GetForUser(tx, user.ID).ByFeedID()[f.ID] != nil {
+			feed = f
+		}
+		return nil
+	})
+	if err != nil {
+		rsp.Status = msg.StatusErr
+		rsp.Message = err.Error()
+		return rsp, nil
+	}
+	if feed == nil {
+		rsp.Status = msg.StatusNotFound
+		return rsp, nil
+	}
+
+	harvest, err := z.fetcher.Validate(ctx, feed)
+	if err != nil {
+		rsp.Status = msg.StatusErr
+		rsp.Message = err.Error()
+		return rsp, nil
+	}
+
+	report := &msg.FeedReport{
+		URL:           feed.URL,
+		Result:        harvest.Transmission.Result,
+		ResultMessage: harvest.Transmission.ResultMessage,
+		StatusCode:    harvest.Transmission.StatusCode,
+		ContentType:   harvest.Transmission.ContentType,
+		Flavor:        harvest.Transmission.Flavor,
+		Title:         harvest.Transmission.Title,
+		SiteURL:       harvest.Feed.SiteURL,
+		Warnings:      harvest.Warnings,
+	}
+	for _, item := range harvest.Items {
+		report.Entries = append(report.Entries, &msg.FeedReportEntry{
+			ID:      item.GUID,
+			Title:   item.Title,
+			URL:     item.URL,
+			Created: item.Created,
+			Updated: item.Updated,
+		})
+	}
+	rsp.Report = report
+
+	return rsp, nil
+
+}
+
+func toScrapeRules(rules *msg.ScrapeRules) *model.ScrapeRules {
+	if rules == nil {
+		return nil
+	}
+	return &model.ScrapeRules{
+		Item:       rules.Item,
+		Title:      rules.Title,
+		Link:       rules.Link,
+		Date:       rules.Date,
+		DateFormat: rules.DateFormat,
+		Content:    rules.Content,
+	}
+}
+
+func toFeedSchedule(feed *model.Feed) *msg.FeedSchedule {
+	return &msg.FeedSchedule{
+		URL:          feed.URL,
+		IntervalSecs: int(feed.Interval / time.Second),
+		Windows:      feed.Windows.Strings(),
+		TimeZone:     feed.TimeZone,
+		DateZone:     feed.DateZone,
+		Paused:       feed.Paused,
+		Proxy:        fetch.RedactProxy(feed.Proxy),
+		Insecure:     feed.Insecure,
+		NextFetch:    feed.NextFetch,
+	}
+}
+
+// isWebURL tests if the URL is an absolute http or https URL.
+func isWebURL(rawurl string) bool {
+	u, err := url.Parse(rawurl)
+	if err != nil || u.Host == "" {
+		return false
+	}
+	scheme := strings.ToLower(u.Scheme)
+	return scheme == "http" || scheme == "https"
+}
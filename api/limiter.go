@@ -0,0 +1,48 @@
+package api
+
+import (
+	"sync"
+	"time"
+)
+
+// rateLimiter allows a maximum number of events per key within a sliding period.
+type rateLimiter struct {
+	sync.Mutex
+	limit  int
+	period time.Duration
+	events map[string][]time.Time
+}
+
+func newRateLimiter(limit int, period time.Duration) *rateLimiter {
+	return &rateLimiter{
+		limit:  limit,
+		period: period,
+		events: make(map[string][]time.Time),
+	}
+}
+
+// Allow records an event for the given key if the limit has not yet been reached.
+func (z *rateLimiter) Allow(key string) bool {
+
+	z.Lock()
+	defer z.Unlock()
+
+	now := time.Now()
+	min := now.Add(-z.period)
+
+	recent := []time.Time{}
+	for _, t := range z.events[key] {
+		if t.After(min) {
+			recent = append(recent, t)
+		}
+	}
+
+	if len(recent) >= z.limit {
+		z.events[key] = recent
+		return false
+	}
+
+	z.events[key] = append(recent, now)
+	return true
+
+}
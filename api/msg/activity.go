@@ -0,0 +1,38 @@
+package msg
+
+import (
+	"time"
+)
+
+// ActivityRequest defines the request for the live state of the feed pipeline
+type ActivityRequest struct{}
+
+// ActivityResponse returns the live state of the poll, fetch and reap services.
+type ActivityResponse struct {
+	Status     int               `json:"status"`
+	Message    string            `json:"message,omitempty"`
+	Polling    bool              `json:"polling,omitempty"`    // a poll is in progress
+	LastPoll   time.Time         `json:"lastPoll,omitempty"`   // start of the most recent poll
+	PollQueue  int               `json:"pollQueue"`            // polled feeds waiting for a fetcher
+	Workers    []*WorkerActivity `json:"workers,omitempty"`    // fetch workers
+	ReapQueue  int               `json:"reapQueue"`            // harvests waiting for the reaper
+	LastReaped time.Time         `json:"lastReaped,omitempty"` // completion of the most recent harvest
+	PerMinute  int               `json:"perMinute"`            // harvests reaped during the last minute
+	Reaped     int64             `json:"reaped"`               // harvests reaped since start
+	Batches    int64             `json:"batches"`              // transactions committed since start
+	LastBatch  int               `json:"lastBatch"`            // harvests in the most recent transaction
+	AvgBatch   float64           `json:"avgBatch"`             // average harvests per transaction
+	LastCommit float64           `json:"lastCommit"`           // duration of the most recent commit in milliseconds
+	AvgCommit  float64           `json:"avgCommit"`            // average commit duration in milliseconds
+	Isolated   int64             `json:"isolated"`             // harvests saved alone after their batch failed
+	Failed     int64             `json:"failed"`               // harvests which could not be saved
+}
+
+// WorkerActivity defines the state of a fetch worker
+type WorkerActivity struct {
+	ID     int       `json:"id"`
+	State  string    `json:"state"` // idle, fetching or waiting for the reaper
+	Since  time.Time `json:"since"`
+	FeedID string    `json:"feedId,omitempty"`
+	URL    string    `json:"url,omitempty"`
+}
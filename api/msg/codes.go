@@ -5,6 +5,8 @@ const (
 	StatusOK       = 0
 	StatusErr      = 1
 	StatusNotFound = 2
+	StatusTooMany  = 3
+	StatusChoose   = 4
 )
 
 // StatusText retrieves the status text for the given status code
@@ -16,6 +18,10 @@ func StatusText(code int) string {
 		return "Error"
 	case StatusNotFound:
 		return "Not Found"
+	case StatusTooMany:
+		return "Too Many Requests"
+	case StatusChoose:
+		return "Multiple Choices"
 	}
 	return ""
 }
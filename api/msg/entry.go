@@ -9,17 +9,31 @@ type Entries []*Entry
 
 // Entry defines an entry in a subscription
 type Entry struct {
-	Subscription string    `json:"subscription,omitempty"`
-	GUID         string    `json:"guid,omitempty"`
-	Title        string    `json:"title,omitempty"`
-	Updated      time.Time `json:"updated,omitempty"`
-	Read         bool      `json:"read,omitempty"`
-	Star         bool      `json:"star,omitempty"`
+	Subscription string       `json:"subscription,omitempty"`
+	GUID         string       `json:"guid,omitempty"`
+	Title        string       `json:"title,omitempty"`
+	Updated      time.Time    `json:"updated,omitempty"`
+	Categories   []string     `json:"categories,omitempty"`
+	Read         bool         `json:"read,omitempty"`
+	Star         bool         `json:"star,omitempty"`
+	Content      string       `json:"content,omitempty"` // full article if extracted, otherwise the feed content
+	Enclosures   []*Enclosure `json:"enclosures,omitempty"`
+	Episode      string       `json:"episode,omitempty"`
+	Image        string       `json:"image,omitempty"`
+}
+
+// Enclosure defines a media file attached to an entry
+type Enclosure struct {
+	URL          string `json:"url"`
+	Type         string `json:"type,omitempty"`
+	Length       int64  `json:"length,omitempty"`
+	DurationSecs int    `json:"durationSecs,omitempty"`
 }
 
 // EntryListRequest defines the request to list entries
 type EntryListRequest struct {
 	Subscription string `json:"subscription,omitempty"`
+	Category     string `json:"category,omitempty"` // only entries having this category, ignoring case
 	// TODO: group
 	// TODO: min/max times
 	// TODO: unread only
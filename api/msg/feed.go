@@ -0,0 +1,210 @@
+package msg
+
+import (
+	"time"
+)
+
+// FeedDetails describes a feed as declared by its publisher and as last fetched
+type FeedDetails struct {
+	URL              string    `json:"url"`
+	SiteURL          string    `json:"siteURL,omitempty"`
+	SelfURL          string    `json:"selfURL,omitempty"`
+	Title            string    `json:"title,omitempty"`
+	Icon             string    `json:"icon,omitempty"`
+	Language         string    `json:"language,omitempty"`
+	Categories       []string  `json:"categories,omitempty"`
+	ItemCategories   []string  `json:"itemCategories,omitempty"` // of the stored items, in lower case
+	Hub              string    `json:"hub,omitempty"`
+	HubActive        bool      `json:"hubActive,omitempty"`
+	HubVerified      time.Time `json:"hubVerified,omitempty"`
+	Status           string    `json:"status,omitempty"`
+	StatusMessage    string    `json:"statusMessage,omitempty"`
+	StatusSince      time.Time `json:"statusSince,omitempty"`
+	LastUpdated      time.Time `json:"lastUpdated,omitempty"`
+	NextFetch        time.Time `json:"nextFetch,omitempty"`
+	TTLSecs          int       `json:"ttlSecs,omitempty"`          // rss ttl
+	UpdatePeriodSecs int       `json:"updatePeriodSecs,omitempty"` // syndication module update period
+	SkipHours        []int     `json:"skipHours,omitempty"`        // hours of the day in UTC
+	SkipDays         []string  `json:"skipDays,omitempty"`         // days of the week in UTC
+	Flavor           string    `json:"flavor,omitempty"`           // of the last parsed transmission
+	Generator        string    `json:"generator,omitempty"`        // of the last parsed transmission
+	Published        time.Time `json:"published,omitempty"`        // feed-level date of the last parsed transmission
+	BadDates         []string  `json:"badDates,omitempty"`         // unparseable dates of the last parsed transmission
+}
+
+// FeedDetailsRequest defines the request for the details of a feed
+type FeedDetailsRequest struct {
+	URL string `json:"url,omitempty"`
+}
+
+// FeedDetailsResponse returns the details of a feed
+type FeedDetailsResponse struct {
+	Status  int          `json:"status"`
+	Message string       `json:"message,omitempty"`
+	Details *FeedDetails `json:"details,omitempty"`
+}
+
+// FeedFaviconRequest defines the request for the icon of a feed
+type FeedFaviconRequest struct {
+	URL string `json:"url,omitempty"`
+}
+
+// FeedFaviconResponse returns the icon of a feed as a normalized image.
+type FeedFaviconResponse struct {
+	Status  int       `json:"status"`
+	Message string    `json:"message,omitempty"`
+	Type    string    `json:"type,omitempty"` // MIME type of Data
+	Data    []byte    `json:"data,omitempty"` // base64 encoded in JSON
+	Source  string    `json:"source,omitempty"`
+	Updated time.Time `json:"updated,omitempty"`
+}
+
+// FeedFrequencyRequest defines the request for the learned publishing frequency of a feed
+type FeedFrequencyRequest struct {
+	URL string `json:"url,omitempty"`
+}
+
+// FeedFrequencyResponse returns the learned publishing frequency of a feed.
+// Histogram contains the average number of items per week for each hour of the week,
+// in UTC starting with Sunday 00:00.
+type FeedFrequencyResponse struct {
+	Status     int       `json:"status"`
+	Message    string    `json:"message,omitempty"`
+	Histogram  []float64 `json:"histogram,omitempty"`
+	Since      time.Time `json:"since,omitempty"`
+	NextUpdate time.Time `json:"nextUpdate,omitempty"`
+	NextFetch  time.Time `json:"nextFetch,omitempty"`
+}
+
+// FeedSchedule defines the manual fetch settings of a feed
+type FeedSchedule struct {
+	URL          string    `json:"url,omitempty"`
+	IntervalSecs int       `json:"intervalSecs,omitempty"` // fixed fetch interval, zero for automatic
+	Windows      []string  `json:"windows,omitempty"`      // for example "mon-fri 06:00-20:00"
+	TimeZone     string    `json:"timezone,omitempty"`
+	DateZone     string    `json:"dateZone,omitempty"` // time zone of feed dates without a zone
+	Paused       bool      `json:"paused,omitempty"`
+	Proxy        string    `json:"proxy,omitempty"`     // overrides the fetcher proxy, shown without credentials
+	Insecure     bool      `json:"insecure,omitempty"`  // skip verification of the TLS certificate
+	NextFetch    time.Time `json:"nextFetch,omitempty"` // read only
+}
+
+// FeedScheduleRequest defines the request to retrieve the fetch settings of a feed
+type FeedScheduleRequest struct {
+	URL string `json:"url,omitempty"`
+}
+
+// FeedScheduleResponse returns the fetch settings of a feed
+type FeedScheduleResponse struct {
+	Status   int           `json:"status"`
+	Message  string        `json:"message,omitempty"`
+	Schedule *FeedSchedule `json:"schedule,omitempty"`
+}
+
+// FeedScheduleUpdateRequest defines the request to replace the fetch settings of a feed
+type FeedScheduleUpdateRequest struct {
+	Schedule *FeedSchedule `json:"schedule,omitempty"`
+}
+
+// FeedScheduleUpdateResponse returns the status of a schedule update request
+type FeedScheduleUpdateResponse struct {
+	Status   int           `json:"status"`
+	Message  string        `json:"message,omitempty"`
+	Schedule *FeedSchedule `json:"schedule,omitempty"`
+}
+
+// FeedRefreshRequest defines the request to fetch feeds immediately
+type FeedRefreshRequest struct {
+	URL       string `json:"url,omitempty"`
+	AllErrors bool   `json:"allErrors,omitempty"` // refresh all subscribed feeds with an error status
+}
+
+// FeedRefreshResult summarizes the transmission of a refreshed feed
+type FeedRefreshResult struct {
+	URL           string        `json:"url"`
+	Result        string        `json:"result,omitempty"`
+	ResultMessage string        `json:"resultMessage,omitempty"`
+	StatusCode    int           `json:"statusCode,omitempty"`
+	Duration      time.Duration `json:"duration,omitempty"`
+	ItemCount     int           `json:"itemCount,omitempty"`
+	NewItems      int           `json:"newItems,omitempty"`
+	Error         string        `json:"error,omitempty"` // set if the refresh did not complete
+}
+
+// FeedRefreshResponse returns the results of a refresh request
+type FeedRefreshResponse struct {
+	Status  int                  `json:"status"`
+	Message string               `json:"message,omitempty"`
+	Results []*FeedRefreshResult `json:"results,omitempty"`
+}
+
+// FeedReport describes a feed as parsed in diagnostic mode
+type FeedReport struct {
+	URL           string             `json:"url"`
+	Result        string             `json:"result,omitempty"`
+	ResultMessage string             `json:"resultMessage,omitempty"`
+	StatusCode    int                `json:"statusCode,omitempty"`
+	ContentType   string             `json:"contentType,omitempty"`
+	Flavor        string             `json:"flavor,omitempty"`
+	Title         string             `json:"title,omitempty"`
+	SiteURL       string             `json:"siteURL,omitempty"`
+	Warnings      []string           `json:"warnings,omitempty"`
+	Entries       []*FeedReportEntry `json:"entries,omitempty"`
+}
+
+// FeedReportEntry is an entry of a FeedReport, the ID is a hash of the content if the entry has none
+type FeedReportEntry struct {
+	ID      string    `json:"id"`
+	Title   string    `json:"title,omitempty"`
+	URL     string    `json:"url,omitempty"`
+	Created time.Time `json:"created,omitempty"`
+	Updated time.Time `json:"updated,omitempty"`
+}
+
+// FeedValidateRequest defines the request to fetch a feed and report the problems found parsing it
+type FeedValidateRequest struct {
+	URL string `json:"url,omitempty"`
+}
+
+// FeedValidateResponse returns the report of a FeedValidateRequest
+type FeedValidateResponse struct {
+	Status  int         `json:"status"`
+	Message string      `json:"message,omitempty"`
+	Report  *FeedReport `json:"report,omitempty"`
+}
+
+// ScrapeRules define how items are extracted from a web page which has no feed
+type ScrapeRules struct {
+	Item       string `json:"item"`
+	Title      string `json:"title,omitempty"`
+	Link       string `json:"link,omitempty"`
+	Date       string `json:"date,omitempty"`
+	DateFormat string `json:"dateFormat,omitempty"`
+	Content    string `json:"content,omitempty"`
+}
+
+// ScrapedItem is an item extracted from a web page
+type ScrapedItem struct {
+	GUID    string    `json:"guid"`
+	URL     string    `json:"url"`
+	Title   string    `json:"title,omitempty"`
+	Created time.Time `json:"created,omitempty"`
+	Content string    `json:"content,omitempty"`
+}
+
+// FeedScrapePreviewRequest defines the request to apply scrape rules to a web page without saving them
+type FeedScrapePreviewRequest struct {
+	URL   string       `json:"url"`
+	Rules *ScrapeRules `json:"rules"`
+}
+
+// FeedScrapePreviewResponse returns the items extracted by a FeedScrapePreviewRequest
+type FeedScrapePreviewResponse struct {
+	Status        int            `json:"status"`
+	Message       string         `json:"message,omitempty"`
+	Result        string         `json:"result,omitempty"`
+	ResultMessage string         `json:"resultMessage,omitempty"`
+	StatusCode    int            `json:"statusCode,omitempty"`
+	Title         string         `json:"title,omitempty"`
+	Items         []*ScrapedItem `json:"items,omitempty"`
+}
@@ -16,18 +16,34 @@ type Subscription struct {
 	Added    time.Time `json:"added,omitempty"`
 	AutoRead bool      `json:"autoread,omitempty"`
 	AutoStar bool      `json:"autostar,omitempty"`
+	FullText bool      `json:"fulltext,omitempty"`
+	// new entries having any of these categories are marked read or starred
+	AutoReadCategories []string `json:"autoreadCategories,omitempty"`
+	AutoStarCategories []string `json:"autostarCategories,omitempty"`
 }
 
 // SubscriptionAddUpdateRequest defines an add/update subscription request
 type SubscriptionAddUpdateRequest struct {
 	AddGroups    bool          `json:"addGroups"`
 	Subscription *Subscription `json:"subscription"`
+	Scrape       *ScrapeRules  `json:"scrape,omitempty"`   // subscribe to a web page without a feed, skips feed discovery
+	Backfill     int           `json:"backfill,omitempty"` // number of older items to import from the feed archive, capped by the server
 }
 
-// SubscriptionAddUpdateResponse defines the response to a SubscriptionAddUpdateRequest
+// SubscriptionAddUpdateResponse defines the response to a SubscriptionAddUpdateRequest.
+// If the subscription URL is a web page advertising several feeds, the status is StatusChoose
+// and the candidates are returned so that the request can be repeated with one of them.
 type SubscriptionAddUpdateResponse struct {
-	Status  int    `json:"status"`
-	Message string `json:"message,omitempty"`
+	Status     int              `json:"status"`
+	Message    string           `json:"message,omitempty"`
+	URL        string           `json:"url,omitempty"` // the discovered feed URL
+	Candidates []*FeedCandidate `json:"candidates,omitempty"`
+}
+
+// FeedCandidate defines a feed discovered at a web page
+type FeedCandidate struct {
+	URL   string `json:"url"`
+	Title string `json:"title,omitempty"`
 }
 
 // SubscriptionListRequest defines the request to add a subscription
@@ -3,6 +3,7 @@ package api
 import (
 	"fmt"
 	"net/http"
+	"sync"
 
 	"github.com/kwo/rakewire/auth"
 	"github.com/kwo/rakewire/model"
@@ -59,6 +60,27 @@ func (z *API) opmlImport(ctx context.Context, w http.ResponseWriter, r *http.Req
 		return
 	}
 
+	unknown, err := z.unknownOutlines(opmldoc.Body.Outlines)
+	if err != nil {
+		message := fmt.Sprintf("Error importing OPML: %s\n", err.Error())
+		log.Debugf("%s", message)
+		w.Header().Set(hContentType, "text/plain")
+		w.WriteHeader(http.StatusInternalServerError)
+		w.Write([]byte(message))
+		return
+	}
+
+	for _, url := range unknown {
+		if !mayCreateFeed(user, url) {
+			message := fmt.Sprintf("%s: %s\n", msgLocalFeed, url)
+			log.Debugf("%s", message)
+			w.Header().Set(hContentType, "text/plain")
+			w.WriteHeader(http.StatusForbidden)
+			w.Write([]byte(message))
+			return
+		}
+	}
+
 	err = z.db.Update(func(tx model.Transaction) error {
 		if u := model.U.GetByUsername(tx, user.Name); u != nil {
 			return opml.Import(tx, u.ID, opmldoc)
@@ -75,8 +97,124 @@ func (z *API) opmlImport(ctx context.Context, w http.ResponseWriter, r *http.Req
 		return
 	}
 
+	// discovery takes too long for the request, unknown URLs are imported as given and replaced afterwards
+	if z.fetcher != nil && len(unknown) > 0 {
+		if err := z.fetcher.Background(func(ctx context.Context) {
+			z.discoverOutlines(ctx, user.ID, unknown)
+		}); err != nil {
+			log.Debugf("Cannot discover imported feeds: %s", err.Error())
+		}
+	}
+
 	w.Header().Set(hContentType, "text/plain")
 	w.WriteHeader(http.StatusOK)
 	w.Write([]byte("OK\n"))
 
 }
+
+// unknownOutlines returns the URLs of the outlines which are not yet known as feeds.
+func (z *API) unknownOutlines(outlines opml.Outlines) ([]string, error) {
+
+	var result []string
+	seen := make(map[string]bool)
+
+	err := z.db.Select(func(tx model.Transaction) error {
+		var walk func(outlines opml.Outlines)
+		walk = func(outlines opml.Outlines) {
+			for _, outline := range outlines {
+				walk(outline.Outlines)
+				if len(outline.XMLURL) == 0 || seen[outline.XMLURL] {
+					continue
+				}
+				seen[outline.XMLURL] = true
+				if model.F.GetByURL(tx, outline.XMLURL) == nil {
+					result = append(result, outline.XMLURL)
+				}
+			}
+		}
+		walk(outlines)
+		return nil
+	})
+
+	return result, err
+
+}
+
+// discoverOutlines runs after an import, moving the subscriptions of the user to the feed discovered at each URL,
+// if exactly one was found and it differs from the URL. Otherwise the URL is kept as imported.
+// Discovery ends when the context is cancelled.
+func (z *API) discoverOutlines(ctx context.Context, userID string, urls []string) {
+
+	const maxConcurrent = 8
+
+	var wg sync.WaitGroup
+	semaphore := make(chan struct{}, maxConcurrent)
+
+	for _, url := range urls {
+		if ctx.Err() != nil {
+			break
+		}
+		wg.Add(1)
+		semaphore <- struct{}{}
+		go func(url string) {
+			defer wg.Done()
+			defer func() { <-semaphore }()
+			candidates, err := z.fetcher.Discover(ctx, url)
+			if err != nil {
+				log.Debugf("Cannot discover feed %s: %s", url, err.Error())
+				return
+			}
+			if len(candidates) != 1 || candidates[0].URL == url || ctx.Err() != nil {
+				return
+			}
+			if err := z.moveSubscriptions(userID, url, candidates[0].URL); err != nil {
+				log.Infof("Cannot move subscription from %s to %s: %s", url, candidates[0].URL, err.Error())
+			}
+		}(url)
+	}
+
+	wg.Wait()
+
+}
+
+// moveSubscriptions replaces the subscriptions of the user to the feed at the given URL with subscriptions to the feed at the other URL.
+func (z *API) moveSubscriptions(userID, fromURL, toURL string) error {
+
+	return z.db.Update(func(tx model.Transaction) error {
+
+		from := model.F.GetByURL(tx, fromURL)
+		if from == nil {
+			return nil
+		}
+		subscriptions := model.S.GetForUser(tx, userID).ByFeedID()
+		if len(subscriptions[from.ID]) == 0 {
+			return nil
+		}
+
+		to := model.F.GetByURL(tx, toURL)
+		if to == nil {
+			to = model.F.New(toURL)
+			if err := model.F.Save(tx, to); err != nil {
+				return err
+			}
+		}
+
+		for _, subscription := range subscriptions[from.ID] {
+			if err := model.S.Delete(tx, subscription.GetID()); err != nil {
+				return err
+			}
+			if len(subscriptions[to.ID]) > 0 {
+				continue // already subscribed to the discovered feed
+			}
+			subscription.FeedID = to.ID
+			if err := model.S.Save(tx, subscription); err != nil {
+				return err
+			}
+			subscriptions[to.ID] = append(subscriptions[to.ID], subscription)
+		}
+
+		return nil
+
+	})
+
+}
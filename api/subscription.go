@@ -1,15 +1,23 @@
 package api
 
 import (
+	"fmt"
 	"strings"
 	"time"
 
 	"github.com/kwo/rakewire/api/msg"
 	"github.com/kwo/rakewire/auth"
+	"github.com/kwo/rakewire/feedparser"
+	"github.com/kwo/rakewire/fetch"
 	"github.com/kwo/rakewire/model"
+	"github.com/kwo/rakewire/scraper"
 	"golang.org/x/net/context"
 )
 
+const (
+	msgLocalFeed = "Only admins may subscribe to local files and commands"
+)
+
 // SubscriptionAddUpdate adds or updates a subscription
 func (z *API) SubscriptionAddUpdate(ctx context.Context, req *msg.SubscriptionAddUpdateRequest) (*msg.SubscriptionAddUpdateResponse, error) {
 
@@ -17,11 +25,85 @@ func (z *API) SubscriptionAddUpdate(ctx context.Context, req *msg.SubscriptionAd
 
 	rsp := &msg.SubscriptionAddUpdateResponse{}
 
-	err := z.db.Update(func(tx model.Transaction) error {
+	if req.Subscription == nil {
+		rsp.Status = msg.StatusErr
+		rsp.Message = "Missing subscription"
+		return rsp, nil
+	}
+
+	rules := toScrapeRules(req.Scrape)
+	if rules != nil {
+		if _, err := scraper.New(rules); err != nil {
+			rsp.Status = msg.StatusErr
+			rsp.Message = err.Error()
+			return rsp, nil
+		}
+	}
+
+	var candidates []*feedparser.FeedLink
+	var err error
+	if rules == nil {
+		candidates, err = z.discoverFeeds(ctx, req.Subscription.URL)
+	}
+	if err == fetch.ErrNoFeeds {
+		rsp.Status = msg.StatusErr
+		rsp.Message = fmt.Sprintf("Cannot discover feed: %s", err.Error())
+		return rsp, nil
+	} else if err != nil {
+		// the site may be down for now, store the URL as given for the poller to retry
+		log.Debugf("Cannot discover feed %s: %s", req.Subscription.URL, err.Error())
+		candidates = nil
+	}
+	if len(candidates) > 1 {
+		rsp.Status = msg.StatusChoose
+		rsp.Message = fmt.Sprintf("Found %d feeds at %s", len(candidates), req.Subscription.URL)
+		for _, candidate := range candidates {
+			rsp.Candidates = append(rsp.Candidates, &msg.FeedCandidate{URL: candidate.URL, Title: candidate.Title})
+		}
+		return rsp, nil
+	}
+	var candidateTitle string
+	if len(candidates) == 1 {
+		req.Subscription.URL = candidates[0].URL
+		candidateTitle = candidates[0].Title
+	}
+	rsp.URL = req.Subscription.URL
+
+	var feed *model.Feed
+	var created bool
+	err = z.db.Update(func(tx model.Transaction) error {
 
-		feed := model.F.GetByURL(tx, req.Subscription.URL)
+		feed = model.F.GetByURL(tx, req.Subscription.URL)
 		if feed == nil {
+			if !mayCreateFeed(user, req.Subscription.URL) {
+				rsp.Status = msg.StatusErr
+				rsp.Message = msgLocalFeed
+				return errEscape
+			}
 			feed = model.F.New(req.Subscription.URL)
+			feed.Scrape = rules
+			if err := model.F.Save(tx, feed); err != nil {
+				return err
+			}
+		} else if rules != nil && (feed.Scrape == nil || *rules != *feed.Scrape) {
+			// feeds are shared, only admins may change the rules of a scraped page and parsed feeds remain feeds
+			if feed.Scrape == nil {
+				rsp.Status = msg.StatusErr
+				rsp.Message = fmt.Sprintf("%s is already subscribed as a feed and cannot be scraped", feed.URL)
+				return errEscape
+			}
+			if !user.HasRole(auth.RoleAdmin) {
+				rsp.Status = msg.StatusErr
+				rsp.Message = "Only admins may change the scrape rules of a page"
+				return errEscape
+			}
+			// new rules: fetch the page again even if it has not been modified
+			feed.Scrape = rules
+			feed.ETag = ""
+			feed.LastModified = time.Time{}
+			feed.BodyHash = ""
+			feed.AdjustFetchTime(0)
+			feed.ApplyFetchWindows()
 			if err := model.F.Save(tx, feed); err != nil {
 				return err
 			}
@@ -34,18 +116,25 @@ func (z *API) SubscriptionAddUpdate(ctx context.Context, req *msg.SubscriptionAd
 		}
 		if subscription == nil {
 			subscription = model.S.New(user.ID, feed.ID)
+			created = true
 		}
 
 		subscription.Title = req.Subscription.Title
 		if len(subscription.Title) == 0 {
 			subscription.Title = feed.Title
 		}
+		if len(subscription.Title) == 0 {
+			subscription.Title = candidateTitle
+		}
 		if len(subscription.Title) == 0 {
 			subscription.Title = feed.URL
 		}
 		subscription.Notes = req.Subscription.Notes
 		subscription.AutoRead = req.Subscription.AutoRead
 		subscription.AutoStar = req.Subscription.AutoStar
+		subscription.AutoReadCategories = req.Subscription.AutoReadCategories
+		subscription.AutoStarCategories = req.Subscription.AutoStarCategories
+		subscription.FullText = req.Subscription.FullText
 		if subscription.Added.IsZero() {
 			subscription.Added = time.Now().Truncate(time.Second)
 		}
@@ -83,6 +172,15 @@ func (z *API) SubscriptionAddUpdate(ctx context.Context, req *msg.SubscriptionAd
 		rsp.Message = err.Error()
 	}
 
+	// only new subscriptions are backfilled, an update leaves the entries as they are
+	if err == nil && created && req.Backfill > 0 {
+		if z.fetcher == nil {
+			rsp.Message = "Cannot backfill: Fetcher not available"
+		} else if err := z.fetcher.Backfill(feed, user.ID, req.Backfill); err != nil {
+			rsp.Message = fmt.Sprintf("Cannot backfill: %s", err.Error())
+		}
+	}
+
 	return rsp, nil
 
 }
@@ -106,13 +204,16 @@ func (z *API) SubscriptionList(ctx context.Context, req *msg.SubscriptionListReq
 				groupNames = append(groupNames, group.Name)
 			}
 			subscription := &msg.Subscription{
-				URL:      feedsByID[sub.FeedID].URL,
-				Title:    sub.Title,
-				Groups:   groupNames,
-				Notes:    sub.Notes,
-				Added:    sub.Added,
-				AutoRead: sub.AutoRead,
-				AutoStar: sub.AutoStar,
+				URL:                feedsByID[sub.FeedID].URL,
+				Title:              sub.Title,
+				Groups:             groupNames,
+				Notes:              sub.Notes,
+				Added:              sub.Added,
+				AutoRead:           sub.AutoRead,
+				AutoStar:           sub.AutoStar,
+				FullText:           sub.FullText,
+				AutoReadCategories: sub.AutoReadCategories,
+				AutoStarCategories: sub.AutoStarCategories,
 			}
 			if len(req.Filter) == 0 || matchFilter(req.Filter, subscription) {
 				rsp.Subscriptions = append(rsp.Subscriptions, subscription)
@@ -127,6 +228,29 @@ func (z *API) SubscriptionList(ctx context.Context, req *msg.SubscriptionListReq
 
 }
 
+// discoverFeeds returns the feeds found at the given URL if it is not already a known feed.
+// No candidates are returned for known feeds or if no fetcher is available.
+func (z *API) discoverFeeds(ctx context.Context, url string) ([]*feedparser.FeedLink, error) {
+
+	if z.fetcher == nil {
+		return nil, nil
+	}
+
+	var known bool
+	if err := z.db.Select(func(tx model.Transaction) error {
+		known = model.F.GetByURL(tx, url) != nil
+		return nil
+	}); err != nil {
+		return nil, err
+	}
+	if known {
+		return nil, nil
+	}
+
+	return z.fetcher.Discover(ctx, url)
+
+}
+
 func matchFilter(filter string, subscription *msg.Subscription) bool {
 
 	match := false
@@ -190,3 +314,9 @@ func (z *API) SubscriptionUnsubscribe(ctx context.Context, req *msg.UnsubscribeR
 	return rsp, nil
 
 }
+
+// mayCreateFeed tests if the user may add a feed with the given URL,
+// local files and commands are read by the server and shared with all subscribers, so only admins may add them.
+func mayCreateFeed(user *auth.User, rawurl string) bool {
+	return user.HasRole(auth.RoleAdmin) || !fetch.IsLocal(rawurl)
+}
@@ -12,6 +12,11 @@ const (
 	schemeJWT   = "Bearer "
 )
 
+// Roles
+const (
+	RoleAdmin = "admin"
+)
+
 // package level errors
 var (
 	ErrBadHeader       = errors.New("Cannot parse authorization header")
@@ -0,0 +1,64 @@
+package remote
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/codegangsta/cli"
+	"github.com/kwo/rakewire/api/msg"
+)
+
+// Activity shows what the fetchers of a remote instance are doing
+func Activity(c *cli.Context) error {
+
+	req := &msg.ActivityRequest{}
+	rsp := &msg.ActivityResponse{}
+
+	if err := makeRequest(c, "activity", req, rsp); err != nil {
+		fmt.Printf("Error: %s\n", err.Error())
+		os.Exit(1)
+	}
+
+	if rsp.Status != 0 {
+		if len(rsp.Message) > 0 {
+			fmt.Printf("%s: %s\n", msg.StatusText(rsp.Status), rsp.Message)
+		} else {
+			fmt.Println(msg.StatusText(rsp.Status))
+		}
+		os.Exit(1)
+	}
+
+	now := time.Now()
+
+	fmt.Printf("last poll:   %s", fmtSince(rsp.LastPoll, now))
+	if rsp.Polling {
+		fmt.Print(" (polling)")
+	}
+	fmt.Println()
+	fmt.Printf("poll queue:  %d\n", rsp.PollQueue)
+	fmt.Printf("reap queue:  %d\n", rsp.ReapQueue)
+	fmt.Printf("last reaped: %s\n", fmtSince(rsp.LastReaped, now))
+	fmt.Printf("reaped:      %d/min, %d total\n", rsp.PerMinute, rsp.Reaped)
+	fmt.Printf("batches:     %d, last %d, avg %.1f harvests\n", rsp.Batches, rsp.LastBatch, rsp.AvgBatch)
+	fmt.Printf("commit:      last %.1fms, avg %.1fms\n", rsp.LastCommit, rsp.AvgCommit)
+	fmt.Printf("isolated:    %d, failed %d\n", rsp.Isolated, rsp.Failed)
+
+	if len(rsp.Workers) > 0 {
+		fmt.Println()
+		fmt.Printf("%3s %-8s %10s  %s\n", "id", "state", "since", "url")
+		for _, worker := range rsp.Workers {
+			fmt.Printf("%3d %-8s %10s  %s\n", worker.ID, worker.State, now.Sub(worker.Since).Truncate(time.Second).String(), worker.URL)
+		}
+	}
+
+	return nil
+
+}
+
+func fmtSince(t time.Time, now time.Time) string {
+	if t.IsZero() {
+		return "never"
+	}
+	return fmt.Sprintf("%s (%s ago)", t.Local().Format(time.RFC3339), now.Sub(t).Truncate(time.Second).String())
+}
@@ -3,6 +3,7 @@ package remote
 import (
 	"fmt"
 	"os"
+	"strings"
 	"time"
 
 	"github.com/codegangsta/cli"
@@ -17,6 +18,7 @@ func EntryList(c *cli.Context) error {
 
 	if c.NArg() == 1 {
 		req.Subscription = c.Args()[0]
+		req.Category = c.String("category")
 	} else {
 		cli.ShowCommandHelp(c, c.Command.Name)
 		os.Exit(1)
@@ -40,9 +42,15 @@ func EntryList(c *cli.Context) error {
 			return " "
 		}
 
-		fmt.Printf("%s %s %-25s %-80s %-20s\n", "u", "s", "updated", "title", "guid")
+		fmt.Printf("%s %s %-25s %-80s %-20s %s\n", "u", "s", "updated", "title", "guid", "categories")
 		for _, entry := range rsp.Entries {
-			fmt.Printf("%s %s %-25s %-80s %-20s\n", fmtBool(!entry.Read, "#"), fmtBool(entry.Star, "*"), entry.Updated.Format(time.RFC3339), entry.Title, entry.GUID)
+			fmt.Printf("%s %s %-25s %-80s %-20s %s\n", fmtBool(!entry.Read, "#"), fmtBool(entry.Star, "*"), entry.Updated.Format(time.RFC3339), entry.Title, entry.GUID, strings.Join(entry.Categories, ", "))
+			if c.Bool("enclosures") {
+				for _, enclosure := range entry.Enclosures {
+					duration := time.Duration(enclosure.DurationSecs) * time.Second
+					fmt.Printf("    %-20s %12d %10s %s\n", enclosure.Type, enclosure.Length, duration, enclosure.URL)
+				}
+			}
 		}
 
 	} else {
@@ -0,0 +1,442 @@
+package remote
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/codegangsta/cli"
+	"github.com/kwo/rakewire/api/msg"
+)
+
+// FeedDetails shows the metadata and publisher hints of a feed
+func FeedDetails(c *cli.Context) error {
+
+	var url string
+	if c.NArg() == 1 {
+		url = c.Args()[0]
+	} else {
+		cli.ShowCommandHelp(c, c.Command.Name)
+		os.Exit(1)
+	}
+
+	req := &msg.FeedDetailsRequest{URL: url}
+	rsp := &msg.FeedDetailsResponse{}
+
+	if err := makeRequest(c, "feeds/details", req, rsp); err != nil {
+		fmt.Printf("Error: %s\n", err.Error())
+		os.Exit(1)
+	}
+
+	if rsp.Status != 0 {
+		if len(rsp.Message) > 0 {
+			fmt.Printf("%s: %s\n", msg.StatusText(rsp.Status), rsp.Message)
+		} else {
+			fmt.Println(msg.StatusText(rsp.Status))
+		}
+		os.Exit(1)
+	}
+
+	details := rsp.Details
+
+	formatTime := func(t time.Time) string {
+		if t.IsZero() {
+			return ""
+		}
+		return t.Format(time.RFC3339)
+	}
+	formatSecs := func(secs int) string {
+		if secs == 0 {
+			return ""
+		}
+		return (time.Duration(secs) * time.Second).String()
+	}
+
+	var hours []string
+	for _, hour := range details.SkipHours {
+		hours = append(hours, fmt.Sprintf("%02d", hour))
+	}
+
+	hub := details.Hub
+	if len(hub) > 0 {
+		hub = fmt.Sprintf("%s (active: %t)", hub, details.HubActive)
+	}
+
+	fmt.Printf("url:           %s\n", details.URL)
+	fmt.Printf("title:         %s\n", details.Title)
+	fmt.Printf("site:          %s\n", details.SiteURL)
+	fmt.Printf("self:          %s\n", details.SelfURL)
+	fmt.Printf("icon:          %s\n", details.Icon)
+	fmt.Printf("language:      %s\n", details.Language)
+	fmt.Printf("categories:    %s\n", strings.Join(details.Categories, ", "))
+	fmt.Printf("item tags:     %s\n", strings.Join(details.ItemCategories, ", "))
+	fmt.Printf("hub:           %s\n", hub)
+	fmt.Printf("flavor:        %s\n", details.Flavor)
+	fmt.Printf("generator:     %s\n", details.Generator)
+	fmt.Printf("published:     %s\n", formatTime(details.Published))
+	fmt.Printf("status:        %s %s\n", details.Status, details.StatusMessage)
+	fmt.Printf("status since:  %s\n", formatTime(details.StatusSince))
+	fmt.Printf("last updated:  %s\n", formatTime(details.LastUpdated))
+	fmt.Printf("next fetch:    %s\n", formatTime(details.NextFetch))
+	fmt.Printf("ttl:           %s\n", formatSecs(details.TTLSecs))
+	fmt.Printf("update period: %s\n", formatSecs(details.UpdatePeriodSecs))
+	fmt.Printf("skip hours:    %s\n", strings.Join(hours, ", "))
+	fmt.Printf("skip days:     %s\n", strings.Join(details.SkipDays, ", "))
+	for _, value := range details.BadDates {
+		fmt.Printf("bad date:      %s\n", value)
+	}
+
+	return nil
+
+}
+
+// FeedSchedule shows or updates the fetch settings of a feed
+func FeedSchedule(c *cli.Context) error {
+
+	var url string
+	if c.NArg() == 1 {
+		url = c.Args()[0]
+	} else {
+		cli.ShowCommandHelp(c, c.Command.Name)
+		os.Exit(1)
+	}
+
+	req := &msg.FeedScheduleRequest{URL: url}
+	rsp := &msg.FeedScheduleResponse{}
+
+	if err := makeRequest(c, "feeds/schedule", req, rsp); err != nil {
+		fmt.Printf("Error: %s\n", err.Error())
+		os.Exit(1)
+	}
+
+	if rsp.Status != 0 {
+		if len(rsp.Message) > 0 {
+			fmt.Printf("%s: %s\n", msg.StatusText(rsp.Status), rsp.Message)
+		} else {
+			fmt.Println(msg.StatusText(rsp.Status))
+		}
+		return nil
+	}
+
+	schedule := rsp.Schedule
+	modified := false
+
+	if c.IsSet("interval") {
+		value := c.String("interval")
+		if value == "auto" {
+			schedule.IntervalSecs = 0
+		} else if interval, err := time.ParseDuration(value); err == nil {
+			schedule.IntervalSecs = int(interval / time.Second)
+		} else {
+			fmt.Printf("Error: invalid interval: %s\n", value)
+			os.Exit(1)
+		}
+		modified = true
+	}
+
+	if c.Bool("clear-windows") {
+		schedule.Windows = nil
+		modified = true
+	}
+
+	if windows := c.StringSlice("window"); len(windows) > 0 {
+		schedule.Windows = append(schedule.Windows, windows...)
+		modified = true
+	}
+
+	if c.IsSet("timezone") {
+		schedule.TimeZone = c.String("timezone")
+		modified = true
+	}
+
+	if c.IsSet("datezone") {
+		schedule.DateZone = c.String("datezone")
+		modified = true
+	}
+
+	if c.Bool("pause") {
+		schedule.Paused = true
+		modified = true
+	} else if c.Bool("resume") {
+		schedule.Paused = false
+		modified = true
+	}
+
+	if c.IsSet("proxy") {
+		schedule.Proxy = c.String("proxy")
+		if schedule.Proxy == "default" {
+			schedule.Proxy = ""
+		}
+		modified = true
+	}
+
+	if c.Bool("insecure") {
+		schedule.Insecure = true
+		modified = true
+	} else if c.Bool("verify") {
+		schedule.Insecure = false
+		modified = true
+	}
+
+	if modified {
+		updateReq := &msg.FeedScheduleUpdateRequest{Schedule: schedule}
+		updateRsp := &msg.FeedScheduleUpdateResponse{}
+		if err := makeRequest(c, "feeds/schedule/update", updateReq, updateRsp); err != nil {
+			fmt.Printf("Error: %s\n", err.Error())
+			os.Exit(1)
+		}
+		if updateRsp.Status != 0 {
+			if len(updateRsp.Message) > 0 {
+				fmt.Printf("%s: %s\n", msg.StatusText(updateRsp.Status), updateRsp.Message)
+			} else {
+				fmt.Println(msg.StatusText(updateRsp.Status))
+			}
+			os.Exit(1)
+		}
+		schedule = updateRsp.Schedule
+	}
+
+	interval := "auto"
+	if schedule.IntervalSecs > 0 {
+		interval = (time.Duration(schedule.IntervalSecs) * time.Second).String()
+	}
+	timezone := schedule.TimeZone
+	if len(timezone) == 0 {
+		timezone = "UTC"
+	}
+	datezone := schedule.DateZone
+	if len(datezone) == 0 {
+		datezone = "UTC"
+	}
+	proxy := schedule.Proxy
+	if len(proxy) == 0 {
+		proxy = "default"
+	}
+
+	fmt.Printf("url:        %s\n", schedule.URL)
+	fmt.Printf("paused:     %t\n", schedule.Paused)
+	fmt.Printf("interval:   %s\n", interval)
+	fmt.Printf("windows:    %s\n", strings.Join(schedule.Windows, "; "))
+	fmt.Printf("timezone:   %s\n", timezone)
+	fmt.Printf("datezone:   %s\n", datezone)
+	fmt.Printf("proxy:      %s\n", proxy)
+	fmt.Printf("insecure:   %t\n", schedule.Insecure)
+	fmt.Printf("next fetch: %s\n", schedule.NextFetch.Format(time.RFC3339))
+
+	return nil
+
+}
+
+// FeedRefresh fetches a feed, or all feeds in error, immediately
+func FeedRefresh(c *cli.Context) error {
+
+	req := &msg.FeedRefreshRequest{AllErrors: c.Bool("all-errors")}
+	if c.NArg() == 1 && !req.AllErrors {
+		req.URL = c.Args()[0]
+	} else if c.NArg() != 0 || !req.AllErrors {
+		cli.ShowCommandHelp(c, c.Command.Name)
+		os.Exit(1)
+	}
+
+	rsp := &msg.FeedRefreshResponse{}
+
+	if err := makeRequest(c, "feeds/refresh", req, rsp); err != nil {
+		fmt.Printf("Error: %s\n", err.Error())
+		os.Exit(1)
+	}
+
+	for _, result := range rsp.Results {
+		if len(result.Error) > 0 {
+			fmt.Printf("%-2s  %3s  %8s  %7s  %s: %s\n", "--", "", "", "", result.URL, result.Error)
+			continue
+		}
+		fmt.Printf("%-2s  %3d  %8s  %3d/%-3d  %s  %s\n", result.Result, result.StatusCode, result.Duration.String(), result.NewItems, result.ItemCount, result.URL, result.ResultMessage)
+	}
+
+	if rsp.Status != 0 {
+		if len(rsp.Message) > 0 {
+			fmt.Printf("%s: %s\n", msg.StatusText(rsp.Status), rsp.Message)
+		} else {
+			fmt.Println(msg.StatusText(rsp.Status))
+		}
+		os.Exit(1)
+	}
+
+	if len(rsp.Results) == 0 {
+		fmt.Println("No feeds to refresh")
+	}
+
+	return nil
+
+}
+
+// FeedScrape previews the items extracted from a web page and optionally subscribes to it
+func FeedScrape(c *cli.Context) error {
+
+	var url string
+	var groups []string
+	var title string
+	if c.NArg() >= 1 {
+		url = c.Args()[0]
+		if c.NArg() >= 2 {
+			groups = strings.Split(c.Args()[1], ",")
+		}
+		if c.NArg() >= 3 {
+			title = c.Args()[2]
+		}
+	} else {
+		cli.ShowCommandHelp(c, c.Command.Name)
+		os.Exit(1)
+	}
+
+	rules := &msg.ScrapeRules{
+		Item:       c.String("item"),
+		Title:      c.String("title"),
+		Link:       c.String("link"),
+		Date:       c.String("date"),
+		DateFormat: c.String("date-format"),
+		Content:    c.String("content"),
+	}
+
+	req := &msg.FeedScrapePreviewRequest{URL: url, Rules: rules}
+	rsp := &msg.FeedScrapePreviewResponse{}
+
+	if err := makeRequest(c, "feeds/scrape/preview", req, rsp); err != nil {
+		fmt.Printf("Error: %s\n", err.Error())
+		os.Exit(1)
+	}
+
+	if rsp.Status != 0 {
+		if len(rsp.Message) > 0 {
+			fmt.Printf("%s: %s\n", msg.StatusText(rsp.Status), rsp.Message)
+		} else {
+			fmt.Println(msg.StatusText(rsp.Status))
+		}
+		os.Exit(1)
+	}
+
+	fmt.Printf("%-2s  %3d  %s  %s\n", rsp.Result, rsp.StatusCode, rsp.Title, rsp.ResultMessage)
+	for _, item := range rsp.Items {
+		created := ""
+		if !item.Created.IsZero() {
+			created = item.Created.Format("02.01.06 15:04")
+		}
+		fmt.Printf("%-14s  %-40s  %s\n", created, item.Title, item.URL)
+	}
+
+	if len(groups) == 0 {
+		return nil
+	}
+
+	if len(rsp.Items) == 0 {
+		fmt.Println("No items found, not subscribing")
+		os.Exit(1)
+	}
+
+	subReq := &msg.SubscriptionAddUpdateRequest{
+		AddGroups: c.Bool("groups"),
+		Scrape:    rules,
+		Subscription: &msg.Subscription{
+			URL:    url,
+			Groups: groups,
+			Title:  title,
+		},
+	}
+	subRsp := &msg.SubscriptionAddUpdateResponse{}
+
+	if err := makeRequest(c, "subscriptions/add", subReq, subRsp); err != nil {
+		fmt.Printf("Error: %s\n", err.Error())
+		os.Exit(1)
+	}
+
+	if len(subRsp.Message) > 0 {
+		fmt.Printf("%s: %s\n", msg.StatusText(subRsp.Status), subRsp.Message)
+	} else {
+		fmt.Println(msg.StatusText(subRsp.Status))
+	}
+
+	return nil
+
+}
+
+// FeedValidate fetches a feed on the remote instance and shows the problems found parsing it
+func FeedValidate(c *cli.Context) error {
+
+	var url string
+	if c.NArg() == 1 {
+		url = c.Args()[0]
+	} else {
+		cli.ShowCommandHelp(c, c.Command.Name)
+		os.Exit(1)
+	}
+
+	req := &msg.FeedValidateRequest{URL: url}
+	rsp := &msg.FeedValidateResponse{}
+
+	if err := makeRequest(c, "feeds/validate", req, rsp); err != nil {
+		fmt.Printf("Error: %s\n", err.Error())
+		os.Exit(1)
+	}
+
+	if rsp.Status != 0 {
+		if len(rsp.Message) > 0 {
+			fmt.Printf("%s: %s\n", msg.StatusText(rsp.Status), rsp.Message)
+		} else {
+			fmt.Println(msg.StatusText(rsp.Status))
+		}
+		os.Exit(1)
+	}
+
+	PrintFeedReport(rsp.Report, c.Bool("json"))
+
+	return nil
+
+}
+
+// PrintFeedReport prints the report of a validated feed as a table or as JSON.
+func PrintFeedReport(report *msg.FeedReport, asJSON bool) {
+
+	if asJSON {
+		data, err := json.MarshalIndent(report, "", "  ")
+		if err != nil {
+			fmt.Printf("Error: %s\n", err.Error())
+			os.Exit(1)
+		}
+		fmt.Println(string(data))
+		return
+	}
+
+	formatTime := func(t time.Time) string {
+		if t.IsZero() {
+			return ""
+		}
+		return t.Format(time.RFC3339)
+	}
+
+	fmt.Printf("url:          %s\n", report.URL)
+	if len(report.Result) > 0 {
+		fmt.Printf("result:       %s %s\n", report.Result, report.ResultMessage)
+	}
+	if report.StatusCode != 0 {
+		fmt.Printf("status code:  %d\n", report.StatusCode)
+	}
+	fmt.Printf("content type: %s\n", report.ContentType)
+	fmt.Printf("flavor:       %s\n", report.Flavor)
+	fmt.Printf("title:        %s\n", report.Title)
+	fmt.Printf("site:         %s\n", report.SiteURL)
+	fmt.Printf("entries:      %d\n", len(report.Entries))
+	for _, warning := range report.Warnings {
+		fmt.Printf("warning:      %s\n", warning)
+	}
+
+	if len(report.Entries) > 0 {
+		fmt.Println()
+		fmt.Printf("%-25s %-60s %-40s %s\n", "updated", "title", "id", "url")
+		for _, entry := range report.Entries {
+			fmt.Printf("%-25s %-60s %-40s %s\n", formatTime(entry.Updated), entry.Title, entry.ID, entry.URL)
+		}
+	}
+
+}
@@ -1,8 +1,10 @@
 package remote
 
 import (
+	"bufio"
 	"fmt"
 	"os"
+	"strconv"
 	"strings"
 	"time"
 
@@ -29,32 +31,69 @@ func SubscriptionAdd(c *cli.Context) error {
 
 	req := &msg.SubscriptionAddUpdateRequest{
 		AddGroups: c.Bool("groups"),
+		Backfill:  c.Int("backfill"),
 		Subscription: &msg.Subscription{
-			URL:      url,
-			Groups:   groups,
-			Title:    title,
-			AutoRead: c.Bool("autoread"),
-			AutoStar: c.Bool("autostar"),
+			URL:                url,
+			Groups:             groups,
+			Title:              title,
+			AutoRead:           c.Bool("autoread"),
+			AutoStar:           c.Bool("autostar"),
+			FullText:           c.Bool("fulltext"),
+			AutoReadCategories: c.StringSlice("autoread-category"),
+			AutoStarCategories: c.StringSlice("autostar-category"),
 		},
 	}
 
 	rsp := &msg.SubscriptionAddUpdateResponse{}
 
-	if err := makeRequest(c, "subscriptions/add", req, rsp); err == nil {
-		if len(rsp.Message) > 0 {
-			fmt.Printf("%s: %s\n", msg.StatusText(rsp.Status), rsp.Message)
-		} else {
-			fmt.Println(msg.StatusText(rsp.Status))
+	if err := makeRequest(c, "subscriptions/add", req, rsp); err == nil && rsp.Status == msg.StatusChoose {
+		req.Subscription.URL = chooseCandidate(rsp.Message, rsp.Candidates)
+		rsp = &msg.SubscriptionAddUpdateResponse{}
+		if err := makeRequest(c, "subscriptions/add", req, rsp); err != nil {
+			fmt.Printf("Error: %s\n", err.Error())
+			os.Exit(1)
 		}
-	} else {
+	} else if err != nil {
 		fmt.Printf("Error: %s\n", err.Error())
 		os.Exit(1)
 	}
 
+	if rsp.Status == msg.StatusOK && len(rsp.URL) > 0 && rsp.URL != url {
+		fmt.Printf("Subscribed to %s\n", rsp.URL)
+	}
+	if len(rsp.Message) > 0 {
+		fmt.Printf("%s: %s\n", msg.StatusText(rsp.Status), rsp.Message)
+	} else {
+		fmt.Println(msg.StatusText(rsp.Status))
+	}
+
 	return nil
 
 }
 
+// chooseCandidate prompts the user to select one of the discovered feeds
+func chooseCandidate(message string, candidates []*msg.FeedCandidate) string {
+
+	fmt.Println(message)
+	for i, candidate := range candidates {
+		fmt.Printf("%2d) %-40s %s\n", i+1, candidate.Title, candidate.URL)
+	}
+
+	reader := bufio.NewReader(os.Stdin)
+	for {
+		fmt.Printf("Choose a feed [1-%d]: ", len(candidates))
+		line, err := reader.ReadString('\n')
+		if choice, errChoice := strconv.Atoi(strings.TrimSpace(line)); errChoice == nil && choice >= 1 && choice <= len(candidates) {
+			return candidates[choice-1].URL
+		}
+		if err != nil {
+			fmt.Println()
+			os.Exit(1)
+		}
+	}
+
+}
+
 // SubscriptionList retrieves the list of user subscriptions from the remote instance
 func SubscriptionList(c *cli.Context) error {
 
@@ -83,9 +122,9 @@ func SubscriptionList(c *cli.Context) error {
 			return " "
 		}
 
-		fmt.Printf("%-15s %s %s %-25s %-80s %-20s\n", "groups", "r", "s", "title", "url", "added")
+		fmt.Printf("%-15s %s %s %s %-25s %-80s %-20s\n", "groups", "r", "s", "f", "title", "url", "added")
 		for _, sub := range rsp.Subscriptions {
-			fmt.Printf("%-15s %s %s %-25s %-80s %-20s\n", strings.Join(sub.Groups, ", "), fmtBool(sub.AutoRead, "#"), fmtBool(sub.AutoStar, "*"), sub.Title, sub.URL, sub.Added.Format(time.RFC3339))
+			fmt.Printf("%-15s %s %s %s %-25s %-80s %-20s\n", strings.Join(sub.Groups, ", "), fmtBool(sub.AutoRead, "#"), fmtBool(sub.AutoStar, "*"), fmtBool(sub.FullText, "F"), sub.Title, sub.URL, sub.Added.Format(time.RFC3339))
 		}
 
 	} else {
@@ -5,27 +5,37 @@ import (
 	"io/ioutil"
 	"os"
 	"os/signal"
+	"strings"
 	"syscall"
 	"time"
 
 	"github.com/codegangsta/cli"
+	"github.com/kwo/rakewire/favicon"
 	"github.com/kwo/rakewire/fetch"
+	"github.com/kwo/rakewire/fulltext"
 	"github.com/kwo/rakewire/httpd"
 	"github.com/kwo/rakewire/logger"
 	"github.com/kwo/rakewire/model"
 	"github.com/kwo/rakewire/pollfeed"
 	"github.com/kwo/rakewire/reaper"
+	"github.com/kwo/rakewire/sanitize"
+	"github.com/kwo/rakewire/websub"
+	"golang.org/x/net/context"
 )
 
 type startContext struct {
-	database model.Database
-	fetchd   *fetch.Service
-	polld    *pollfeed.Service
-	reaperd  *reaper.Service
-	httpd    *httpd.Service
-	log      *logger.Logger
-	errors   chan error
-	pidFile  string
+	database  model.Database
+	faviconsd *favicon.Service
+	fetchd    *fetch.Service
+	fulltextd *fulltext.Service
+	polld     *pollfeed.Service
+	reaperd   *reaper.Service
+	websubd   *websub.Service
+	httpd     *httpd.Service
+	log       *logger.Logger
+	errors    chan error
+	pidFile   string
+	timeout   time.Duration // shutdown deadline
 }
 
 // Start the app
@@ -42,6 +52,7 @@ func Start(c *cli.Context) error {
 		log:     logger.New("main"),
 		pidFile: pidFile,
 		errors:  make(chan error, 1),
+		timeout: time.Duration(c.Int("shutdown.timeoutsecs")) * time.Second,
 	}
 
 	if db, err := openDatabase(dbFile); err == nil {
@@ -59,15 +70,79 @@ func Start(c *cli.Context) error {
 		IntervalSeconds: c.Int("poll.intervalsecs"),
 	}
 	ctx.polld = pollfeed.NewService(pollConfig, ctx.database)
-	ctx.reaperd = reaper.NewService(ctx.database)
+	sanitizePolicy := &sanitize.Policy{
+		StripImages: c.Bool("sanitize.stripimages"),
+		IframeHosts: c.StringSlice("sanitize.iframehost"),
+	}
+	reaperConfig := &reaper.Configuration{
+		MinIntervalSeconds: c.Int("schedule.minintervalsecs"),
+		MaxIntervalSeconds: c.Int("schedule.maxintervalsecs"),
+		BatchMax:           c.Int("reap.batchmax"),
+		BatchWindowMillis:  c.Int("reap.batchwindowms"),
+		Sanitize:           sanitizePolicy,
+	}
+	ctx.reaperd = reaper.NewService(reaperConfig, ctx.database)
 
 	fetchConfig := &fetch.Configuration{
-		TimeoutSeconds: c.Int("fetch.timeoutsecs"),
-		Workers:        c.Int("fetch.workers"),
-		UserAgent:      c.App.Name + " " + c.App.Version,
+		TimeoutSeconds:    c.Int("fetch.timeoutsecs"),
+		Workers:           c.Int("fetch.workers"),
+		UserAgent:         c.App.Name + " " + c.App.Version,
+		Proxy:             c.String("fetch.proxy"),
+		TLSCAFile:         c.String("fetch.tlsca"),
+		TLSCertFile:       c.String("fetch.tlscert"),
+		TLSKeyFile:        c.String("fetch.tlskey"),
+		MaxSizeKB:         c.Int("fetch.maxsizekb"),
+		MaxDecompressedKB: c.Int("fetch.maxdecompressedkb"),
+		MaxDepth:          c.Int("fetch.maxdepth"),
+		MaxEntries:        c.Int("fetch.maxentries"),
+		FileRoots:         c.StringSlice("fetch.fileroot"),
+		Commands:          parseCommands(c.StringSlice("fetch.exec")),
+		BackfillMaxItems:  c.Int("fetch.backfillmaxitems"),
+		BackfillDelaySecs: c.Int("fetch.backfilldelaysecs"),
 	}
 	ctx.fetchd = fetch.NewService(fetchConfig, ctx.polld.Output, ctx.reaperd.Input)
 
+	// articles, icons and hubs are retrieved through the proxy and with the certificates of the fetcher
+	client, err := fetch.NewClient(fetchConfig)
+	if err != nil {
+		ctx.log.Infof("Error: Cannot configure the HTTP client: %s", err.Error())
+		closeDatabase(ctx.database)
+		return nil
+	}
+
+	fulltextConfig := &fulltext.Configuration{
+		Workers:          c.Int("fulltext.workers"),
+		IntervalSeconds:  c.Int("fulltext.intervalsecs"),
+		HostDelaySeconds: c.Int("fulltext.hostdelaysecs"),
+		TimeoutSeconds:   c.Int("fetch.timeoutsecs"),
+		UserAgent:        c.App.Name + " " + c.App.Version,
+		Sanitize:         sanitizePolicy,
+		Client:           client,
+	}
+	ctx.fulltextd = fulltext.NewService(fulltextConfig, ctx.database)
+
+	faviconConfig := &favicon.Configuration{
+		IntervalSeconds: c.Int("favicon.intervalsecs"),
+		MaxAgeHours:     c.Int("favicon.maxagehours"),
+		TimeoutSeconds:  c.Int("fetch.timeoutsecs"),
+		UserAgent:       c.App.Name + " " + c.App.Version,
+		Client:          client,
+	}
+	ctx.faviconsd = favicon.NewService(faviconConfig, ctx.database)
+
+	websubConfig := &websub.Configuration{
+		CallbackURL:     c.String("websub.callback"),
+		IntervalSeconds: c.Int("websub.intervalsecs"),
+		LeaseSeconds:    c.Int("websub.leasesecs"),
+		TimeoutSeconds:  c.Int("fetch.timeoutsecs"),
+		UserAgent:       c.App.Name + " " + c.App.Version,
+		Client:          client,
+	}
+	if websubConfig.CallbackURL == "" {
+		websubConfig.CallbackURL = "https://" + c.String("host") + websub.Path
+	}
+	ctx.websubd = websub.NewService(websubConfig, ctx.database, ctx.fetchd)
+
 	httpdConfig := &httpd.Configuration{
 		DebugMode:      len(c.App.Version) == 0,
 		ListenHostPort: c.String("bind"),
@@ -75,9 +150,9 @@ func Start(c *cli.Context) error {
 		TLSCertFile:    c.String("tlscert"),
 		TLSKeyFile:     c.String("tlskey"),
 	}
-	ctx.httpd = httpd.NewService(httpdConfig, ctx.database, c.App.Version, appStart)
+	ctx.httpd = httpd.NewService(httpdConfig, ctx.database, ctx.fetchd, ctx, ctx.websubd, c.App.Version, appStart)
 
-	for i := 0; i < 4; i++ {
+	for i := 0; i < 7; i++ {
 		var err error
 		switch i {
 		case 0:
@@ -87,6 +162,12 @@ func Start(c *cli.Context) error {
 		case 2:
 			err = ctx.reaperd.Start()
 		case 3:
+			err = ctx.fulltextd.Start()
+		case 4:
+			err = ctx.faviconsd.Start()
+		case 5:
+			err = ctx.websubd.Start()
+		case 6:
 			err = ctx.httpd.Start()
 		} // select
 		if err != nil {
@@ -102,6 +183,26 @@ func Start(c *cli.Context) error {
 
 }
 
+// parseCommands maps the exec feed names to their command lines, given as name=command.
+func parseCommands(values []string) map[string]string {
+	commands := make(map[string]string)
+	for _, value := range values {
+		if parts := strings.SplitN(value, "=", 2); len(parts) == 2 && strings.TrimSpace(parts[0]) != "" {
+			commands[strings.TrimSpace(parts[0])] = strings.TrimSpace(parts[1])
+		}
+	}
+	return commands
+}
+
+// Activity reports the live state of the poll, fetch and reap services.
+func (z *startContext) Activity() *model.Activity {
+	return &model.Activity{
+		Poll:  z.polld.Activity(),
+		Fetch: z.fetchd.Activity(),
+		Reap:  z.reaperd.Activity(),
+	}
+}
+
 func monitorShutdown(ctx *startContext) {
 
 	// write pidfile
@@ -122,11 +223,21 @@ func monitorShutdown(ctx *startContext) {
 
 	ctx.log.Infof("stopping... ")
 
-	// shutdown httpd
-	ctx.httpd.Stop()
-	ctx.polld.Stop()
-	ctx.fetchd.Stop()
-	ctx.reaperd.Stop()
+	// finish active requests and drain the pipeline: the poller stops feeding the fetchers,
+	// the fetchers complete in-flight fetches and close the reaper input once the reaper has accepted all harvests.
+	shutdown, cancel := context.WithTimeout(context.Background(), ctx.timeout)
+	defer cancel()
+	if err := ctx.httpd.Shutdown(shutdown); err != nil {
+		ctx.log.Infof("httpd shutdown: %s", err.Error())
+	}
+	ctx.websubd.Stop()
+	for _, service := range []model.DrainingService{ctx.polld, ctx.fetchd, ctx.reaperd} {
+		if err := service.Shutdown(shutdown); err != nil {
+			ctx.log.Infof("shutdown: %s", err.Error())
+		}
+	}
+	ctx.fulltextd.Stop()
+	ctx.faviconsd.Stop()
 	if err := model.Instance.Close(ctx.database); err != nil {
 		ctx.log.Infof("Error closing database: %s", err.Error())
 	}
@@ -0,0 +1,132 @@
+package cmd
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"mime"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/codegangsta/cli"
+	"github.com/kwo/rakewire/api/msg"
+	"github.com/kwo/rakewire/cmd/remote"
+	"github.com/kwo/rakewire/feedparser"
+	"github.com/kwo/rakewire/fetch"
+)
+
+const (
+	validateTimeout = 30 * time.Second
+)
+
+// Validate parses a feed from a URL or file in diagnostic mode and shows the problems found, without a database
+func Validate(c *cli.Context) error {
+
+	var location string
+	if c.NArg() == 1 {
+		location = c.Args()[0]
+	} else {
+		cli.ShowCommandHelp(c, c.Command.Name)
+		os.Exit(1)
+	}
+
+	report := &msg.FeedReport{URL: location}
+
+	cfg := &fetch.Configuration{
+		TimeoutSeconds:    int(validateTimeout / time.Second),
+		Proxy:             c.String("fetch.proxy"),
+		TLSCAFile:         c.String("fetch.tlsca"),
+		TLSCertFile:       c.String("fetch.tlscert"),
+		TLSKeyFile:        c.String("fetch.tlskey"),
+		MaxSizeKB:         c.Int("fetch.maxsizekb"),
+		MaxDecompressedKB: c.Int("fetch.maxdecompressedkb"),
+	}
+
+	var body []byte
+	var err error
+	if u, errURL := url.Parse(location); errURL == nil && (u.Scheme == "http" || u.Scheme == "https") {
+		body, err = validateGet(c, cfg, report)
+	} else {
+		body, err = validateRead(report)
+	}
+	if err != nil {
+		fmt.Printf("Error: %s\n", err.Error())
+		os.Exit(1)
+	}
+
+	parser := feedparser.NewParser()
+	parser.Diagnose = true
+	parser.MaxDepth = c.Int("fetch.maxdepth")
+	parser.MaxEntries = c.Int("fetch.maxentries")
+	feed, err := parser.ParseContentType(bytes.NewReader(body), report.ContentType)
+	if err != nil {
+		fmt.Printf("Cannot parse feed: %s\n", err.Error())
+		os.Exit(1)
+	}
+
+	report.Flavor = feed.Flavor
+	report.Title = feed.Title
+	report.SiteURL = feed.LinkAlternate
+	report.Warnings = feed.Warnings
+	for _, entry := range feed.Entries {
+		report.Entries = append(report.Entries, &msg.FeedReportEntry{
+			ID:      entry.ID,
+			Title:   entry.Title,
+			URL:     entry.LinkAlternate,
+			Created: entry.Created,
+			Updated: entry.Updated,
+		})
+	}
+
+	remote.PrintFeedReport(report, c.Bool("json"))
+
+	return nil
+
+}
+
+func validateGet(c *cli.Context, cfg *fetch.Configuration, report *msg.FeedReport) ([]byte, error) {
+
+	req, err := http.NewRequest(http.MethodGet, report.URL, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("User-Agent", fmt.Sprintf("%s/%s", c.App.Name, c.App.Version))
+	req.Header.Set("Accept-Encoding", "gzip") // decompressed by fetch.ReadAll within the limits
+
+	client, err := fetch.NewClient(cfg)
+	if err != nil {
+		return nil, err
+	}
+	rsp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer rsp.Body.Close()
+
+	report.StatusCode = rsp.StatusCode
+	report.ContentType = rsp.Header.Get("Content-Type")
+	if rsp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("Unexpected status %s", rsp.Status)
+	}
+	report.URL = rsp.Request.URL.String() // after redirects
+
+	return fetch.ReadAll(rsp, cfg)
+
+}
+
+func validateRead(report *msg.FeedReport) ([]byte, error) {
+
+	filename := strings.TrimPrefix(report.URL, "file://")
+	if abs, err := filepath.Abs(filename); err == nil {
+		filename = abs
+	}
+	report.URL = filename
+	report.ContentType = mime.TypeByExtension(filepath.Ext(filename))
+
+	return ioutil.ReadFile(filename)
+
+}
@@ -0,0 +1,206 @@
+// Package favicon retrieves, normalizes and stores the icons of feeds.
+package favicon
+
+import (
+	"errors"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/kwo/rakewire/logger"
+	"github.com/kwo/rakewire/model"
+)
+
+const (
+	batchSize  = 20             // maximum number of feeds processed per poll
+	retryDelay = 24 * time.Hour // wait before looking again for the icon of a feed which has none
+)
+
+var (
+	// ErrRestart indicates that the service cannot be started because it is already running.
+	ErrRestart = errors.New("The service is already started")
+	log        = logger.New("favicon")
+)
+
+// Configuration contains all parameters for the Favicon service
+type Configuration struct {
+	IntervalSeconds int // how often to look for feeds without a current icon
+	MaxAgeHours     int // how long an icon is kept before being retrieved again
+	TimeoutSeconds  int
+	UserAgent       string
+	Client          *http.Client // carries the proxy and TLS settings of the fetcher, nil for a plain client
+}
+
+// Service keeps the icons of all feeds current
+type Service struct {
+	sync.Mutex
+	database     model.Database
+	finder       *Finder
+	pollInterval time.Duration
+	maxAge       time.Duration
+	running      bool
+	killsignal   chan bool
+	latch        sync.WaitGroup
+}
+
+// NewService creates a new favicon service
+func NewService(cfg *Configuration, database model.Database) *Service {
+	client := cfg.Client
+	if client == nil {
+		client = &http.Client{Timeout: time.Duration(cfg.TimeoutSeconds) * time.Second}
+	}
+	return &Service{
+		database: database,
+		finder: &Finder{
+			Client:    client,
+			UserAgent: cfg.UserAgent,
+		},
+		pollInterval: time.Duration(cfg.IntervalSeconds) * time.Second,
+		maxAge:       time.Duration(cfg.MaxAgeHours) * time.Hour,
+	}
+}
+
+// Start service
+func (z *Service) Start() error {
+
+	z.Lock()
+	defer z.Unlock()
+	if z.running {
+		log.Debugf("service already started, exiting...")
+		return ErrRestart
+	}
+
+	log.Infof("starting...")
+	log.Infof("interval: %s", z.pollInterval.String())
+	log.Infof("max age:  %s", z.maxAge.String())
+
+	z.killsignal = make(chan bool)
+
+	z.latch.Add(1)
+	go z.run()
+
+	z.running = true
+	log.Infof("started")
+
+	return nil
+
+}
+
+// Stop service
+func (z *Service) Stop() {
+
+	z.Lock()
+	if !z.running {
+		z.Unlock()
+		log.Debugf("service already stopped, exiting...")
+		return
+	}
+	z.Unlock()
+
+	log.Debugf("stopping...")
+	close(z.killsignal)
+	z.latch.Wait()
+
+	z.Lock()
+	z.running = false
+	z.Unlock()
+	log.Infof("stopped")
+
+}
+
+// IsRunning indicated if the service is active or not.
+func (z *Service) IsRunning() bool {
+	z.Lock()
+	defer z.Unlock()
+	return z.running
+}
+
+func (z *Service) run() {
+
+	log.Debugf("run starting...")
+
+	ticker := time.NewTicker(z.pollInterval)
+
+run:
+	for {
+		select {
+		case tick := <-ticker.C:
+			z.poll(tick)
+		case <-z.killsignal:
+			break run
+		}
+	}
+
+	ticker.Stop()
+
+	z.latch.Done()
+	log.Debugf("run exited")
+
+}
+
+// poll retrieves the icons of feeds having none or an expired one.
+func (z *Service) poll(now time.Time) {
+
+	var feeds model.Feeds
+	if err := z.database.Select(func(tx model.Transaction) error {
+		feeds = model.V.GetStale(tx, now)
+		return nil
+	}); err != nil {
+		log.Infof("Error polling feeds: %s", err.Error())
+		return
+	}
+
+	for i, feed := range feeds {
+		if i == batchSize {
+			return
+		}
+		select {
+		case <-z.killsignal:
+			return
+		default:
+		}
+		icon, err := z.finder.Find(feed)
+		z.save(feed.ID, icon, err)
+	}
+
+}
+
+// save stores the icon, or the absence of one, and schedules the next retrieval.
+func (z *Service) save(feedID string, icon *Icon, findErr error) {
+
+	err := z.database.Update(func(tx model.Transaction) error {
+
+		// the feed may have been removed in the meantime
+		if model.F.Get(tx, feedID) == nil {
+			return nil
+		}
+
+		now := time.Now().Truncate(time.Second)
+
+		favicon := model.V.Get(tx, feedID)
+		if favicon == nil {
+			favicon = model.V.New(feedID)
+		}
+
+		if findErr == nil {
+			favicon.URL = icon.URL
+			favicon.Type = MimeType
+			favicon.Data = icon.Data
+			favicon.Updated = now
+			favicon.Expires = now.Add(z.maxAge)
+			log.Debugf("icon %s for feed %s", icon.URL, feedID)
+		} else {
+			// keep a previously found icon until a new one is found
+			favicon.Expires = now.Add(retryDelay)
+			log.Debugf("no icon for feed %s: %s", feedID, findErr.Error())
+		}
+
+		return model.V.Save(tx, favicon)
+
+	})
+
+	if err != nil {
+		log.Infof("Error saving favicon %s: %s", feedID, err.Error())
+	}
+
+}
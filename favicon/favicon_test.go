@@ -0,0 +1,202 @@
+package favicon
+
+import (
+	"bytes"
+	"image"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/kwo/rakewire/model"
+)
+
+func TestInterfaceService(t *testing.T) {
+
+	var s model.Service = &Service{}
+	if s == nil {
+		t.Fatal("Does not implement model.Service interface.")
+	}
+
+}
+
+func TestFindIconLinks(t *testing.T) {
+
+	t.Parallel()
+
+	page := `
+	<html><head>
+		<base href="/static/">
+		<link rel="apple-touch-icon" href="touch.png">
+		<link rel="stylesheet" href="site.css">
+		<link rel="Shortcut Icon" href="favicon.png">
+	</head><body>
+		<link rel="icon" href="ignored.png">
+	</body></html>
+	`
+
+	links := FindIconLinks("http://localhost/blog/", strings.NewReader(page))
+	expected := []string{"http://localhost/static/favicon.png", "http://localhost/static/touch.png"}
+	if len(links) != len(expected) {
+		t.Fatalf("Bad link count, expected %d, actual %d: %v", len(expected), len(links), links)
+	}
+	for i := range expected {
+		if links[i] != expected[i] {
+			t.Errorf("Bad link %d, expected %s, actual %s", i, expected[i], links[i])
+		}
+	}
+
+}
+
+func TestFind(t *testing.T) {
+
+	t.Parallel()
+
+	icon := encodePNG(t, image.NewRGBA(image.Rect(0, 0, 16, 16)))
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/linked/":
+			w.Write([]byte(`<html><head><link rel="icon" href="/linked.png"></head></html>`))
+		case "/broken/":
+			w.Write([]byte(`<html><head><link rel="icon" href="/broken.png"></head></html>`))
+		case "/declared.png", "/linked.png", "/favicon.ico":
+			w.Write(icon)
+		case "/broken.png":
+			w.Write([]byte("not an image"))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	finder := &Finder{Client: &http.Client{Timeout: 5 * time.Second}}
+
+	tests := []struct {
+		feed     *model.Feed
+		expected string
+	}{
+		{&model.Feed{URL: server.URL + "/feed", SiteURL: server.URL + "/linked/", Icon: server.URL + "/declared.png"}, "/declared.png"},
+		{&model.Feed{URL: server.URL + "/feed", SiteURL: server.URL + "/linked/", Icon: server.URL + "/missing.png"}, "/linked.png"},
+		{&model.Feed{URL: server.URL + "/feed", SiteURL: server.URL + "/broken/"}, "/favicon.ico"},
+	}
+
+	for i, test := range tests {
+		result, err := finder.Find(test.feed)
+		if err != nil {
+			t.Errorf("%d: cannot find icon: %s", i, err.Error())
+			continue
+		}
+		if result.URL != server.URL+test.expected {
+			t.Errorf("%d: bad icon url, expected %s, actual %s", i, server.URL+test.expected, result.URL)
+		}
+		if !bytes.HasPrefix(result.Data, pngMagic) {
+			t.Errorf("%d: icon not normalized", i)
+		}
+	}
+
+}
+
+func TestPoll(t *testing.T) {
+
+	t.Parallel()
+
+	icon := encodePNG(t, image.NewRGBA(image.Rect(0, 0, 16, 16)))
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if strings.HasPrefix(r.URL.Path, "/good/") {
+			w.Write(icon)
+			return
+		}
+		http.NotFound(w, r)
+	}))
+	defer server.Close()
+
+	database := openTestDatabase(t)
+	defer closeTestDatabase(t, database)
+
+	var good, bad *model.Feed
+	if err := database.Update(func(tx model.Transaction) error {
+		good = model.F.New(server.URL + "/good/feed")
+		good.Icon = server.URL + "/good/icon.png"
+		bad = model.F.New(server.URL + "/bad/feed")
+		if err := model.F.Save(tx, good); err != nil {
+			return err
+		}
+		return model.F.Save(tx, bad)
+	}); err != nil {
+		t.Fatalf("Cannot save feeds: %s", err.Error())
+	}
+
+	z := NewService(&Configuration{IntervalSeconds: 3600, MaxAgeHours: 24 * 7, TimeoutSeconds: 5}, database)
+	z.killsignal = make(chan bool)
+	now := time.Now()
+	z.poll(now)
+
+	if err := database.Select(func(tx model.Transaction) error {
+
+		favicon := model.V.Get(tx, good.ID)
+		if favicon == nil || !favicon.HasData() {
+			t.Fatal("Missing favicon")
+		}
+		if favicon.Type != MimeType || favicon.URL != good.Icon {
+			t.Errorf("Bad favicon: %s %s", favicon.Type, favicon.URL)
+		}
+		if favicon.Expires.Before(now.Add(24*7*time.Hour - time.Minute)) {
+			t.Errorf("Bad expiry: %s", favicon.Expires)
+		}
+
+		favicon = model.V.Get(tx, bad.ID)
+		if favicon == nil || favicon.HasData() {
+			t.Fatal("Expected empty favicon")
+		}
+		if favicon.Expires.Before(now.Add(retryDelay - time.Minute)) {
+			t.Errorf("Retry not delayed: %s", favicon.Expires)
+		}
+
+		if feeds := model.V.GetStale(tx, now); len(feeds) != 0 {
+			t.Errorf("Expected no stale feeds, actual %d", len(feeds))
+		}
+
+		return nil
+
+	}); err != nil {
+		t.Fatalf("Cannot get favicons: %s", err.Error())
+	}
+
+}
+
+func openTestDatabase(t *testing.T) model.Database {
+
+	f, err := ioutil.TempFile("", "bolt-")
+	if err != nil {
+		t.Fatalf("Cannot acquire temp file: %s", err.Error())
+	}
+	f.Close()
+	location := f.Name()
+
+	boltDB, err := model.Instance.Open(location)
+	if err != nil {
+		t.Fatalf("Cannot open database: %s", err.Error())
+	}
+
+	return boltDB
+
+}
+
+func closeTestDatabase(t *testing.T, d model.Database) {
+
+	location := d.Location()
+
+	if err := model.Instance.Close(d); err != nil {
+		t.Errorf("Cannot close database: %s", err.Error())
+	}
+
+	if err := os.Remove(location); err != nil {
+		t.Errorf("Cannot remove temp file: %s", err.Error())
+	}
+
+}
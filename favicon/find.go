@@ -0,0 +1,208 @@
+package favicon
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/kwo/rakewire/model"
+	"golang.org/x/net/html"
+)
+
+const (
+	hUserAgent  = "User-Agent"
+	maxIconSize = 1024 * 1024     // larger icons are rejected
+	maxPageSize = 1024 * 1024 * 2 // only the head of the site page is searched for icon links
+)
+
+var (
+	// ErrNotFound indicates that no icon could be found for a feed.
+	ErrNotFound = errors.New("No icon found")
+)
+
+// Icon is a normalized icon and the location it was retrieved from.
+type Icon struct {
+	URL  string
+	Data []byte
+}
+
+// Finder locates and retrieves the icon of a feed.
+type Finder struct {
+	Client    *http.Client
+	UserAgent string
+}
+
+// Find tries, in order, the icon declared by the feed, the icon links of the site page and /favicon.ico of the site.
+func (z *Finder) Find(feed *model.Feed) (*Icon, error) {
+
+	tried := make(map[string]bool)
+	try := func(rawurl string) *Icon {
+		if len(rawurl) == 0 || tried[rawurl] {
+			return nil
+		}
+		tried[rawurl] = true
+		data, err := z.get(rawurl)
+		if err == nil {
+			data, err = Normalize(data)
+		}
+		if err != nil {
+			log.Debugf("icon %s: %s", rawurl, err.Error())
+			return nil
+		}
+		return &Icon{URL: rawurl, Data: data}
+	}
+
+	if icon := try(feed.Icon); icon != nil {
+		return icon, nil
+	}
+
+	siteURL := feed.SiteURL
+	if len(siteURL) == 0 {
+		siteURL = rootOf(feed.URL)
+	}
+
+	if links, err := z.findIconLinks(siteURL); err == nil {
+		for _, link := range links {
+			if icon := try(link); icon != nil {
+				return icon, nil
+			}
+		}
+	} else {
+		log.Debugf("site %s: %s", siteURL, err.Error())
+	}
+
+	for _, base := range []string{siteURL, feed.URL} {
+		if icon := try(resolve(base, "/favicon.ico")); icon != nil {
+			return icon, nil
+		}
+	}
+
+	return nil, ErrNotFound
+
+}
+
+func (z *Finder) findIconLinks(siteURL string) ([]string, error) {
+
+	rsp, err := z.do(siteURL)
+	if err != nil {
+		return nil, err
+	}
+	defer rsp.Body.Close()
+
+	return FindIconLinks(rsp.Request.URL.String(), io.LimitReader(rsp.Body, maxPageSize)), nil
+
+}
+
+func (z *Finder) get(rawurl string) ([]byte, error) {
+
+	rsp, err := z.do(rawurl)
+	if err != nil {
+		return nil, err
+	}
+	defer rsp.Body.Close()
+
+	data, err := ioutil.ReadAll(io.LimitReader(rsp.Body, maxIconSize+1))
+	if err != nil {
+		return nil, err
+	}
+	if len(data) > maxIconSize {
+		return nil, fmt.Errorf("Icon larger than %d bytes", maxIconSize)
+	}
+	return data, nil
+
+}
+
+func (z *Finder) do(rawurl string) (*http.Response, error) {
+
+	req, err := http.NewRequest(http.MethodGet, rawurl, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set(hUserAgent, z.UserAgent)
+
+	rsp, err := z.Client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if rsp.StatusCode != http.StatusOK {
+		rsp.Body.Close()
+		return nil, fmt.Errorf("Cannot retrieve %s: %s", rawurl, rsp.Status)
+	}
+
+	return rsp, nil
+
+}
+
+// FindIconLinks returns the absolute URLs of the icon links in the head of an HTML page, in document order.
+// Links with rel icon or shortcut icon take precedence over apple-touch-icon links.
+func FindIconLinks(base string, reader io.Reader) []string {
+
+	var icons, touchIcons []string
+
+	tokenizer := html.NewTokenizer(reader)
+	for {
+		tt := tokenizer.Next()
+		if tt == html.ErrorToken {
+			break
+		}
+		token := tokenizer.Token()
+		if tt == html.EndTagToken && token.Data == "head" || tt == html.StartTagToken && token.Data == "body" {
+			break
+		}
+		if tt != html.StartTagToken && tt != html.SelfClosingTagToken {
+			continue
+		}
+		switch token.Data {
+		case "base":
+			if href := attr(token, "href"); len(href) > 0 {
+				base = resolve(base, href)
+			}
+		case "link":
+			href := attr(token, "href")
+			if len(href) == 0 {
+				continue
+			}
+			for _, rel := range strings.Fields(strings.ToLower(attr(token, "rel"))) {
+				if rel == "icon" {
+					icons = append(icons, resolve(base, href))
+					break
+				} else if rel == "apple-touch-icon" || rel == "apple-touch-icon-precomposed" {
+					touchIcons = append(touchIcons, resolve(base, href))
+					break
+				}
+			}
+		}
+	}
+
+	return append(icons, touchIcons...)
+
+}
+
+func attr(token html.Token, name string) string {
+	for _, a := range token.Attr {
+		if a.Key == name {
+			return strings.TrimSpace(a.Val)
+		}
+	}
+	return ""
+}
+
+func resolve(base, ref string) string {
+	b, err := url.Parse(base)
+	if err != nil {
+		return ""
+	}
+	r, err := url.Parse(ref)
+	if err != nil {
+		return ""
+	}
+	return b.ResolveReference(r).String()
+}
+
+func rootOf(rawurl string) string {
+	return resolve(rawurl, "/")
+}
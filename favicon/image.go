@@ -0,0 +1,276 @@
+package favicon
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"image"
+	"image/color"
+	_ "image/gif"  // register decoder
+	_ "image/jpeg" // register decoder
+	"image/png"
+)
+
+const (
+	// Size is the width and height of normalized icons in pixels.
+	Size = 32
+	// MimeType is the format of normalized icons.
+	MimeType = "image/png"
+	// maxDimension is the largest width or height of an image which is decoded.
+	maxDimension = 1024
+)
+
+var (
+	// ErrBadIcon indicates that the retrieved data cannot be decoded as an image.
+	ErrBadIcon = errors.New("Cannot decode icon")
+	pngMagic   = []byte("\x89PNG\r\n\x1a\n")
+)
+
+// Normalize decodes an ICO, PNG, GIF or JPEG image and reencodes it as a PNG of Size x Size pixels.
+// Images which are not square are scaled to fit and centered on a transparent background.
+func Normalize(data []byte) ([]byte, error) {
+
+	var img image.Image
+	var err error
+	if isICO(data) {
+		img, err = decodeICO(data)
+	} else {
+		img, err = decodeImage(data)
+	}
+	if err != nil {
+		return nil, ErrBadIcon
+	}
+
+	buf := &bytes.Buffer{}
+	if err := png.Encode(buf, scale(img, Size)); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+
+}
+
+// decodeImage decodes a PNG, GIF or JPEG image unless its header declares dimensions above the maximum,
+// a small file must not force the allocation of a huge image.
+func decodeImage(data []byte) (image.Image, error) {
+	config, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	if config.Width > maxDimension || config.Height > maxDimension {
+		return nil, ErrBadIcon
+	}
+	img, _, err := image.Decode(bytes.NewReader(data))
+	return img, err
+}
+
+// scale resizes the image to fit a square of the given size using box sampling.
+func scale(src image.Image, size int) *image.RGBA {
+
+	dst := image.NewRGBA(image.Rect(0, 0, size, size))
+	b := src.Bounds()
+	sw, sh := b.Dx(), b.Dy()
+	if sw == 0 || sh == 0 {
+		return dst
+	}
+
+	// fit within size preserving the aspect ratio
+	dw, dh := size, size
+	if sw > sh {
+		dh = maxInt(1, size*sh/sw)
+	} else if sh > sw {
+		dw = maxInt(1, size*sw/sh)
+	}
+	ox, oy := (size-dw)/2, (size-dh)/2
+
+	for y := 0; y < dh; y++ {
+		y0 := b.Min.Y + y*sh/dh
+		y1 := maxInt(y0+1, b.Min.Y+(y+1)*sh/dh)
+		for x := 0; x < dw; x++ {
+			x0 := b.Min.X + x*sw/dw
+			x1 := maxInt(x0+1, b.Min.X+(x+1)*sw/dw)
+			var r, g, bl, a, n uint32
+			for sy := y0; sy < y1; sy++ {
+				for sx := x0; sx < x1; sx++ {
+					pr, pg, pb, pa := src.At(sx, sy).RGBA()
+					r, g, bl, a = r+pr, g+pg, bl+pb, a+pa
+					n++
+				}
+			}
+			dst.SetRGBA(ox+x, oy+y, color.RGBA{uint8(r / n >> 8), uint8(g / n >> 8), uint8(bl / n >> 8), uint8(a / n >> 8)})
+		}
+	}
+
+	return dst
+
+}
+
+func maxInt(a, b int) int {
+	if a > b {
+		return a
+	}
+	return b
+}
+
+/********** ICO decoding **********/
+
+// icoEntry is an entry in the ICO directory
+type icoEntry struct {
+	Width    uint8
+	Height   uint8
+	Colors   uint8
+	Reserved uint8
+	Planes   uint16
+	BitCount uint16
+	Size     uint32
+	Offset   uint32
+}
+
+// bitmapHeader is the BITMAPINFOHEADER of an embedded DIB
+type bitmapHeader struct {
+	Size          uint32
+	Width         int32
+	Height        int32
+	Planes        uint16
+	BitCount      uint16
+	Compression   uint32
+	SizeImage     uint32
+	XPelsPerMeter int32
+	YPelsPerMeter int32
+	ColorsUsed    uint32
+	ColorsImp     uint32
+}
+
+func isICO(data []byte) bool {
+	return len(data) >= 6 && data[0] == 0 && data[1] == 0 && data[2] == 1 && data[3] == 0
+}
+
+// decodeICO decodes the image in the icon file closest in size to, but not smaller than, Size.
+// Embedded PNG images and uncompressed bitmaps of 1, 4, 8, 24 and 32 bits per pixel are supported.
+func decodeICO(data []byte) (image.Image, error) {
+
+	count := int(binary.LittleEndian.Uint16(data[4:6]))
+	if count == 0 || len(data) < 6+count*16 {
+		return nil, ErrBadIcon
+	}
+
+	var best *icoEntry
+	bestWidth := 0
+	for i := 0; i < count; i++ {
+		entry := &icoEntry{}
+		binary.Read(bytes.NewReader(data[6+i*16:6+(i+1)*16]), binary.LittleEndian, entry)
+		if int64(entry.Offset)+int64(entry.Size) > int64(len(data)) {
+			continue
+		}
+		width := int(entry.Width)
+		if width == 0 {
+			width = 256
+		}
+		switch {
+		case best == nil:
+		case width == bestWidth && entry.BitCount > best.BitCount:
+		case bestWidth < Size && width > bestWidth:
+		case width >= Size && width < bestWidth:
+		default:
+			continue
+		}
+		best, bestWidth = entry, width
+	}
+	if best == nil {
+		return nil, ErrBadIcon
+	}
+
+	payload := data[best.Offset : best.Offset+best.Size]
+	if bytes.HasPrefix(payload, pngMagic) {
+		return decodeImage(payload)
+	}
+	return decodeDIB(payload)
+
+}
+
+func decodeDIB(data []byte) (image.Image, error) {
+
+	header := &bitmapHeader{}
+	if err := binary.Read(bytes.NewReader(data), binary.LittleEndian, header); err != nil {
+		return nil, ErrBadIcon
+	}
+	if header.Compression != 0 || header.Width <= 0 || header.Width > 256 {
+		return nil, ErrBadIcon
+	}
+
+	width := int(header.Width)
+	height := int(header.Height) / 2 // height includes the AND mask
+	if height <= 0 || height > 256 {
+		return nil, ErrBadIcon
+	}
+
+	bpp := int(header.BitCount)
+	offset := int(header.Size)
+
+	var palette []color.NRGBA
+	if bpp <= 8 {
+		colors := int(header.ColorsUsed)
+		if colors == 0 || colors > 1<<uint(bpp) {
+			colors = 1 << uint(bpp)
+		}
+		if offset+colors*4 > len(data) {
+			return nil, ErrBadIcon
+		}
+		for i := 0; i < colors; i++ {
+			p := data[offset+i*4:]
+			palette = append(palette, color.NRGBA{p[2], p[1], p[0], 0xff})
+		}
+		offset += colors * 4
+	}
+
+	stride := ((width*bpp + 31) / 32) * 4
+	maskStride := ((width + 31) / 32) * 4
+	maskOffset := offset + stride*height
+	if maskOffset > len(data) {
+		return nil, ErrBadIcon
+	}
+	hasMask := maskOffset+maskStride*height <= len(data)
+
+	img := image.NewNRGBA(image.Rect(0, 0, width, height))
+	hasAlpha := false
+	for y := 0; y < height; y++ {
+		row := data[offset+(height-1-y)*stride:]
+		for x := 0; x < width; x++ {
+			var c color.NRGBA
+			switch bpp {
+			case 32:
+				c = color.NRGBA{row[x*4+2], row[x*4+1], row[x*4], row[x*4+3]}
+				hasAlpha = hasAlpha || c.A != 0
+			case 24:
+				c = color.NRGBA{row[x*3+2], row[x*3+1], row[x*3], 0xff}
+			case 8, 4, 1:
+				bit := x * bpp
+				index := int(row[bit/8]>>uint(8-bpp-bit%8)) & (1<<uint(bpp) - 1)
+				if index < len(palette) {
+					c = palette[index]
+				}
+			default:
+				return nil, ErrBadIcon
+			}
+			img.SetNRGBA(x, y, c)
+		}
+	}
+
+	// apply the AND mask if the image has no alpha channel of its own
+	if !hasAlpha {
+		for y := 0; y < height; y++ {
+			for x := 0; x < width; x++ {
+				c := img.NRGBAAt(x, y)
+				c.A = 0xff
+				if hasMask {
+					if row := data[maskOffset+(height-1-y)*maskStride:]; row[x/8]&(0x80>>uint(x%8)) != 0 {
+						c.A = 0
+					}
+				}
+				img.SetNRGBA(x, y, c)
+			}
+		}
+	}
+
+	return img, nil
+
+}
@@ -0,0 +1,172 @@
+package favicon
+
+import (
+	"bytes"
+	"encoding/binary"
+	"hash/crc32"
+	"image"
+	"image/color"
+	"image/png"
+	"testing"
+)
+
+func TestNormalizePNG(t *testing.T) {
+
+	t.Parallel()
+
+	// a wide logo is scaled to fit and centered vertically
+	src := image.NewRGBA(image.Rect(0, 0, 88, 31))
+	for y := 0; y < 31; y++ {
+		for x := 0; x < 88; x++ {
+			src.Set(x, y, color.RGBA{0xff, 0, 0, 0xff})
+		}
+	}
+
+	img := normalizeAndDecode(t, encodePNG(t, src))
+
+	if c := color.RGBAModel.Convert(img.At(Size/2, Size/2)).(color.RGBA); c != (color.RGBA{0xff, 0, 0, 0xff}) {
+		t.Errorf("Bad center pixel: %v", c)
+	}
+	if _, _, _, a := img.At(0, 0).RGBA(); a != 0 {
+		t.Errorf("Expected transparent corner, alpha: %d", a)
+	}
+
+}
+
+func TestNormalizeICOWithPNG(t *testing.T) {
+
+	t.Parallel()
+
+	small := image.NewRGBA(image.Rect(0, 0, 16, 16))
+	large := image.NewRGBA(image.Rect(0, 0, 48, 48))
+	for y := 0; y < 48; y++ {
+		for x := 0; x < 48; x++ {
+			large.Set(x, y, color.RGBA{0, 0, 0xff, 0xff})
+		}
+	}
+
+	ico := makeICO(t, []icoImage{
+		{16, 32, encodePNG(t, small)},
+		{48, 32, encodePNG(t, large)},
+	})
+
+	img := normalizeAndDecode(t, ico)
+	if c := color.RGBAModel.Convert(img.At(0, 0)).(color.RGBA); c != (color.RGBA{0, 0, 0xff, 0xff}) {
+		t.Errorf("Expected larger image to be chosen, pixel: %v", c)
+	}
+
+}
+
+func TestNormalizeICOWithBitmap(t *testing.T) {
+
+	t.Parallel()
+
+	// 2x2 24 bit bitmap: bottom row green, top row white, top-left pixel masked out
+	var dib bytes.Buffer
+	binary.Write(&dib, binary.LittleEndian, &bitmapHeader{Size: 40, Width: 2, Height: 4, Planes: 1, BitCount: 24})
+	dib.Write([]byte{0, 0xff, 0, 0, 0xff, 0, 0, 0})             // bottom row, padded to 8 bytes
+	dib.Write([]byte{0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0, 0}) // top row
+	dib.Write([]byte{0, 0, 0, 0})                               // mask bottom row
+	dib.Write([]byte{0x80, 0, 0, 0})                            // mask top row
+
+	img, err := decodeICO(makeICO(t, []icoImage{{2, 24, dib.Bytes()}}))
+	if err != nil {
+		t.Fatalf("Cannot decode icon: %s", err.Error())
+	}
+
+	if _, _, _, a := img.At(0, 0).RGBA(); a != 0 {
+		t.Errorf("Expected masked pixel to be transparent, alpha: %d", a)
+	}
+	if c := color.NRGBAModel.Convert(img.At(1, 0)).(color.NRGBA); c != (color.NRGBA{0xff, 0xff, 0xff, 0xff}) {
+		t.Errorf("Bad top right pixel: %v", c)
+	}
+	if c := color.NRGBAModel.Convert(img.At(0, 1)).(color.NRGBA); c != (color.NRGBA{0, 0xff, 0, 0xff}) {
+		t.Errorf("Bad bottom left pixel: %v", c)
+	}
+
+}
+
+func TestNormalizeBad(t *testing.T) {
+
+	t.Parallel()
+
+	for _, data := range [][]byte{nil, []byte("<html></html>"), {0, 0, 1, 0, 1, 0}} {
+		if _, err := Normalize(data); err != ErrBadIcon {
+			t.Errorf("Expected ErrBadIcon for %q, actual %v", data, err)
+		}
+	}
+
+}
+
+func TestNormalizeTooLarge(t *testing.T) {
+
+	t.Parallel()
+
+	// a tiny file declaring a huge image is rejected before decoding
+	huge := resizePNG(encodePNG(t, image.NewRGBA(image.Rect(0, 0, 1, 1))), 30000, 30000)
+	if _, err := Normalize(huge); err != ErrBadIcon {
+		t.Errorf("Expected ErrBadIcon for huge png, actual %v", err)
+	}
+	if _, err := Normalize(makeICO(t, []icoImage{{0, 32, huge}})); err != ErrBadIcon {
+		t.Errorf("Expected ErrBadIcon for huge png in icon, actual %v", err)
+	}
+
+	// the maximum itself is accepted
+	img := normalizeAndDecode(t, encodePNG(t, image.NewRGBA(image.Rect(0, 0, maxDimension, 1))))
+	if b := img.Bounds(); b.Dx() != Size {
+		t.Errorf("Bad normalized size: %s", b)
+	}
+
+}
+
+type icoImage struct {
+	width    uint8
+	bitCount uint16
+	data     []byte
+}
+
+func makeICO(t *testing.T, images []icoImage) []byte {
+	var buf bytes.Buffer
+	binary.Write(&buf, binary.LittleEndian, []uint16{0, 1, uint16(len(images))})
+	offset := 6 + 16*len(images)
+	for _, i := range images {
+		binary.Write(&buf, binary.LittleEndian, &icoEntry{Width: i.width, Height: i.width, Planes: 1, BitCount: i.bitCount, Size: uint32(len(i.data)), Offset: uint32(offset)})
+		offset += len(i.data)
+	}
+	for _, i := range images {
+		buf.Write(i.data)
+	}
+	return buf.Bytes()
+}
+
+func encodePNG(t *testing.T, img image.Image) []byte {
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		t.Fatalf("Cannot encode png: %s", err.Error())
+	}
+	return buf.Bytes()
+}
+
+// resizePNG rewrites the dimensions in the IHDR chunk of the png and its checksum.
+func resizePNG(data []byte, width, height uint32) []byte {
+	result := append([]byte{}, data...)
+	binary.BigEndian.PutUint32(result[16:20], width)
+	binary.BigEndian.PutUint32(result[20:24], height)
+	binary.BigEndian.PutUint32(result[29:33], crc32.ChecksumIEEE(result[12:29]))
+	return result
+}
+
+func normalizeAndDecode(t *testing.T, data []byte) image.Image {
+	result, err := Normalize(data)
+	if err != nil {
+		t.Fatalf("Cannot normalize icon: %s", err.Error())
+	}
+	img, err := png.Decode(bytes.NewReader(result))
+	if err != nil {
+		t.Fatalf("Normalized icon is not a png: %s", err.Error())
+	}
+	if b := img.Bounds(); b.Dx() != Size || b.Dy() != Size {
+		t.Fatalf("Bad normalized size: %s", b)
+	}
+	return img
+}
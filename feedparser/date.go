@@ -0,0 +1,220 @@
+package feedparser
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// maxBadDates limits the number of unparseable date values recorded per feed.
+	maxBadDates = 10
+)
+
+var (
+	// monthNames maps English, German and French month names and abbreviations, in lower case, to English abbreviations.
+	monthNames = map[string]string{
+		"january": "Jan", "february": "Feb", "march": "Mar", "april": "Apr", "may": "May", "june": "Jun",
+		"july": "Jul", "august": "Aug", "september": "Sep", "sept": "Sep", "october": "Oct", "november": "Nov", "december": "Dec",
+		"jan": "Jan", "feb": "Feb", "mar": "Mar", "apr": "Apr", "jun": "Jun", "jul": "Jul", "aug": "Aug", "sep": "Sep",
+		"oct": "Oct", "nov": "Nov", "dec": "Dec",
+		// German
+		"januar": "Jan", "jänner": "Jan", "jän": "Jan", "februar": "Feb", "märz": "Mar", "maerz": "Mar", "mär": "Mar",
+		"mrz": "Mar", "mai": "May", "juni": "Jun", "juli": "Jul", "oktober": "Oct", "okt": "Oct", "dezember": "Dec",
+		"dez": "Dec",
+		// French
+		"janvier": "Jan", "janv": "Jan", "février": "Feb", "fevrier": "Feb", "févr": "Feb", "fevr": "Feb", "mars": "Mar",
+		"avril": "Apr", "avr": "Apr", "juin": "Jun", "juillet": "Jul", "juil": "Jul", "août": "Aug", "aout": "Aug",
+		"septembre": "Sep", "octobre": "Oct", "novembre": "Nov", "décembre": "Dec", "decembre": "Dec", "déc": "Dec",
+	}
+	// weekdayNames are English, German and French weekday names and abbreviations in lower case,
+	// weekdays are redundant and frequently wrong, they are removed before parsing.
+	// The French "mar" is missing as it is taken for March.
+	weekdayNames = map[string]bool{
+		"mon": true, "tue": true, "tues": true, "wed": true, "thu": true, "thur": true, "thurs": true, "fri": true,
+		"sat": true, "sun": true, "monday": true, "tuesday": true, "wednesday": true, "thursday": true, "friday": true,
+		"saturday": true, "sunday": true, "mo": true, "di": true, "mi": true, "do": true, "fr": true, "sa": true,
+		"so": true, "montag": true, "dienstag": true, "mittwoch": true, "donnerstag": true, "freitag": true,
+		"samstag": true, "sonnabend": true, "sonntag": true, "lun": true, "mer": true, "jeu": true, "ven": true,
+		"sam": true, "dim": true, "lundi": true, "mardi": true, "mercredi": true, "jeudi": true, "vendredi": true,
+		"samedi": true, "dimanche": true,
+	}
+	// zoneOffsets maps the obsolete RFC 822 zone names and other common abbreviations to their offsets.
+	// Go only resolves the abbreviation of the local zone, all others are parsed as UTC.
+	zoneOffsets = map[string]string{
+		"ut": "+0000", "utc": "+0000", "gmt": "+0000", "z": "+0000", "wet": "+0000",
+		"est": "-0500", "edt": "-0400", "cst": "-0600", "cdt": "-0500", "mst": "-0700", "mdt": "-0600",
+		"pst": "-0800", "pdt": "-0700", "akst": "-0900", "akdt": "-0800", "hst": "-1000",
+		"ast": "-0400", "adt": "-0300", "nst": "-0330", "ndt": "-0230",
+		"bst": "+0100", "west": "+0100", "cet": "+0100", "mez": "+0100", "cest": "+0200", "mesz": "+0200",
+		"eet": "+0200", "eest": "+0300", "msk": "+0300", "ist": "+0530", "sgt": "+0800", "hkt": "+0800",
+		"jst": "+0900", "kst": "+0900", "aest": "+1000", "aedt": "+1100", "nzst": "+1200", "nzdt": "+1300",
+	}
+	// zoneWithOffset matches zones such as GMT+1, UTC+01:00 or GMT-0530.
+	zoneWithOffset = regexp.MustCompile(`^(?i:gmt|utc|ut)([+-])(\d{1,2}):?(\d{2})?$`)
+	// dateLayouts are tried in order after normalizing the value, layouts without a zone are parsed in the fallback location.
+	dateLayouts = makeDateLayouts()
+)
+
+// ParseTime parses a date in any of the formats found in feeds, interpreting dates without a zone in the given location,
+// UTC if nil. An empty value returns the zero time without an error.
+func ParseTime(value string, location *time.Location) (time.Time, error) {
+
+	value = strings.TrimSpace(value)
+	if len(value) == 0 {
+		return time.Time{}, nil
+	}
+	if location == nil {
+		location = time.UTC
+	}
+
+	// unix timestamps
+	if n, err := strconv.ParseInt(value, 10, 64); err == nil && len(value) >= 9 && len(value) <= 10 {
+		return time.Unix(n, 0).UTC(), nil
+	}
+
+	normalized := normalizeTime(value)
+	for _, layout := range dateLayouts {
+		var t time.Time
+		var err error
+		if hasZone(layout) {
+			t, err = time.Parse(layout, normalized)
+		} else {
+			t, err = time.ParseInLocation(layout, normalized, location)
+		}
+		if err == nil {
+			return t, nil
+		}
+	}
+
+	return time.Time{}, fmt.Errorf("Cannot parse date: %s", value)
+
+}
+
+// parseTime parses the value, recording it on the feed if it cannot be parsed.
+func (z *Parser) parseTime(value string) time.Time {
+	t, err := ParseTime(value, z.Location)
+	if err != nil && z.feed != nil && len(z.feed.BadDates) < maxBadDates {
+		z.feed.BadDates = append(z.feed.BadDates, strings.TrimSpace(value))
+	}
+	return t
+}
+
+// normalizeTime removes weekdays, commas, comments and the German "Uhr",
+// translates month names to English abbreviations and zone names to numeric offsets.
+func normalizeTime(value string) string {
+
+	fields := strings.Fields(strings.Replace(value, ",", " ", -1))
+	result := make([]string, 0, len(fields))
+
+	for i, field := range fields {
+
+		// comments, as in "-0800 (PST)"
+		if strings.HasPrefix(field, "(") && strings.HasSuffix(field, ")") && i > 0 {
+			continue
+		}
+
+		lower := strings.ToLower(strings.TrimSuffix(field, "."))
+
+		if lower == "uhr" || (i == 0 && weekdayNames[lower]) {
+			continue
+		}
+		if month, ok := monthNames[lower]; ok {
+			result = append(result, month)
+			continue
+		}
+		if i > 0 {
+			if offset, ok := zoneOffsets[lower]; ok {
+				result = append(result, offset)
+				continue
+			}
+			if m := zoneWithOffset.FindStringSubmatch(field); m != nil {
+				hours, minutes := m[2], m[3]
+				if len(hours) == 1 {
+					hours = "0" + hours
+				}
+				if len(minutes) == 0 {
+					minutes = "00"
+				}
+				result = append(result, m[1]+hours+minutes)
+				continue
+			}
+		}
+		// a day followed by a dot, as in "2. März"
+		if n := len(field); n > 1 && n <= 3 && field[n-1] == '.' && isDigits(field[:n-1]) {
+			result = append(result, field[:n-1])
+			continue
+		}
+
+		result = append(result, field)
+
+	}
+
+	return strings.Join(result, " ")
+
+}
+
+func makeDateLayouts() []string {
+
+	zones := []string{" -0700", " -07:00", ""}
+	var layouts []string
+
+	// RFC 822 and variants, weekdays and commas removed
+	for _, date := range []string{"2 Jan 2006", "2 Jan 06", "Jan 2 2006", "2006 Jan 2", "2-Jan-06", "2-Jan-2006"} {
+		for _, clock := range []string{" 15:04:05", " 15:04"} {
+			for _, zone := range zones {
+				layouts = append(layouts, date+clock+zone)
+			}
+		}
+	}
+	layouts = append(layouts,
+		"Jan 2 15:04:05 -0700 2006", // unix date with the zone replaced
+		"Jan 2 15:04:05 2006",       // ansi c
+		"2 Jan 2006",
+		"Jan 2 2006",
+		"Jan 2006",
+	)
+
+	// ISO 8601 and variants, fractional seconds are accepted after the seconds
+	for _, date := range []string{"2006-01-02T", "2006-01-02 "} {
+		for _, clock := range []string{"15:04:05", "15:04"} {
+			for _, zone := range []string{"Z07:00", "Z0700", " -0700", " -07:00", ""} {
+				layouts = append(layouts, date+clock+zone)
+			}
+		}
+	}
+	layouts = append(layouts,
+		"20060102T150405Z0700",
+		"20060102T150405",
+		"2006-01-02",
+		"20060102",
+	)
+
+	// German numeric dates
+	for _, clock := range []string{" 15:04:05", " 15:04", ""} {
+		for _, zone := range zones {
+			if clock == "" && zone != "" {
+				continue
+			}
+			layouts = append(layouts, "2.1.2006"+clock+zone)
+		}
+	}
+
+	return layouts
+
+}
+
+func hasZone(layout string) bool {
+	return strings.Contains(layout, "-07") || strings.Contains(layout, "Z07")
+}
+
+func isDigits(value string) bool {
+	for _, r := range value {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return len(value) > 0
+}
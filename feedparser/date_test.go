@@ -0,0 +1,129 @@
+package feedparser
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseTime(t *testing.T) {
+
+	t.Parallel()
+
+	berlin, err := time.LoadLocation("Europe/Berlin")
+	if err != nil {
+		t.Skipf("Time zone database not available: %s", err.Error())
+	}
+
+	utc := time.Date(2016, time.March, 2, 10, 30, 0, 0, time.UTC)
+	local := time.Date(2016, time.March, 2, 10, 30, 0, 0, berlin)
+	day := time.Date(2016, time.March, 2, 0, 0, 0, 0, berlin)
+
+	tests := []struct {
+		value    string
+		expected time.Time
+	}{
+		// RFC 822 and variants
+		{"Wed, 02 Mar 2016 10:30:00 GMT", utc},
+		{"Wed, 02 Mar 2016 10:30:00 +0000", utc},
+		{"Wed, 2 Mar 2016 10:30:00 UT", utc},
+		{"Wed, 02 Mar 2016 10:30 GMT", utc},
+		{"Wed, 02 Mar 16 10:30:00 GMT", utc},
+		{"Wed, 02 Mar 2016 05:30:00 EST", utc},
+		{"Wed, 02 Mar 2016 02:30:00 PST", utc},
+		{"Wed, 02 Mar 2016 03:30:00 MST", utc},
+		{"Wed, 02 Mar 2016 11:30:00 CET", utc},
+		{"Wed, 02 Mar 2016 11:30:00 MEZ", utc},
+		{"Wed, 02 Mar 2016 02:30:00 -0800 (PST)", utc},
+		{"Wed, 02 Mar 2016 11:30:00 GMT+1", utc},
+		{"Wed, 02 Mar 2016 12:30:00 UTC+02:00", utc},
+		{"Thu, 02 Mar 2016 10:30:00 GMT", utc}, // wrong weekday
+		{"Wednesday, 02-Mar-16 10:30:00 GMT", utc},
+		{"02 Mar 2016 10:30:00 Z", utc},
+		{"Wed, 02 March 2016 10:30:00 +00:00", utc},
+		{"March 2, 2016 10:30 GMT", utc},
+		{"Mar 2 10:30:00 2016", local},
+		{"Wed Mar  2 10:30:00 EST 2016", utc.Add(5 * time.Hour)},
+		{"Wed Mar 02 10:30:00 -0500 2016", utc.Add(5 * time.Hour)},
+		// ISO 8601 variants
+		{"2016-03-02T10:30:00Z", utc},
+		{"2016-03-02T10:30:00.123Z", utc.Add(123 * time.Millisecond)},
+		{"2016-03-02T11:30:00+01:00", utc},
+		{"2016-03-02T11:30:00+0100", utc},
+		{"2016-03-02T10:30Z", utc},
+		{"2016-03-02T11:30+01:00", utc},
+		{"2016-03-02 10:30:00 +0000", utc},
+		{"2016-03-02 10:30:00", local},
+		{"2016-03-02T10:30:00", local},
+		{"2016-03-02 10:30", local},
+		{"20160302T103000Z", utc},
+		{"2016-03-02", day},
+		{"20160302", day},
+		// localized
+		{"Mi, 02 Mär 2016 11:30:00 +0100", utc},
+		{"Mittwoch, 2. März 2016, 10:30 Uhr", local},
+		{"2. März 2016 10:30", local},
+		{"02. Mrz. 2016 10:30:00 MEZ", utc.Add(-time.Hour)},
+		{"02.03.2016 10:30", local},
+		{"2.3.2016", day},
+		{"mer., 2 mars 2016 10:30:00 +0000", utc},
+		{"2 décembre 2016", time.Date(2016, time.December, 2, 0, 0, 0, 0, berlin)},
+		// unix timestamp
+		{"1456914600", utc},
+		// garbage
+		{"yesterday", time.Time{}},
+		{"2016-13-45", time.Time{}},
+		{"Wed, 02 Foo 2016 10:30:00 GMT", time.Time{}},
+	}
+
+	for _, test := range tests {
+		actual, err := ParseTime(test.value, berlin)
+		if test.expected.IsZero() {
+			if err == nil {
+				t.Errorf("Expected error for %s, actual %s", test.value, actual)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("Cannot parse %s: %s", test.value, err.Error())
+		} else if !actual.Equal(test.expected) {
+			t.Errorf("Bad date for %s: expected %s, actual %s", test.value, test.expected, actual)
+		}
+	}
+
+	if actual, err := ParseTime("  ", nil); err != nil || !actual.IsZero() {
+		t.Errorf("Expected zero time for empty value: %s %v", actual, err)
+	}
+
+	if actual, err := ParseTime("2016-03-02 10:30", nil); err != nil || !actual.Equal(utc) {
+		t.Errorf("Expected UTC without a location: %s %v", actual, err)
+	}
+
+}
+
+func TestBadDates(t *testing.T) {
+
+	t.Parallel()
+
+	feed := `<rss version="2.0"><channel><title>Dates</title>
+	<item><guid>1</guid><pubDate>Mittwoch, 2. März 2016, 10:30 Uhr</pubDate></item>
+	<item><guid>2</guid><pubDate>gestern</pubDate></item>
+	<item><guid>3</guid><pubDate>2016-03-02 10:30</pubDate></item>
+	</channel></rss>`
+
+	p := NewParser()
+	p.Location = time.FixedZone("CET", 60*60)
+	f, err := p.Parse(strings.NewReader(feed))
+	if err != nil {
+		t.Fatalf("Cannot parse feed: %s", err.Error())
+	}
+
+	expected := time.Date(2016, time.March, 2, 9, 30, 0, 0, time.UTC)
+	assertEqual(t, 3, len(f.Entries))
+	assertEqual(t, true, f.Entries[0].Updated.Equal(expected))
+	assertEqual(t, true, f.Entries[1].Updated.IsZero())
+	assertEqual(t, true, f.Entries[2].Updated.Equal(expected))
+	assertEqual(t, 1, len(f.BadDates))
+	assertEqual(t, "gestern", f.BadDates[0])
+
+}
@@ -0,0 +1,126 @@
+package feedparser
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"mime"
+	"net/url"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+
+	"golang.org/x/net/html/charset"
+)
+
+const (
+	// maxWarnings limits the number of warnings collected per feed.
+	maxWarnings = 100
+)
+
+var (
+	xmlDeclEncoding = regexp.MustCompile(`encoding\s*=\s*["']([^"']+)["']`)
+)
+
+// diagnose collects warnings about problems which the lenient parser worked around.
+// It runs before the post processors so that missing IDs are not yet replaced by hashes.
+func (z *Parser) diagnose(raw []byte, contentType string, isJSON bool) {
+
+	if !isJSON {
+		z.diagnoseXML(raw, contentType)
+	} else if !utf8.Valid(raw) {
+		z.warn("Document is not valid UTF-8")
+	}
+
+	for _, date := range z.feed.BadDates {
+		z.warn("Cannot parse date: %s", date)
+	}
+
+	z.diagnoseURL("feed", "link", z.feed.LinkAlternate)
+	z.diagnoseURL("feed", "self link", z.feed.LinkSelf)
+	z.diagnoseURL("feed", "icon", z.feed.Icon)
+
+	ids := make(map[string]int)
+	for i, entry := range z.feed.Entries {
+		name := fmt.Sprintf("entry %d", i+1)
+		if isEmpty(entry.ID) {
+			z.warn("Entry %d has no ID, a hash of its content is used", i+1)
+		} else if first, ok := ids[entry.ID]; ok {
+			z.warn("Entry %d has the same ID as entry %d: %s", i+1, first, entry.ID)
+		} else {
+			ids[entry.ID] = i + 1
+		}
+		z.diagnoseURL(name, "link", entry.LinkAlternate)
+		z.diagnoseURL(name, "self link", entry.LinkSelf)
+		for _, enclosure := range entry.Enclosures {
+			z.diagnoseURL(name, "enclosure", enclosure.URL)
+		}
+	}
+
+}
+
+// diagnoseXML reads the document again with a strict decoder to find the markup errors the parser ignored,
+// such as mismatched tags or undefined entities, and compares the declared encodings.
+func (z *Parser) diagnoseXML(raw []byte, contentType string) {
+
+	var declared string
+	decoder := xml.NewDecoder(bytes.NewReader(raw))
+	decoder.CharsetReader = charset.NewReaderLabel
+	decoder.Strict = true
+
+	for {
+		token, err := decoder.Token()
+		if err != nil {
+			if err != io.EOF {
+				z.warn("Markup error: %s", err.Error())
+			}
+			break
+		}
+		if pi, ok := token.(xml.ProcInst); ok && pi.Target == "xml" {
+			if m := xmlDeclEncoding.FindSubmatch(pi.Inst); m != nil {
+				declared = string(m[1])
+			}
+		}
+	}
+
+	if isEmpty(declared) || strings.EqualFold(declared, "utf-8") {
+		if !utf8.Valid(raw) {
+			z.warn("Document is not valid UTF-8 and does not declare another encoding")
+		}
+	}
+
+	if _, params, err := mime.ParseMediaType(contentType); err == nil {
+		if served := params["charset"]; !isEmpty(served) && !isEmpty(declared) && !sameEncoding(served, declared) {
+			z.warn("Content type charset %s differs from the declared encoding %s, which is used", served, declared)
+		}
+	}
+
+}
+
+// diagnoseURL warns about links which are still relative, having no xml:base to resolve them against.
+func (z *Parser) diagnoseURL(name, kind, value string) {
+	if isEmpty(value) {
+		return
+	}
+	if u, err := url.Parse(value); err != nil {
+		z.warn("Invalid %s in %s: %s", kind, name, value)
+	} else if !u.IsAbs() {
+		z.warn("Relative %s in %s: %s", kind, name, value)
+	}
+}
+
+func (z *Parser) warn(format string, args ...interface{}) {
+	if len(z.feed.Warnings) < maxWarnings {
+		z.feed.Warnings = append(z.feed.Warnings, fmt.Sprintf(format, args...))
+	}
+}
+
+func sameEncoding(a, b string) bool {
+	ea, na := charset.Lookup(a)
+	eb, nb := charset.Lookup(b)
+	if ea == nil || eb == nil {
+		return strings.EqualFold(a, b)
+	}
+	return na == nb
+}
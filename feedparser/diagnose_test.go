@@ -0,0 +1,107 @@
+package feedparser
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestDiagnose(t *testing.T) {
+
+	t.Parallel()
+
+	feed := `<?xml version="1.0" encoding="iso-8859-1"?>
+<rss version="2.0"><channel><title>Diagnose &nbsp;</title><link>/</link>
+	<item><title>no id</title><link>http://localhost/1</link></item>
+	<item><guid>2</guid><link>/2</link><pubDate>gestern</pubDate></item>
+	<item><guid>2</guid><link>http://localhost/3</link><enclosure url="3.mp3" type="audio/mpeg"/></item>
+	<item><guid>4</guid><title><b>bold</title></item>
+</channel></rss>`
+
+	p := NewParser()
+	p.Diagnose = true
+	f, err := p.ParseContentType(strings.NewReader(feed), "application/rss+xml; charset=utf-8")
+	if err != nil {
+		t.Fatalf("Cannot parse feed: %s", err.Error())
+	}
+
+	expected := []string{
+		"Markup error: ",
+		"Content type charset utf-8 differs from the declared encoding iso-8859-1",
+		"Cannot parse date: gestern",
+		"Relative link in feed: /",
+		"Entry 1 has no ID",
+		"Relative link in entry 2: /2",
+		"Entry 3 has the same ID as entry 2: 2",
+		"Relative enclosure in entry 3: 3.mp3",
+	}
+
+	if len(f.Warnings) != len(expected) {
+		t.Fatalf("Bad warnings, expected %d, actual %d: %v", len(expected), len(f.Warnings), f.Warnings)
+	}
+	for i, warning := range f.Warnings {
+		if !strings.HasPrefix(warning, expected[i]) {
+			t.Errorf("Bad warning %d, expected %s, actual %s", i, expected[i], warning)
+		}
+	}
+
+	// post processors still run
+	if isEmpty(f.Entries[0].ID) {
+		t.Error("Entry without ID not hashed")
+	}
+
+}
+
+func TestDiagnoseValid(t *testing.T) {
+
+	t.Parallel()
+
+	feed := `<?xml version="1.0" encoding="utf-8"?>
+<feed xmlns="http://www.w3.org/2005/Atom" xml:base="http://localhost/"><title>Valid</title><id>urn:feed</id>
+	<link href="/"/>
+	<entry><id>urn:1</id><title>One</title><link href="1"/><updated>2016-03-02T10:30:00Z</updated></entry>
+</feed>`
+
+	p := NewParser()
+	p.Diagnose = true
+	f, err := p.Parse(strings.NewReader(feed))
+	if err != nil {
+		t.Fatalf("Cannot parse feed: %s", err.Error())
+	}
+	if len(f.Warnings) != 0 {
+		t.Errorf("Unexpected warnings: %v", f.Warnings)
+	}
+
+	// no diagnostics unless requested
+	f, err = NewParser().Parse(strings.NewReader(strings.Replace(feed, "urn:1", "", 1)))
+	if err != nil {
+		t.Fatalf("Cannot parse feed: %s", err.Error())
+	}
+	if len(f.Warnings) != 0 {
+		t.Errorf("Unexpected warnings: %v", f.Warnings)
+	}
+
+}
+
+func TestDiagnoseJSON(t *testing.T) {
+
+	t.Parallel()
+
+	feed := `{"version": "https://jsonfeed.org/version/1", "title": "JSON",
+		"items": [{"id": "1", "url": "/1"}, {"id": "1", "url": "http://localhost/2"}]}`
+
+	p := NewParser()
+	p.Diagnose = true
+	f, err := p.Parse(strings.NewReader(feed))
+	if err != nil {
+		t.Fatalf("Cannot parse feed: %s", err.Error())
+	}
+
+	expected := []string{
+		"Relative link in entry 1: /1",
+		"Entry 2 has the same ID as entry 1: 1",
+	}
+	if strings.Join(f.Warnings, "\n") != strings.Join(expected, "\n") {
+		t.Errorf("Bad warnings: %v", f.Warnings)
+	}
+
+}
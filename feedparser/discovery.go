@@ -0,0 +1,109 @@
+package feedparser
+
+import (
+	"net/url"
+	"strings"
+
+	"golang.org/x/net/html"
+)
+
+var (
+	// feedTypes are the media types of alternate links which point to feeds.
+	feedTypes = map[string]bool{
+		"application/atom+xml":  true,
+		"application/rss+xml":   true,
+		"application/rdf+xml":   true,
+		"application/feed+json": true,
+		"application/json":      true,
+	}
+)
+
+// FeedLink represents a feed advertised by an HTML page
+type FeedLink struct {
+	URL   string
+	Title string
+	Type  string
+}
+
+// FindFeedLinks will find all feeds advertised by <link rel="alternate"> elements in the given HTML content.
+// Relative URLs are resolved against the given base URL, or the URL of a <base> element, if present.
+func FindFeedLinks(base, content string) []*FeedLink {
+
+	if isEmpty(content) {
+		return nil
+	}
+
+	baseURL, err := url.Parse(base)
+	if err != nil {
+		return nil
+	}
+
+	result := []*FeedLink{}
+	seen := make(map[string]bool)
+	tokenizer := html.NewTokenizer(strings.NewReader(content))
+
+Loop:
+	for {
+
+		tt := tokenizer.Next()
+
+		if tt == html.ErrorToken {
+			break Loop
+		}
+
+		if tt != html.StartTagToken && tt != html.SelfClosingTagToken {
+			continue
+		}
+
+		token := tokenizer.Token()
+		attrs := make(map[string]string)
+		for _, attr := range token.Attr {
+			attrs[strings.ToLower(attr.Key)] = strings.TrimSpace(attr.Val)
+		}
+
+		switch token.Data {
+
+		case "base":
+			if href, err := url.Parse(attrs["href"]); err == nil && attrs["href"] != "" {
+				baseURL = baseURL.ResolveReference(href)
+			}
+
+		case "link":
+			if !hasRel(attrs["rel"], "alternate") || hasRel(attrs["rel"], "stylesheet") {
+				continue
+			}
+			mediaType := strings.ToLower(strings.TrimSpace(strings.Split(attrs["type"], ";")[0]))
+			if !feedTypes[mediaType] || attrs["href"] == "" {
+				continue
+			}
+			href, err := url.Parse(attrs["href"])
+			if err != nil {
+				continue
+			}
+			feedURL := baseURL.ResolveReference(href).String()
+			if seen[feedURL] {
+				continue
+			}
+			seen[feedURL] = true
+			result = append(result, &FeedLink{
+				URL:   feedURL,
+				Title: attrs["title"],
+				Type:  mediaType,
+			})
+
+		}
+
+	} // loop
+
+	return result
+
+}
+
+func hasRel(rel, value string) bool {
+	for _, field := range strings.Fields(strings.ToLower(rel)) {
+		if field == value {
+			return true
+		}
+	}
+	return false
+}
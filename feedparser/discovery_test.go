@@ -0,0 +1,61 @@
+package feedparser
+
+import (
+	"testing"
+)
+
+func TestFindFeedLinks(t *testing.T) {
+
+	t.Parallel()
+
+	content := `<!DOCTYPE html>
+<html>
+<head>
+	<title>Example</title>
+	<link rel="stylesheet" href="/style.css" type="text/css">
+	<link rel="alternate" type="application/atom+xml" title="Atom" href="/atom.xml">
+	<link rel="alternate" type="application/rss+xml; charset=utf-8" title="RSS" href="http://feeds.example.com/rss">
+	<link rel="alternate" type="application/rss+xml" title="Duplicate" href="/atom.xml">
+	<link rel="alternate" hreflang="de" href="/de/">
+	<link rel="ALTERNATE" type="application/feed+json" title="JSON" href="feed.json"/>
+</head>
+<body><p>Hello</p></body>
+</html>`
+
+	links := FindFeedLinks("http://example.com/blog/", content)
+	if len(links) != 3 {
+		t.Fatalf("Bad link count, expected %d, actual %d", 3, len(links))
+	}
+
+	expected := []*FeedLink{
+		{URL: "http://example.com/atom.xml", Title: "Atom", Type: "application/atom+xml"},
+		{URL: "http://feeds.example.com/rss", Title: "RSS", Type: "application/rss+xml"},
+		{URL: "http://example.com/blog/feed.json", Title: "JSON", Type: "application/feed+json"},
+	}
+	for i, link := range links {
+		if *link != *expected[i] {
+			t.Errorf("Bad link %d, expected %v, actual %v", i, expected[i], link)
+		}
+	}
+
+}
+
+func TestFindFeedLinksBase(t *testing.T) {
+
+	t.Parallel()
+
+	content := `<html><head><base href="http://cdn.example.com/site/"><link rel="alternate" type="application/rss+xml" href="rss.xml"></head></html>`
+
+	links := FindFeedLinks("http://example.com/", content)
+	if len(links) != 1 {
+		t.Fatalf("Bad link count, expected %d, actual %d", 1, len(links))
+	}
+	if links[0].URL != "http://cdn.example.com/site/rss.xml" {
+		t.Errorf("Bad link URL: %s", links[0].URL)
+	}
+
+	if links := FindFeedLinks("http://example.com/", "<html><head></head></html>"); len(links) != 0 {
+		t.Errorf("Expected no links, actual %d", len(links))
+	}
+
+}
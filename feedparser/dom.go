@@ -16,6 +16,17 @@ type generator struct {
 	Version string `xml:"version,attr"`
 }
 
+type mediaContent struct {
+	URL      string `xml:"url,attr"`
+	Type     string `xml:"type,attr"`
+	FileSize string `xml:"fileSize,attr"`
+	Duration string `xml:"duration,attr"`
+}
+
+type mediaGroup struct {
+	Contents []*mediaContent `xml:"http://search.yahoo.com/mrss/ content"`
+}
+
 type person struct {
 	Name  string `xml:"name"`
 	URI   string `xml:"uri"`
@@ -26,6 +37,14 @@ type rssImage struct {
 	URL string `xml:"url"`
 }
 
+type skipDays struct {
+	Days []string `xml:"day"`
+}
+
+type skipHours struct {
+	Hours []string `xml:"hour"`
+}
+
 type text struct {
 	Text string `xml:",chardata"`
 }
@@ -62,6 +62,14 @@ func (z *elements) IsStackFeed(flavor string, offset int) bool {
 		return z.Level() == 2+offset &&
 			z.stack[0].Match(nsRSS, "rss") &&
 			z.stack[1].Match(nsRSS, "channel")
+
+	case flavorRDF:
+		// items are siblings of the channel, so both are at the feed level
+		return (z.Level() == 1+offset &&
+			z.stack[0].Match(nsRDF, "RDF")) ||
+			(z.Level() == 2+offset &&
+				z.stack[0].Match(nsRDF, "RDF") &&
+				z.stack[1].Match(nsRSS1, "channel"))
 	}
 
 	return false
@@ -82,6 +90,11 @@ func (z *elements) IsStackEntry(flavor string, offset int) bool {
 			z.stack[0].Match(nsRSS, "rss") &&
 			z.stack[1].Match(nsRSS, "channel") &&
 			z.stack[2].Match(nsRSS, "item")
+
+	case flavorRDF:
+		return z.Level() == 2+offset &&
+			z.stack[0].Match(nsRDF, "RDF") &&
+			z.stack[1].Match(nsRSS1, "item")
 	}
 
 	return false
@@ -0,0 +1,84 @@
+package feedparser
+
+import (
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Enclosure is a media file attached to an entry, such as a podcast episode.
+type Enclosure struct {
+	URL      string
+	Type     string
+	Length   int64
+	Duration time.Duration
+}
+
+// addEnclosure appends an enclosure to the entry, merging it with an existing enclosure having the same URL.
+// Feeds frequently list the same file as both an enclosure and a media:content element.
+func (z *Entry) addEnclosure(urlstr, mimeType, length, duration string) {
+
+	urlstr = strings.TrimSpace(urlstr)
+	if isEmpty(urlstr) {
+		return
+	}
+
+	enclosure := z.findEnclosure(urlstr)
+	if enclosure == nil {
+		enclosure = &Enclosure{URL: urlstr}
+		z.Enclosures = append(z.Enclosures, enclosure)
+	}
+
+	if mimeType = strings.TrimSpace(mimeType); isEmpty(enclosure.Type) {
+		enclosure.Type = mimeType
+	}
+	if n, err := strconv.ParseInt(strings.TrimSpace(length), 10, 64); err == nil && n > 0 && enclosure.Length == 0 {
+		enclosure.Length = n
+	}
+	if d := parseDuration(duration); d > 0 && enclosure.Duration == 0 {
+		enclosure.Duration = d
+	}
+
+}
+
+func (z *Entry) findEnclosure(urlstr string) *Enclosure {
+	for _, e := range z.Enclosures {
+		if e.URL == urlstr {
+			return e
+		}
+	}
+	return nil
+}
+
+// finishEnclosures applies the entry-level itunes:duration to enclosures lacking their own.
+func (z *Entry) finishEnclosures() {
+	if z.Duration == 0 {
+		return
+	}
+	for _, e := range z.Enclosures {
+		if e.Duration == 0 {
+			e.Duration = z.Duration
+		}
+	}
+}
+
+// parseDuration parses durations given as seconds, MM:SS or HH:MM:SS.
+func parseDuration(value string) time.Duration {
+
+	value = strings.TrimSpace(value)
+	if isEmpty(value) {
+		return 0
+	}
+
+	var seconds float64
+	for _, part := range strings.Split(value, ":") {
+		n, err := strconv.ParseFloat(strings.TrimSpace(part), 64)
+		if err != nil || n < 0 {
+			return 0
+		}
+		seconds = seconds*60 + n
+	}
+
+	return time.Duration(seconds * float64(time.Second))
+
+}
@@ -0,0 +1,156 @@
+package feedparser
+
+import (
+	"io/ioutil"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestEnclosuresRSS(t *testing.T) {
+
+	t.Parallel()
+
+	rss := `
+	<?xml version="1.0" encoding="UTF-8"?>
+	<rss version="2.0"
+		xmlns:itunes="http://www.itunes.com/dtds/podcast-1.0.dtd"
+		xmlns:media="http://search.yahoo.com/mrss/">
+		<channel>
+			<title>Podcast</title>
+			<link>http://localhost/</link>
+			<description>podcast desc</description>
+			<item>
+				<title>Episode One</title>
+				<link>http://localhost/episodes/1</link>
+				<guid>http://localhost/episodes/1</guid>
+				<enclosure url="http://localhost/audio/1.mp3" type="audio/mpeg" length="12345678"/>
+				<media:content url="http://localhost/audio/1.mp3" fileSize="99" duration="10"/>
+				<media:group>
+					<media:content url="http://localhost/audio/1.ogg" type="audio/ogg" fileSize="2345" duration="1800"/>
+				</media:group>
+				<itunes:duration>1:02:03</itunes:duration>
+				<itunes:episode>1</itunes:episode>
+				<itunes:image href="http://localhost/images/1.jpg"/>
+			</item>
+		</channel>
+	</rss>
+	`
+
+	p := NewParser()
+	feed, err := p.Parse(ioutil.NopCloser(strings.NewReader(rss)))
+	if err != nil {
+		t.Fatalf("Cannot parse RSS feed: %s", err.Error())
+	}
+
+	if count := len(feed.Entries); count != 1 {
+		t.Fatalf("bad entry count, expected: %d, actual: %d", 1, count)
+	}
+	entry := feed.Entries[0]
+
+	if count := len(entry.Enclosures); count != 2 {
+		t.Fatalf("bad enclosure count, expected: %d, actual: %d", 2, count)
+	}
+
+	mp3 := entry.Enclosures[0]
+	if mp3.URL != "http://localhost/audio/1.mp3" {
+		t.Errorf("bad enclosure url: %s", mp3.URL)
+	}
+	if mp3.Type != "audio/mpeg" {
+		t.Errorf("bad enclosure type: %s", mp3.Type)
+	}
+	if mp3.Length != 12345678 {
+		t.Errorf("bad enclosure length, expected: %d, actual: %d", 12345678, mp3.Length)
+	}
+	if expected := 10 * time.Second; mp3.Duration != expected {
+		t.Errorf("bad enclosure duration, expected: %s, actual: %s", expected, mp3.Duration)
+	}
+
+	ogg := entry.Enclosures[1]
+	if ogg.URL != "http://localhost/audio/1.ogg" || ogg.Type != "audio/ogg" || ogg.Length != 2345 {
+		t.Errorf("bad media:group enclosure: %v", ogg)
+	}
+	if expected := 30 * time.Minute; ogg.Duration != expected {
+		t.Errorf("bad enclosure duration, expected: %s, actual: %s", expected, ogg.Duration)
+	}
+
+	if expected := time.Hour + 2*time.Minute + 3*time.Second; entry.Duration != expected {
+		t.Errorf("bad entry duration, expected: %s, actual: %s", expected, entry.Duration)
+	}
+	if entry.Episode != "1" {
+		t.Errorf("bad episode, expected: %s, actual: %s", "1", entry.Episode)
+	}
+	if entry.Image != "http://localhost/images/1.jpg" {
+		t.Errorf("bad image: %s", entry.Image)
+	}
+
+}
+
+func TestEnclosuresAtom(t *testing.T) {
+
+	t.Parallel()
+
+	atom := `
+	<?xml version='1.0' encoding='UTF-8'?>
+	<feed xmlns='http://www.w3.org/2005/Atom' xmlns:itunes='http://www.itunes.com/dtds/podcast-1.0.dtd'>
+		<title>Podcast</title>
+		<id>http://localhost/</id>
+		<entry xml:base='http://localhost/episodes/'>
+			<id>http://localhost/episodes/2</id>
+			<title>Episode Two</title>
+			<link href='http://localhost/episodes/2'/>
+			<link rel='enclosure' href='2.m4a' type='audio/mp4' length='4321'/>
+			<itunes:duration>3600</itunes:duration>
+		</entry>
+	</feed>
+	`
+
+	p := NewParser()
+	feed, err := p.Parse(ioutil.NopCloser(strings.NewReader(atom)))
+	if err != nil {
+		t.Fatalf("Cannot parse Atom feed: %s", err.Error())
+	}
+
+	if count := len(feed.Entries); count != 1 {
+		t.Fatalf("bad entry count, expected: %d, actual: %d", 1, count)
+	}
+	entry := feed.Entries[0]
+
+	if count := len(entry.Enclosures); count != 1 {
+		t.Fatalf("bad enclosure count, expected: %d, actual: %d", 1, count)
+	}
+
+	enclosure := entry.Enclosures[0]
+	if enclosure.URL != "http://localhost/episodes/2.m4a" {
+		t.Errorf("bad enclosure url: %s", enclosure.URL)
+	}
+	if enclosure.Type != "audio/mp4" || enclosure.Length != 4321 {
+		t.Errorf("bad enclosure: %v", enclosure)
+	}
+	if enclosure.Duration != time.Hour {
+		t.Errorf("bad enclosure duration, expected: %s, actual: %s", time.Hour, enclosure.Duration)
+	}
+
+}
+
+func TestParseDuration(t *testing.T) {
+
+	t.Parallel()
+
+	tests := map[string]time.Duration{
+		"":         0,
+		"90":       90 * time.Second,
+		"12.5":     12500 * time.Millisecond,
+		"04:05":    4*time.Minute + 5*time.Second,
+		"01:02:03": time.Hour + 2*time.Minute + 3*time.Second,
+		"abc":      0,
+		"-5":       0,
+	}
+
+	for value, expected := range tests {
+		if actual := parseDuration(value); actual != expected {
+			t.Errorf("bad duration for %q, expected: %s, actual: %s", value, expected, actual)
+		}
+	}
+
+}
@@ -1,12 +1,15 @@
 package feedparser
 
 import (
+	"bufio"
+	"bytes"
 	"encoding/xml"
 	"errors"
 	"io"
 	"io/ioutil"
 	"net/url"
 	"regexp"
+	"strconv"
 	"strings"
 	"time"
 
@@ -14,26 +17,40 @@ import (
 )
 
 const (
-	nsAtom       = "http://www.w3.org/2005/Atom"
-	nsContent    = "http://purl.org/rss/1.0/modules/content/"
-	nsDublinCore = "http://purl.org/dc/elements/1.1/"
-	nsNone       = ""
-	nsRSS        = ""
-	nsXML        = "http://www.w3.org/XML/1998/namespace"
+	nsAtom        = "http://www.w3.org/2005/Atom"
+	nsContent     = "http://purl.org/rss/1.0/modules/content/"
+	nsDublinCore  = "http://purl.org/dc/elements/1.1/"
+	nsITunes      = "http://www.itunes.com/dtds/podcast-1.0.dtd"
+	nsMedia       = "http://search.yahoo.com/mrss/"
+	nsNone        = ""
+	nsRDF         = "http://www.w3.org/1999/02/22-rdf-syntax-ns#"
+	nsRSS         = ""
+	nsRSS1        = "http://purl.org/rss/1.0/"
+	nsSyndication = "http://purl.org/rss/1.0/modules/syndication/"
+	nsXML         = "http://www.w3.org/XML/1998/namespace"
 )
 
 const (
 	flavorAtom = "atom"
+	flavorJSON = "json"
+	flavorRDF  = "rdf"
 	flavorRSS  = "rss"
 )
 
 const (
 	linkSelf      = "self"
 	linkAlternate = "alternate"
+	linkEnclosure = "enclosure"
+	linkHub       = "hub"
+	linkNext      = "next"
 )
 
 var (
-	rssPerson = regexp.MustCompile(`^(.+)\s+\((.+)\)$`)
+	// ErrTooDeep indicates that the elements of the feed are nested deeper than the maximum depth of the parser.
+	ErrTooDeep = errors.New("Feed exceeds the maximum element depth")
+	// ErrTooManyEntries indicates that the feed contains more entries than the maximum of the parser.
+	ErrTooManyEntries = errors.New("Feed exceeds the maximum number of entries")
+	rssPerson         = regexp.MustCompile(`^(.+)\s+\((.+)\)$`)
 )
 
 var (
@@ -56,28 +73,44 @@ func NewParserWithPostProcessors(postprocessors ...PostProcessor) *Parser {
 
 // Parser can parse feeds
 type Parser struct {
-	decoder *xml.Decoder
-	entry   *Entry
-	feed    *Feed
-	postp   []PostProcessor
-	stack   *elements
+	MaxDepth    int            // maximum nesting of elements, zero for no limit
+	MaxEntries  int            // maximum number of entries, zero for no limit
+	Location    *time.Location // of dates without a zone, UTC if nil
+	Diagnose    bool           // collect warnings about the feed in Feed.Warnings
+	decoder     *xml.Decoder
+	entry       *Entry
+	feed        *Feed
+	postp       []PostProcessor
+	stack       *elements
+	syPeriod    string // sy:updatePeriod
+	syFrequency string // sy:updateFrequency
 }
 
 // Feed feed
 type Feed struct {
 	Authors       []string
+	BadDates      []string // date values which could not be parsed
+	Categories    []string
 	Entries       []*Entry
 	Flavor        string
 	Generator     string
 	Icon          string
 	ID            string
+	Language      string
 	Links         map[string]string
 	LinkAlternate string
+	LinkHub       string
 	LinkSelf      string
+	Published     time.Time // updated, pubDate or lastBuildDate as declared by the feed
 	Rights        string
+	SkipDays      []time.Weekday // days on which the feed is not updated, in UTC
+	SkipHours     []int          // hours of the day during which the feed is not updated, in UTC
 	Subtitle      string
 	Title         string
-	Updated       time.Time
+	TTL           time.Duration // how long the feed may be cached
+	Updated       time.Time     // calculated from the entries
+	UpdatePeriod  time.Duration // expected time between updates, from the syndication module
+	Warnings      []string      // problems found by a parser in diagnostic mode
 }
 
 // Entry entry
@@ -87,7 +120,11 @@ type Entry struct {
 	Content       string
 	Contributors  []string
 	Created       time.Time
+	Duration      time.Duration
+	Enclosures    []*Enclosure
+	Episode       string
 	ID            string
+	Image         string
 	Links         map[string]string
 	LinkAlternate string
 	LinkSelf      string
@@ -96,8 +133,61 @@ type Entry struct {
 	Updated       time.Time
 }
 
-// Parse feed
+// Parse feed, JSON feeds are recognized by their leading brace.
 func (z *Parser) Parse(reader io.Reader) (*Feed, error) {
+	return z.ParseContentType(reader, "")
+}
+
+// ParseContentType parses a feed, treating it as a JSON feed if the content type is a JSON media type or the content starts with a brace.
+func (z *Parser) ParseContentType(reader io.Reader, contentType string) (*Feed, error) {
+
+	// keep a copy of the document for the diagnostics
+	var raw bytes.Buffer
+	source := reader
+	if z.Diagnose {
+		source = io.TeeReader(reader, &raw)
+	}
+
+	buffered := bufio.NewReader(source)
+
+	var exitError error
+	isJSON := isJSONContentType(contentType) || startsWithBrace(buffered)
+	if isJSON {
+		exitError = z.parseJSON(buffered)
+	} else {
+		exitError = z.parseXML(buffered)
+	}
+
+	// finish reading stream
+	if exitError != nil {
+		ioutil.ReadAll(buffered)
+	}
+
+	// close stream
+	if closer, ok := reader.(io.Closer); ok {
+		closer.Close()
+	}
+
+	if exitError == nil && z.feed == nil {
+		exitError = errors.New("Cannot parse feed")
+	}
+
+	if exitError == nil && z.feed != nil && z.Diagnose {
+		z.diagnose(raw.Bytes(), contentType, isJSON)
+	}
+
+	// run postprocessors
+	if exitError == nil && z.feed != nil {
+		for _, p := range z.postp {
+			p(z.feed)
+		}
+	}
+
+	return z.feed, exitError
+
+}
+
+func (z *Parser) parseXML(reader io.Reader) error {
 
 	z.decoder = xml.NewDecoder(reader)
 	z.decoder.CharsetReader = charset.NewReaderLabel
@@ -106,6 +196,8 @@ func (z *Parser) Parse(reader io.Reader) (*Feed, error) {
 	z.stack = &elements{}
 	z.feed = nil
 	z.entry = nil
+	z.syPeriod = ""
+	z.syFrequency = ""
 
 	var exitError error
 
@@ -125,6 +217,10 @@ Loop:
 
 		case xml.StartElement:
 			e := z.stack.Push(t)
+			if z.MaxDepth > 0 && z.stack.Level() > z.MaxDepth {
+				exitError = ErrTooDeep
+				break Loop
+			}
 
 			switch {
 			case z.feed == nil:
@@ -139,7 +235,13 @@ Loop:
 					z.doStartFeedAtom(e, &t)
 				case flavorRSS:
 					z.doStartFeedRSS(e, &t)
+				case flavorRDF:
+					z.doStartFeedRDF(e, &t)
 				} // flavor
+				if z.entry != nil && z.MaxEntries > 0 && len(z.feed.Entries) >= z.MaxEntries {
+					exitError = ErrTooManyEntries
+					break Loop
+				}
 
 			case z.entry != nil && z.stack.IsStackEntry(z.feed.Flavor, 1):
 				switch z.feed.Flavor {
@@ -147,6 +249,8 @@ Loop:
 					z.doStartEntryAtom(e, &t)
 				case flavorRSS:
 					z.doStartEntryRSS(e, &t)
+				case flavorRDF:
+					z.doStartEntryRDF(e, &t)
 				} // flavor
 
 			} // level
@@ -166,6 +270,8 @@ Loop:
 					z.doEndFeedAtom(e)
 				case flavorRSS:
 					z.doEndFeedRSS(e)
+				case flavorRDF:
+					z.doEndFeedRDF(e)
 				}
 
 			case z.entry != nil && z.stack.IsStackEntry(z.feed.Flavor, 0):
@@ -174,6 +280,8 @@ Loop:
 					z.doEndEntryAtom(e)
 				case flavorRSS:
 					z.doEndEntryRSS(e)
+				case flavorRDF:
+					z.doEndEntryRDF(e)
 				}
 
 			}
@@ -183,33 +291,16 @@ Loop:
 
 	} // loop
 
-	// finish reading stream
-	if exitError != nil {
-		ioutil.ReadAll(reader)
-	}
-
-	// close stream
-	if closer, ok := reader.(io.Closer); ok {
-		closer.Close()
-	}
-
-	if exitError == nil && z.feed == nil {
-		exitError = errors.New("Cannot parse feed")
-	}
-
-	// run postprocessors
 	if exitError == nil && z.feed != nil {
-		for _, p := range z.postp {
-			p(z.feed)
-		}
+		z.feed.UpdatePeriod = makeUpdatePeriod(z.syPeriod, z.syFrequency)
 	}
 
-	return z.feed, exitError
+	return exitError
 
 }
 
 func (z *Parser) doStartFeedNil(e *element, start *xml.StartElement) error {
-	if e.Match(nsAtom, "feed") || e.Match(nsRSS, "rss") {
+	if e.Match(nsAtom, "feed") || e.Match(nsRSS, "rss") || e.Match(nsRDF, "RDF") {
 		z.feed = &Feed{}
 		z.feed.Links = make(map[string]string)
 		switch {
@@ -217,7 +308,10 @@ func (z *Parser) doStartFeedNil(e *element, start *xml.StartElement) error {
 			z.feed.Flavor = flavorAtom
 		case e.Match(nsRSS, "rss"):
 			z.feed.Flavor = flavorRSS
+		case e.Match(nsRDF, "RDF"):
+			z.feed.Flavor = flavorRDF
 		} // switch
+		z.feed.Language = strings.TrimSpace(e.Attr(nsXML, "lang"))
 	} else {
 		return errors.New("Cannot parse " + e.name.Space + " : " + e.name.Local)
 	}
@@ -230,6 +324,10 @@ func (z *Parser) doStartFeedAtom(e *element, start *xml.StartElement) {
 		if value := z.makePersonAtom(e, start); !isEmpty(value) {
 			z.feed.Authors = append(z.feed.Authors, value)
 		}
+	case e.Match(nsAtom, "category"):
+		if value := makeCategory(e, start); !isEmpty(value) {
+			z.feed.Categories = append(z.feed.Categories, value)
+		}
 	case e.Match(nsAtom, "entry"):
 		z.entry = &Entry{}
 		z.entry.Links = make(map[string]string)
@@ -251,9 +349,11 @@ func (z *Parser) doStartFeedAtom(e *element, start *xml.StartElement) {
 		z.feed.Subtitle = z.makeContent(e, start)
 	case e.Match(nsAtom, "title"):
 		z.feed.Title = z.makeContent(e, start)
-		// ignore feed updated, and calculate from entries (see doEndEntryAtom)
-		// case e.Match(nsAtom, "updated"):
-		// 	z.feed.Updated = z.parseTime(z.makeText(e, start))
+	case e.Match(nsAtom, "updated"):
+		// feed updated is calculated from entries (see doEndEntryAtom)
+		z.feed.Published = z.parseTime(z.makeText(e, start))
+	default:
+		z.doStartFeedSyndication(e, start)
 	} // z.stack
 }
 
@@ -263,6 +363,10 @@ func (z *Parser) doStartFeedRSS(e *element, start *xml.StartElement) {
 		key := e.Attr(nsNone, "rel")
 		value := makeURL(z.stack.Attr(nsXML, "base"), e.Attr(nsNone, "href"))
 		z.feed.Links[key] = value
+	case e.Match(nsRSS, "category"):
+		if value := z.makeText(e, start); !isEmpty(value) {
+			z.feed.Categories = append(z.feed.Categories, value)
+		}
 	case e.Match(nsRSS, "copyright"):
 		z.feed.Rights = z.makeText(e, start)
 	case e.Match(nsRSS, "description"):
@@ -273,9 +377,21 @@ func (z *Parser) doStartFeedRSS(e *element, start *xml.StartElement) {
 		if image := z.makeRSSImage(e, start); image != nil {
 			z.feed.Icon = image.URL
 		}
-		// ignore feed updated, and calculate from entries (see doEndEntryRSS)
-	// case e.Match(nsRSS, "pubdate"):
-	// 	z.feed.Updated = z.parseTime(z.makeText(e, start))
+	case e.Match(nsRSS, "language"):
+		z.feed.Language = z.makeText(e, start)
+	case e.Match(nsRSS, "lastbuilddate"), e.Match(nsRSS, "pubdate"):
+		// feed updated is calculated from entries (see doEndEntryRSS)
+		if t := z.parseTime(z.makeText(e, start)); t.After(z.feed.Published) {
+			z.feed.Published = t
+		}
+	case e.Match(nsRSS, "skipdays"):
+		z.feed.SkipDays = z.makeSkipDays(e, start)
+	case e.Match(nsRSS, "skiphours"):
+		z.feed.SkipHours = z.makeSkipHours(e, start)
+	case e.Match(nsRSS, "ttl"):
+		if minutes, err := strconv.Atoi(z.makeText(e, start)); err == nil && minutes > 0 {
+			z.feed.TTL = time.Duration(minutes) * time.Minute
+		}
 	case e.Match(nsRSS, "title"):
 		z.feed.Title = z.makeText(e, start)
 	case e.Match(nsRSS, "item"):
@@ -283,6 +399,65 @@ func (z *Parser) doStartFeedRSS(e *element, start *xml.StartElement) {
 		z.entry.Links = make(map[string]string)
 	case e.Match(nsRSS, "link"):
 		z.feed.Links[linkAlternate] = makeURL(z.stack.Attr(nsXML, "base"), z.makeText(e, start))
+	default:
+		z.doStartFeedSyndication(e, start)
+	}
+}
+
+// doStartFeedSyndication handles the Dublin Core and syndication module elements common to all flavors.
+func (z *Parser) doStartFeedSyndication(e *element, start *xml.StartElement) {
+	switch {
+	case e.Match(nsDublinCore, "date"):
+		if t := z.parseTime(z.makeText(e, start)); t.After(z.feed.Published) {
+			z.feed.Published = t
+		}
+	case e.Match(nsDublinCore, "language"):
+		if isEmpty(z.feed.Language) {
+			z.feed.Language = z.makeText(e, start)
+		}
+	case e.Match(nsDublinCore, "subject"):
+		if value := z.makeText(e, start); !isEmpty(value) {
+			z.feed.Categories = append(z.feed.Categories, value)
+		}
+	case e.Match(nsSyndication, "updatePeriod"):
+		z.syPeriod = z.makeText(e, start)
+	case e.Match(nsSyndication, "updateFrequency"):
+		z.syFrequency = z.makeText(e, start)
+	}
+}
+
+// doStartFeedRDF handles the children of both the rdf:RDF root element and the channel.
+func (z *Parser) doStartFeedRDF(e *element, start *xml.StartElement) {
+	switch {
+	case e.Match(nsAtom, "link"):
+		key := e.Attr(nsNone, "rel")
+		value := makeURL(z.stack.Attr(nsXML, "base"), e.Attr(nsNone, "href"))
+		z.feed.Links[key] = value
+	case e.Match(nsDublinCore, "creator"):
+		if creator := z.makeText(e, start); !isEmpty(creator) {
+			z.feed.Authors = append(z.feed.Authors, creator)
+		}
+	case e.Match(nsDublinCore, "rights"):
+		z.feed.Rights = z.makeText(e, start)
+	case e.Match(nsRSS1, "channel"):
+		z.feed.ID = e.Attr(nsRDF, "about")
+	case e.Match(nsRSS1, "description"):
+		z.feed.Subtitle = z.makeText(e, start)
+	case e.Match(nsRSS1, "image"):
+		// the channel references the image by rdf:resource, the image itself is a sibling of the channel
+		if image := z.makeRSSImage(e, start); image != nil && !isEmpty(image.URL) {
+			z.feed.Icon = makeURL(z.stack.Attr(nsXML, "base"), image.URL)
+		}
+	case e.Match(nsRSS1, "item"):
+		z.entry = &Entry{}
+		z.entry.Links = make(map[string]string)
+		z.entry.ID = e.Attr(nsRDF, "about")
+	case e.Match(nsRSS1, "link"):
+		z.feed.Links[linkAlternate] = makeURL(z.stack.Attr(nsXML, "base"), z.makeText(e, start))
+	case e.Match(nsRSS1, "title"):
+		z.feed.Title = z.makeText(e, start)
+	default:
+		z.doStartFeedSyndication(e, start)
 	}
 }
 
@@ -308,16 +483,21 @@ func (z *Parser) doStartEntryAtom(e *element, start *xml.StartElement) {
 		key := e.Attr(nsNone, "rel")
 		value := makeURL(z.stack.Attr(nsXML, "base"), e.Attr(nsNone, "href"))
 		z.entry.Links[key] = value
+		if key == linkEnclosure {
+			z.entry.addEnclosure(value, e.Attr(nsNone, "type"), e.Attr(nsNone, "length"), "")
+		}
 	case e.Match(nsAtom, "published"):
-		z.entry.Created = parseTime(z.makeText(e, start))
+		z.entry.Created = z.parseTime(z.makeText(e, start))
 	case e.Match(nsAtom, "summary"):
 		z.entry.Summary = z.makeContent(e, start)
 	case e.Match(nsAtom, "title"):
 		z.entry.Title = z.makeContent(e, start)
 	case e.Match(nsAtom, "updated"):
 		if text := z.makeText(e, start); !isEmpty(text) {
-			z.entry.Updated = parseTime(text)
+			z.entry.Updated = z.parseTime(text)
 		}
+	default:
+		z.doStartEntryPodcast(e, start)
 	}
 }
 
@@ -327,6 +507,9 @@ func (z *Parser) doStartEntryRSS(e *element, start *xml.StartElement) {
 		key := e.Attr(nsNone, "rel")
 		value := makeURL(z.stack.Attr(nsXML, "base"), e.Attr(nsNone, "href"))
 		z.entry.Links[key] = value
+		if key == linkEnclosure {
+			z.entry.addEnclosure(value, e.Attr(nsNone, "type"), e.Attr(nsNone, "length"), "")
+		}
 	case e.Match(nsContent, "encoded"):
 		z.entry.Content = z.makeText(e, start)
 	case e.Match(nsDublinCore, "creator"):
@@ -336,7 +519,7 @@ func (z *Parser) doStartEntryRSS(e *element, start *xml.StartElement) {
 	case e.Match(nsDublinCore, "date"):
 		if z.entry.Updated.IsZero() {
 			if text := z.makeText(e, start); !isEmpty(text) {
-				z.entry.Updated = parseTime(text)
+				z.entry.Updated = z.parseTime(text)
 			}
 		}
 	case e.Match(nsRSS, "author"):
@@ -349,16 +532,72 @@ func (z *Parser) doStartEntryRSS(e *element, start *xml.StartElement) {
 		}
 	case e.Match(nsRSS, "description"):
 		z.entry.Summary = z.makeText(e, start)
+	case e.Match(nsRSS, "enclosure"):
+		value := makeURL(z.stack.Attr(nsXML, "base"), e.Attr(nsNone, "url"))
+		z.entry.addEnclosure(value, e.Attr(nsNone, "type"), e.Attr(nsNone, "length"), "")
 	case e.Match(nsRSS, "guid"):
 		z.entry.ID = z.makeText(e, start)
 	case e.Match(nsRSS, "link"):
 		z.entry.Links[linkAlternate] = makeURL(z.stack.Attr(nsXML, "base"), z.makeText(e, start))
 	case e.Match(nsRSS, "pubdate"):
 		if text := z.makeText(e, start); !isEmpty(text) {
-			z.entry.Updated = parseTime(text)
+			z.entry.Updated = z.parseTime(text)
 		}
 	case e.Match(nsRSS, "title"):
 		z.entry.Title = z.makeText(e, start)
+	default:
+		z.doStartEntryPodcast(e, start)
+	}
+}
+
+func (z *Parser) doStartEntryRDF(e *element, start *xml.StartElement) {
+	switch {
+	case e.Match(nsContent, "encoded"):
+		z.entry.Content = z.makeText(e, start)
+	case e.Match(nsDublinCore, "creator"):
+		if creator := z.makeText(e, start); !isEmpty(creator) {
+			z.entry.Authors = append(z.entry.Authors, creator)
+		}
+	case e.Match(nsDublinCore, "contributor"):
+		if contributor := z.makeText(e, start); !isEmpty(contributor) {
+			z.entry.Contributors = append(z.entry.Contributors, contributor)
+		}
+	case e.Match(nsDublinCore, "date"):
+		if text := z.makeText(e, start); !isEmpty(text) {
+			z.entry.Updated = z.parseTime(text)
+		}
+	case e.Match(nsDublinCore, "subject"):
+		if value := z.makeText(e, start); !isEmpty(value) {
+			z.entry.Categories = append(z.entry.Categories, value)
+		}
+	case e.Match(nsRSS1, "description"):
+		z.entry.Summary = z.makeText(e, start)
+	case e.Match(nsRSS1, "link"):
+		z.entry.Links[linkAlternate] = makeURL(z.stack.Attr(nsXML, "base"), z.makeText(e, start))
+	case e.Match(nsRSS1, "title"):
+		z.entry.Title = z.makeText(e, start)
+	default:
+		z.doStartEntryPodcast(e, start)
+	}
+}
+
+// doStartEntryPodcast handles the media RSS and iTunes extensions common to both flavors.
+func (z *Parser) doStartEntryPodcast(e *element, start *xml.StartElement) {
+	switch {
+	case e.Match(nsMedia, "content"):
+		value := makeURL(z.stack.Attr(nsXML, "base"), e.Attr(nsNone, "url"))
+		z.entry.addEnclosure(value, e.Attr(nsNone, "type"), e.Attr(nsNone, "fileSize"), e.Attr(nsNone, "duration"))
+	case e.Match(nsMedia, "group"):
+		base := z.stack.Attr(nsXML, "base")
+		for _, mc := range z.makeMediaGroup(e, start).Contents {
+			z.entry.addEnclosure(makeURL(base, mc.URL), mc.Type, mc.FileSize, mc.Duration)
+		}
+	case e.Match(nsITunes, "duration"):
+		z.entry.Duration = parseDuration(z.makeText(e, start))
+	case e.Match(nsITunes, "episode"):
+		z.entry.Episode = z.makeText(e, start)
+	case e.Match(nsITunes, "image"):
+		z.entry.Image = makeURL(z.stack.Attr(nsXML, "base"), e.Attr(nsNone, "href"))
 	}
 }
 
@@ -366,6 +605,7 @@ func (z *Parser) doEndFeedAtom(e *element) {
 	switch {
 	case e.Match(nsAtom, "feed"):
 		// finished: clean up atom feed here
+		z.feed.LinkHub = z.feed.Links[linkHub]
 		z.feed.LinkSelf = z.feed.Links[linkSelf]
 		z.feed.LinkAlternate = z.feed.Links[linkAlternate]
 		if isEmpty(z.feed.LinkAlternate) {
@@ -385,6 +625,26 @@ func (z *Parser) doEndFeedRSS(e *element) {
 		}
 		// finished: clean up rss feed here
 		z.feed.Flavor = flavorRSS + z.stack.Attr(nsRSS, "version")
+		z.feed.LinkHub = z.feed.Links[linkHub]
+		z.feed.LinkSelf = z.feed.Links[linkSelf]
+		z.feed.LinkAlternate = z.feed.Links[linkAlternate]
+		if isEmpty(z.feed.LinkAlternate) {
+			z.feed.LinkAlternate = z.feed.Links[""]
+		}
+	}
+}
+
+func (z *Parser) doEndFeedRDF(e *element) {
+	switch {
+	case e.Match(nsRDF, "RDF"):
+		// finished: clean up rdf feed here, after the items following the channel
+		if isEmpty(z.feed.ID) {
+			z.feed.ID = z.feed.Links[linkSelf]
+		}
+		if isEmpty(z.feed.ID) {
+			z.feed.ID = z.feed.Links[linkAlternate]
+		}
+		z.feed.LinkHub = z.feed.Links[linkHub]
 		z.feed.LinkSelf = z.feed.Links[linkSelf]
 		z.feed.LinkAlternate = z.feed.Links[linkAlternate]
 		if isEmpty(z.feed.LinkAlternate) {
@@ -408,6 +668,7 @@ func (z *Parser) doEndEntryAtom(e *element) {
 		if isEmpty(z.entry.LinkAlternate) {
 			z.entry.LinkAlternate = z.entry.Links[""]
 		}
+		z.entry.finishEnclosures()
 
 		z.feed.Entries = append(z.feed.Entries, z.entry)
 		z.entry = nil
@@ -434,6 +695,33 @@ func (z *Parser) doEndEntryRSS(e *element) {
 		if isEmpty(z.entry.LinkAlternate) {
 			z.entry.LinkAlternate = z.entry.Links[""]
 		}
+		z.entry.finishEnclosures()
+
+		z.feed.Entries = append(z.feed.Entries, z.entry)
+		z.entry = nil
+	}
+}
+
+func (z *Parser) doEndEntryRDF(e *element) {
+	switch {
+	case e.Match(nsRSS1, "item"):
+		if !isEmpty(z.entry.Summary) && isEmpty(z.entry.Content) {
+			z.entry.Content = z.entry.Summary
+			z.entry.Summary = ""
+		}
+		if z.entry.Created.IsZero() {
+			z.entry.Created = z.entry.Updated
+		}
+		if z.feed.Updated.Before(z.entry.Updated) {
+			z.feed.Updated = z.entry.Updated
+		}
+
+		z.entry.LinkSelf = z.entry.Links[linkSelf]
+		z.entry.LinkAlternate = z.entry.Links[linkAlternate]
+		if isEmpty(z.entry.ID) {
+			z.entry.ID = z.entry.LinkAlternate
+		}
+		z.entry.finishEnclosures()
 
 		z.feed.Entries = append(z.feed.Entries, z.entry)
 		z.entry = nil
@@ -463,6 +751,13 @@ func (z *Parser) makeGenerator(e *element, start *xml.StartElement) string {
 	return result.ToString()
 }
 
+func (z *Parser) makeMediaGroup(e *element, start *xml.StartElement) *mediaGroup {
+	x := &mediaGroup{}
+	z.decoder.DecodeElement(x, start)
+	z.stack.Pop()
+	return x
+}
+
 func (z *Parser) makePersonAtom(e *element, start *xml.StartElement) string {
 	result := &person{}
 	z.decoder.DecodeElement(result, start)
@@ -495,6 +790,35 @@ func (z *Parser) makeRSSImage(e *element, start *xml.StartElement) *rssImage {
 	return nil
 }
 
+func (z *Parser) makeSkipDays(e *element, start *xml.StartElement) []time.Weekday {
+	x := &skipDays{}
+	z.decoder.DecodeElement(x, start)
+	z.stack.Pop()
+	var result []time.Weekday
+	for _, day := range x.Days {
+		for weekday := time.Sunday; weekday <= time.Saturday; weekday++ {
+			if strings.EqualFold(strings.TrimSpace(day), weekday.String()) {
+				result = append(result, weekday)
+			}
+		}
+	}
+	return result
+}
+
+func (z *Parser) makeSkipHours(e *element, start *xml.StartElement) []int {
+	x := &skipHours{}
+	z.decoder.DecodeElement(x, start)
+	z.stack.Pop()
+	var result []int
+	for _, hour := range x.Hours {
+		// some feeds use 24 for midnight
+		if n, err := strconv.Atoi(strings.TrimSpace(hour)); err == nil && n >= 0 && n <= 24 {
+			result = append(result, n%24)
+		}
+	}
+	return result
+}
+
 func (z *Parser) makeText(e *element, start *xml.StartElement) string {
 	x := &text{}
 	z.decoder.DecodeElement(x, start)
@@ -515,34 +839,36 @@ func makeURL(base string, urlstr string) string {
 	return urlstr
 }
 
-// taken from https://github.com/jteeuwen/go-pkg-rss/ timedecoder.go
-func parseTime(formatted string) (t time.Time) {
-	var layouts = [...]string{
-		"Mon, _2 Jan 2006 15:04:05 MST",
-		"Mon, _2 Jan 2006 15:04:05 -0700",
-		time.ANSIC,
-		time.UnixDate,
-		time.RubyDate,
-		time.RFC822,
-		time.RFC822Z,
-		time.RFC850,
-		time.RFC1123,
-		time.RFC1123Z,
-		time.RFC3339,
-		time.RFC3339Nano,
-		"Mon, 2, Jan 2006 15:4",
-		"02 Jan 2006 15:04:05 MST",
-		"_2 Jan 2006 15:04:05 +0000", // found in the wild, differs slightly from RFC822Z
-		"2006-01-02 15:04:05",        // found in the wild, apparently RFC3339 was too difficult
-		"_2 Jan 2006",
-		"2006-01-02",
+// makeUpdatePeriod calculates the expected time between updates from the syndication module elements,
+// which default to once daily if only one of them is given.
+func makeUpdatePeriod(period, frequency string) time.Duration {
+
+	period = strings.ToLower(strings.TrimSpace(period))
+	frequency = strings.TrimSpace(frequency)
+	if isEmpty(period) && isEmpty(frequency) {
+		return 0
 	}
-	formatted = strings.TrimSpace(formatted)
-	for _, layout := range layouts {
-		t, _ = time.Parse(layout, formatted)
-		if !t.IsZero() {
-			break
-		}
+
+	var d time.Duration
+	switch period {
+	case "hourly":
+		d = time.Hour
+	case "", "daily":
+		d = 24 * time.Hour
+	case "weekly":
+		d = 7 * 24 * time.Hour
+	case "monthly":
+		d = 30 * 24 * time.Hour
+	case "yearly":
+		d = 365 * 24 * time.Hour
+	default:
+		return 0
+	}
+
+	if n, err := strconv.Atoi(frequency); err == nil && n > 0 {
+		d = d / time.Duration(n)
 	}
-	return
+
+	return d
+
 }
@@ -105,6 +105,10 @@ func TestLinkWithoutRel(t *testing.T) {
 		t.Errorf("Links without rel not being intrepreted as alternate link: expected: %s, actual: %s", "https://www.tbray.org/ongoing/ongoing.atom", htmlLink)
 	}
 
+	if hubLink := feed.LinkHub; hubLink != "http://pubsubhubbub.appspot.com/" {
+		t.Errorf("Bad hub link: expected: %s, actual: %s", "http://pubsubhubbub.appspot.com/", hubLink)
+	}
+
 }
 
 func TestRSSPerson(t *testing.T) {
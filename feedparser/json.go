@@ -0,0 +1,250 @@
+package feedparser
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"html"
+	"mime"
+	"strings"
+)
+
+const (
+	jsonFeedVersion = "https://jsonfeed.org/version/"
+	jsonPeekSize    = 512
+)
+
+var (
+	// ErrJSONFeedVersion indicates a JSON document which is not a JSON Feed.
+	ErrJSONFeedVersion = errors.New("Cannot parse JSON feed: unknown version")
+	jsonFeedTypes      = map[string]bool{
+		"application/feed+json": true,
+		"application/json":      true,
+	}
+	utf8BOM = []byte{0xef, 0xbb, 0xbf}
+)
+
+// jsonFeed is a JSON Feed document, see https://jsonfeed.org/version/1.1
+type jsonFeed struct {
+	Version     string        `json:"version"`
+	Title       string        `json:"title"`
+	HomePageURL string        `json:"home_page_url"`
+	FeedURL     string        `json:"feed_url"`
+	NextURL     string        `json:"next_url"` // page with older items
+	Description string        `json:"description"`
+	Icon        string        `json:"icon"`
+	Favicon     string        `json:"favicon"`
+	Language    string        `json:"language"` // version 1.1
+	Author      *jsonAuthor   `json:"author"`   // version 1.0
+	Authors     []*jsonAuthor `json:"authors"`
+	Hubs        []*jsonHub    `json:"hubs"`
+	Items       []*jsonItem   `json:"items"`
+}
+
+type jsonAuthor struct {
+	Name string `json:"name"`
+	URL  string `json:"url"`
+}
+
+type jsonHub struct {
+	Type string `json:"type"`
+	URL  string `json:"url"`
+}
+
+type jsonItem struct {
+	ID            jsonID            `json:"id"`
+	URL           string            `json:"url"`
+	ExternalURL   string            `json:"external_url"`
+	Title         string            `json:"title"`
+	ContentHTML   string            `json:"content_html"`
+	ContentText   string            `json:"content_text"`
+	Summary       string            `json:"summary"`
+	Image         string            `json:"image"`
+	DatePublished string            `json:"date_published"`
+	DateModified  string            `json:"date_modified"`
+	Author        *jsonAuthor       `json:"author"` // version 1.0
+	Authors       []*jsonAuthor     `json:"authors"`
+	Tags          []string          `json:"tags"`
+	Attachments   []*jsonAttachment `json:"attachments"`
+}
+
+type jsonAttachment struct {
+	URL               string      `json:"url"`
+	MimeType          string      `json:"mime_type"`
+	SizeInBytes       json.Number `json:"size_in_bytes"`
+	DurationInSeconds json.Number `json:"duration_in_seconds"`
+}
+
+// jsonID is an item ID, which must be a string but is frequently published as a number.
+type jsonID string
+
+func (z *jsonID) UnmarshalJSON(data []byte) error {
+	var value interface{}
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	if err := decoder.Decode(&value); err != nil {
+		return err
+	}
+	switch v := value.(type) {
+	case string:
+		*z = jsonID(v)
+	case json.Number:
+		*z = jsonID(v.String())
+	}
+	return nil
+}
+
+func (z *Parser) parseJSON(reader *bufio.Reader) error {
+
+	z.feed = nil
+	z.entry = nil
+
+	if bom, _ := reader.Peek(len(utf8BOM)); bytes.Equal(bom, utf8BOM) {
+		reader.Discard(len(utf8BOM))
+	}
+
+	f := &jsonFeed{}
+	if err := json.NewDecoder(reader).Decode(f); err != nil {
+		return err
+	}
+
+	if !strings.HasPrefix(f.Version, jsonFeedVersion) {
+		return ErrJSONFeedVersion
+	}
+
+	if z.MaxEntries > 0 && len(f.Items) > z.MaxEntries {
+		return ErrTooManyEntries
+	}
+
+	feed := &Feed{
+		Flavor:   flavorJSON + strings.TrimPrefix(f.Version, jsonFeedVersion),
+		Title:    strings.TrimSpace(f.Title),
+		Subtitle: strings.TrimSpace(f.Description),
+		Authors:  makeAuthorsJSON(f.Author, f.Authors),
+		Language: strings.TrimSpace(f.Language),
+		Links:    make(map[string]string),
+	}
+
+	feed.Icon = strings.TrimSpace(f.Icon)
+	if isEmpty(feed.Icon) {
+		feed.Icon = strings.TrimSpace(f.Favicon)
+	}
+
+	if value := strings.TrimSpace(f.HomePageURL); !isEmpty(value) {
+		feed.Links[linkAlternate] = value
+	}
+	if value := strings.TrimSpace(f.FeedURL); !isEmpty(value) {
+		feed.Links[linkSelf] = value
+	}
+	if value := strings.TrimSpace(f.NextURL); !isEmpty(value) {
+		feed.Links[linkNext] = value
+	}
+	for _, hub := range f.Hubs {
+		if hub != nil && strings.EqualFold(hub.Type, "websub") && !isEmpty(hub.URL) {
+			feed.Links[linkHub] = strings.TrimSpace(hub.URL)
+			break
+		}
+	}
+	feed.LinkHub = feed.Links[linkHub]
+	feed.LinkSelf = feed.Links[linkSelf]
+	feed.LinkAlternate = feed.Links[linkAlternate]
+
+	feed.ID = feed.LinkSelf
+	if isEmpty(feed.ID) {
+		feed.ID = feed.LinkAlternate
+	}
+
+	z.feed = feed
+	for _, item := range f.Items {
+		if item != nil {
+			feed.Entries = append(feed.Entries, z.makeEntryJSON(feed, item))
+		}
+	}
+
+	return nil
+
+}
+
+func (z *Parser) makeEntryJSON(feed *Feed, item *jsonItem) *Entry {
+
+	entry := &Entry{
+		ID:      strings.TrimSpace(string(item.ID)),
+		Title:   strings.TrimSpace(item.Title),
+		Summary: strings.TrimSpace(item.Summary),
+		Image:   strings.TrimSpace(item.Image),
+		Authors: makeAuthorsJSON(item.Author, item.Authors),
+		Created: z.parseTime(item.DatePublished),
+		Updated: z.parseTime(item.DateModified),
+		Links:   make(map[string]string),
+	}
+
+	entry.Content = strings.TrimSpace(item.ContentHTML)
+	if text := strings.TrimSpace(item.ContentText); isEmpty(entry.Content) && !isEmpty(text) {
+		entry.Content = strings.Replace(html.EscapeString(text), "\n", "<br/>", -1)
+	}
+
+	for _, tag := range item.Tags {
+		if tag = strings.TrimSpace(tag); !isEmpty(tag) {
+			entry.Categories = append(entry.Categories, tag)
+		}
+	}
+
+	if value := strings.TrimSpace(item.URL); !isEmpty(value) {
+		entry.Links[linkAlternate] = value
+	}
+	if value := strings.TrimSpace(item.ExternalURL); !isEmpty(value) {
+		entry.Links["related"] = value
+	}
+	entry.LinkAlternate = entry.Links[linkAlternate]
+	if isEmpty(entry.ID) {
+		entry.ID = entry.LinkAlternate
+	}
+
+	for _, attachment := range item.Attachments {
+		if attachment != nil {
+			entry.addEnclosure(attachment.URL, attachment.MimeType, attachment.SizeInBytes.String(), attachment.DurationInSeconds.String())
+		}
+	}
+
+	if entry.Updated.IsZero() {
+		entry.Updated = entry.Created
+	}
+	if entry.Created.IsZero() {
+		entry.Created = entry.Updated
+	}
+	if feed.Updated.Before(entry.Updated) {
+		feed.Updated = entry.Updated
+	}
+
+	return entry
+
+}
+
+func makeAuthorsJSON(author *jsonAuthor, authors []*jsonAuthor) []string {
+	if len(authors) == 0 && author != nil {
+		authors = []*jsonAuthor{author}
+	}
+	var result []string
+	for _, a := range authors {
+		if a == nil {
+			continue
+		}
+		if value := (&person{Name: a.Name, URI: a.URL}).ToString(); !isEmpty(value) {
+			result = append(result, value)
+		}
+	}
+	return result
+}
+
+func isJSONContentType(contentType string) bool {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	return err == nil && jsonFeedTypes[mediaType]
+}
+
+// startsWithBrace reports if the first character of the content, after a byte order mark and whitespace, is an opening brace.
+func startsWithBrace(reader *bufio.Reader) bool {
+	data, _ := reader.Peek(jsonPeekSize)
+	data = bytes.TrimLeft(bytes.TrimPrefix(data, utf8BOM), " \t\r\n")
+	return len(data) > 0 && data[0] == '{'
+}
@@ -0,0 +1,100 @@
+package feedparser
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestJSONFeed(t *testing.T) {
+
+	t.Parallel()
+
+	f := testFile(t, "testdata/jsonfeed.json")
+
+	assertEqual(t, "json1.1", f.Flavor)
+	assertEqual(t, "https://example.org/feed.json", f.ID)
+	assertEqual(t, "My Example Feed", f.Title)
+	assertEqual(t, "A JSON Feed", f.Subtitle)
+	assertEqual(t, "https://example.org/icon.png", f.Icon)
+	assertEqual(t, "https://example.org/", f.LinkAlternate)
+	assertEqual(t, "https://example.org/feed.json", f.LinkSelf)
+	assertEqual(t, "https://hub.example.org/", f.LinkHub)
+	assertEqual(t, "https://example.org/feed.json?page=2", f.Links["next"])
+	assertEqual(t, 1, len(f.Authors))
+	assertEqual(t, "Jane Doe (https://example.org/jane)", f.Authors[0])
+	assertEqual(t, true, time.Date(2020, time.February, 3, 10, 0, 0, 0, time.UTC).Equal(f.Updated))
+	assertEqual(t, 2, len(f.Entries))
+
+	e := f.Entries[0]
+	assertEqual(t, "2", e.ID)
+	assertEqual(t, "Second", e.Title)
+	assertEqual(t, "A greeting", e.Summary)
+	assertEqual(t, `<p>Hello, <a href="https://example.org/world">world</a>!</p>`, e.Content)
+	assertEqual(t, "https://example.org/second-item", e.LinkAlternate)
+	assertEqual(t, true, time.Date(2020, time.February, 2, 10, 0, 0, 0, time.UTC).Equal(e.Created))
+	assertEqual(t, true, time.Date(2020, time.February, 3, 10, 0, 0, 0, time.UTC).Equal(e.Updated))
+	assertEqual(t, 1, len(e.Categories))
+	assertEqual(t, "greeting", e.Categories[0])
+	assertEqual(t, 0, len(e.Authors))
+	assertEqual(t, 1, len(e.Enclosures))
+	assertEqual(t, "https://example.org/second.mp3", e.Enclosures[0].URL)
+	assertEqual(t, "audio/mpeg", e.Enclosures[0].Type)
+	assertEqual(t, int64(1234), e.Enclosures[0].Length)
+	assertEqual(t, 90*time.Second, e.Enclosures[0].Duration)
+
+	e = f.Entries[1]
+	assertEqual(t, "1", e.ID)
+	assertEqual(t, "Plain &lt;text&gt;<br/>second line", e.Content)
+	assertEqual(t, "https://elsewhere.example.com/", e.Links["related"])
+	assertEqual(t, 1, len(e.Authors))
+	assertEqual(t, "John Roe", e.Authors[0])
+	assertEqual(t, true, time.Date(2020, time.February, 1, 9, 0, 0, 0, time.UTC).Equal(e.Created))
+	assertEqual(t, true, e.Created.Equal(e.Updated))
+
+}
+
+func TestJSONFeedVersion1(t *testing.T) {
+
+	t.Parallel()
+
+	feed := "\ufeff  " + `{"version": "https://jsonfeed.org/version/1", "title": "Old", "author": {"name": "Jane"},
+		"items": [{"id": "a", "content_text": "text", "author": {"name": "John"}}]}`
+
+	f := testFeed(t, strings.NewReader(feed))
+	assertEqual(t, "json1", f.Flavor)
+	assertEqual(t, 1, len(f.Authors))
+	assertEqual(t, "Jane", f.Authors[0])
+	assertEqual(t, 1, len(f.Entries))
+	assertEqual(t, "John", f.Entries[0].Authors[0])
+
+}
+
+func TestJSONFeedContentType(t *testing.T) {
+
+	t.Parallel()
+
+	p := NewParser()
+
+	// detected by content type despite leading garbage the sniffer does not skip
+	feed := strings.Repeat(" ", jsonPeekSize) + `{"version": "https://jsonfeed.org/version/1.1", "title": "Typed", "items": []}`
+	if _, err := p.Parse(strings.NewReader(feed)); err == nil {
+		t.Error("Expected error parsing as XML")
+	}
+	f, err := p.ParseContentType(strings.NewReader(feed), "application/feed+json; charset=utf-8")
+	if err != nil {
+		t.Fatalf("Cannot parse typed feed: %s", err.Error())
+	}
+	assertEqual(t, "Typed", f.Title)
+
+	// plain JSON documents are not feeds
+	if _, err := p.Parse(strings.NewReader(`{"title": "x"}`)); err != ErrJSONFeedVersion {
+		t.Errorf("Expected %v, actual %v", ErrJSONFeedVersion, err)
+	}
+
+	p.MaxEntries = 1
+	if _, err := p.Parse(strings.NewReader(`{"version": "https://jsonfeed.org/version/1.1", "items": [{"id": "1"}, {"id": "2"}]}`)); err != ErrTooManyEntries {
+		t.Errorf("Expected %v, actual %v", ErrTooManyEntries, err)
+	}
+
+}
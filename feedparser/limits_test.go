@@ -0,0 +1,49 @@
+package feedparser
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestMaxDepth(t *testing.T) {
+
+	t.Parallel()
+
+	nested := strings.Repeat("<x>", 50) + strings.Repeat("</x>", 50)
+	feed := `<rss version="2.0"><channel><title>Deep</title><item><title>One</title>` + nested + `</item></channel></rss>`
+
+	p := NewParser()
+	if _, err := p.Parse(strings.NewReader(feed)); err != nil {
+		t.Fatalf("Unlimited parser failed: %s", err.Error())
+	}
+
+	p.MaxDepth = 20
+	if _, err := p.Parse(strings.NewReader(feed)); err != ErrTooDeep {
+		t.Errorf("Expected %v, actual %v", ErrTooDeep, err)
+	}
+
+	p.MaxDepth = 60
+	if _, err := p.Parse(strings.NewReader(feed)); err != nil {
+		t.Errorf("Expected feed within limit to be parsed: %s", err.Error())
+	}
+
+}
+
+func TestMaxEntries(t *testing.T) {
+
+	t.Parallel()
+
+	feed := `<feed xmlns="http://www.w3.org/2005/Atom"><title>Many</title>` + strings.Repeat(`<entry><id>x</id><title>x</title></entry>`, 10) + `</feed>`
+
+	p := NewParser()
+	p.MaxEntries = 10
+	if f, err := p.Parse(strings.NewReader(feed)); err != nil || len(f.Entries) != 10 {
+		t.Fatalf("Expected feed within limit to be parsed: %v", err)
+	}
+
+	p.MaxEntries = 9
+	if _, err := p.Parse(strings.NewReader(feed)); err != ErrTooManyEntries {
+		t.Errorf("Expected %v, actual %v", ErrTooManyEntries, err)
+	}
+
+}
@@ -0,0 +1,94 @@
+package feedparser
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestMetadataRSS(t *testing.T) {
+
+	t.Parallel()
+
+	feed := `<?xml version="1.0"?>
+	<rss version="2.0" xmlns:sy="http://purl.org/rss/1.0/modules/syndication/">
+	  <channel>
+	    <title>Hints</title>
+	    <language>en-us</language>
+	    <category>Technology</category>
+	    <category>Go</category>
+	    <pubDate>Tue, 01 Mar 2016 10:00:00 GMT</pubDate>
+	    <lastBuildDate>Wed, 02 Mar 2016 12:00:00 GMT</lastBuildDate>
+	    <ttl>90</ttl>
+	    <skipHours><hour>0</hour><hour>1</hour><hour>24</hour><hour>x</hour></skipHours>
+	    <skipDays><day>Saturday</day><day>sunday</day><day>Funday</day></skipDays>
+	    <sy:updatePeriod>hourly</sy:updatePeriod>
+	    <sy:updateFrequency>2</sy:updateFrequency>
+	    <item><title>One</title><pubDate>Mon, 29 Feb 2016 09:00:00 GMT</pubDate></item>
+	  </channel>
+	</rss>`
+
+	p := NewParser()
+	f, err := p.Parse(strings.NewReader(feed))
+	if err != nil {
+		t.Fatalf("Cannot parse feed: %s", err.Error())
+	}
+
+	assertEqual(t, "en-us", f.Language)
+	assertEqual(t, 2, len(f.Categories))
+	assertEqual(t, "Go", f.Categories[1])
+	assertEqual(t, true, time.Date(2016, time.March, 2, 12, 0, 0, 0, time.UTC).Equal(f.Published))
+	assertEqual(t, true, time.Date(2016, time.February, 29, 9, 0, 0, 0, time.UTC).Equal(f.Updated))
+	assertEqual(t, 90*time.Minute, f.TTL)
+	assertEqual(t, 30*time.Minute, f.UpdatePeriod)
+	assertEqual(t, 3, len(f.SkipHours))
+	assertEqual(t, 0, f.SkipHours[2])
+	assertEqual(t, 2, len(f.SkipDays))
+	assertEqual(t, time.Saturday, f.SkipDays[0])
+	assertEqual(t, time.Sunday, f.SkipDays[1])
+
+	// values are not carried over to the next feed
+	f, err = p.Parse(strings.NewReader(`<rss version="2.0"><channel><title>Plain</title></channel></rss>`))
+	if err != nil {
+		t.Fatalf("Cannot parse feed: %s", err.Error())
+	}
+	assertEqual(t, time.Duration(0), f.UpdatePeriod)
+	assertEqual(t, time.Duration(0), f.TTL)
+
+}
+
+func TestMetadataAtom(t *testing.T) {
+
+	t.Parallel()
+
+	feed := `<feed xmlns="http://www.w3.org/2005/Atom" xmlns:sy="http://purl.org/rss/1.0/modules/syndication/" xml:lang="de">
+	  <title>Hints</title>
+	  <updated>2016-03-02T12:00:00Z</updated>
+	  <category term="news" label="News"/>
+	  <sy:updatePeriod>weekly</sy:updatePeriod>
+	</feed>`
+
+	f, err := NewParser().Parse(strings.NewReader(feed))
+	if err != nil {
+		t.Fatalf("Cannot parse feed: %s", err.Error())
+	}
+
+	assertEqual(t, "de", f.Language)
+	assertEqual(t, 1, len(f.Categories))
+	assertEqual(t, true, time.Date(2016, time.March, 2, 12, 0, 0, 0, time.UTC).Equal(f.Published))
+	assertEqual(t, true, f.Updated.IsZero())
+	assertEqual(t, 7*24*time.Hour, f.UpdatePeriod)
+
+}
+
+func TestMakeUpdatePeriod(t *testing.T) {
+
+	t.Parallel()
+
+	assertEqual(t, time.Duration(0), makeUpdatePeriod("", ""))
+	assertEqual(t, 24*time.Hour, makeUpdatePeriod("daily", ""))
+	assertEqual(t, 12*time.Hour, makeUpdatePeriod("", "2"))
+	assertEqual(t, time.Hour, makeUpdatePeriod(" Hourly ", "0"))
+	assertEqual(t, time.Duration(0), makeUpdatePeriod("fortnightly", "1"))
+
+}
@@ -0,0 +1,79 @@
+package feedparser
+
+import (
+	"encoding/xml"
+	"testing"
+	"time"
+)
+
+func TestElementsRDF(t *testing.T) {
+
+	t.Parallel()
+
+	elements := &elements{}
+
+	elements.stack = append(elements.stack, &element{name: xml.Name{Space: nsRDF, Local: "RDF"}})
+	assertEqual(t, true, elements.IsStackFeed(flavorRDF, 0))
+	assertEqual(t, false, elements.IsStackEntry(flavorRDF, 0))
+
+	elements.stack = append(elements.stack, &element{name: xml.Name{Space: nsRSS1, Local: "channel"}})
+	assertEqual(t, true, elements.IsStackFeed(flavorRDF, 0))
+	assertEqual(t, true, elements.IsStackFeed(flavorRDF, 1))
+	assertEqual(t, false, elements.IsStackEntry(flavorRDF, 0))
+
+	elements.stack = append(elements.stack, &element{name: xml.Name{Space: nsRSS1, Local: "title"}})
+	assertEqual(t, true, elements.IsStackFeed(flavorRDF, 1))
+	assertEqual(t, false, elements.IsStackEntry(flavorRDF, 1))
+
+	// items are siblings of the channel
+	elements.stack = elements.stack[:1]
+	elements.stack = append(elements.stack, &element{name: xml.Name{Space: nsRSS1, Local: "item"}})
+	assertEqual(t, true, elements.IsStackFeed(flavorRDF, 1))
+	assertEqual(t, true, elements.IsStackEntry(flavorRDF, 0))
+	assertEqual(t, false, elements.IsStackFeed(flavorRDF, 0))
+
+	elements.stack = append(elements.stack, &element{name: xml.Name{Space: nsRSS1, Local: "link"}})
+	assertEqual(t, true, elements.IsStackEntry(flavorRDF, 1))
+	assertEqual(t, false, elements.IsStackFeed(flavorRDF, 1))
+
+}
+
+func TestRDF(t *testing.T) {
+
+	t.Parallel()
+
+	f := testFile(t, "testdata/rss10.rdf")
+
+	assertEqual(t, "rdf", f.Flavor)
+	assertEqual(t, "http://example.gov/news/rss.rdf", f.ID)
+	assertEqual(t, "Agency News", f.Title)
+	assertEqual(t, "Press releases of the agency", f.Subtitle)
+	assertEqual(t, "Public domain", f.Rights)
+	assertEqual(t, "http://example.gov/logo.png", f.Icon)
+	assertEqual(t, "http://example.gov/news/", f.LinkAlternate)
+	assertEqual(t, "http://hub.example.gov/", f.LinkHub)
+	assertEqual(t, 1, len(f.Authors))
+	assertEqual(t, "Press Office", f.Authors[0])
+	assertEqual(t, true, time.Date(2016, time.March, 2, 8, 30, 0, 0, time.UTC).Equal(f.Updated))
+
+	assertEqual(t, 2, len(f.Entries))
+
+	e := f.Entries[0]
+	assertEqual(t, "http://example.gov/news/2", e.ID)
+	assertEqual(t, "Second release", e.Title)
+	assertEqual(t, "http://example.gov/news/2", e.LinkAlternate)
+	assertEqual(t, "Short description", e.Summary)
+	assertEqual(t, `<p>Full text with a <a href="http://example.gov/news/1">link</a></p>`, e.Content)
+	assertEqual(t, true, time.Date(2016, time.March, 2, 8, 30, 0, 0, time.UTC).Equal(e.Updated))
+	assertEqual(t, true, e.Created.Equal(e.Updated))
+	assertEqual(t, 1, len(e.Authors))
+	assertEqual(t, "Jane Doe", e.Authors[0])
+	assertEqual(t, 2, len(e.Categories))
+	assertEqual(t, "Science", e.Categories[1])
+
+	e = f.Entries[1]
+	assertEqual(t, "http://example.gov/news/1", e.ID)
+	assertEqual(t, "Only a description", e.Content)
+	assertEqual(t, "", e.Summary)
+
+}
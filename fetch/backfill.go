@@ -0,0 +1,223 @@
+package fetch
+
+import (
+	"errors"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/kwo/rakewire/model"
+)
+
+const (
+	// maxBackfillPages limits the number of pages fetched by one backfill.
+	maxBackfillPages = 100
+	// maxUserBackfills limits the number of backfills running at once for one user.
+	maxUserBackfills = 2
+	// wordpressPaged is the query parameter selecting a page of older items of a WordPress feed.
+	wordpressPaged = "paged"
+)
+
+var (
+	// ErrBackfillDisabled indicates that no items may be backfilled because the maximum is not configured.
+	ErrBackfillDisabled = errors.New("Backfill is disabled")
+	// ErrTooManyBackfills indicates that the user already has the maximum number of backfills running.
+	ErrTooManyBackfills = errors.New("Too many backfills running")
+)
+
+// Backfill imports older items of the feed for the subscription of the given user in the background.
+// The current document is fetched without a conditional request and reaped as usual, then the pages with older items
+// are followed, using the RFC 5005 prev-archive or next links or, for WordPress feeds, the paged query parameter.
+// Each page is reaped as an archive harvest, recorded as a transmission without altering the feed.
+// The backfill stops after maxItems older items, capped by the configured maximum, or at a page without unseen items.
+// Each user may run a limited number of backfills at once.
+func (z *Service) Backfill(feed *model.Feed, userID string, maxItems int) error {
+
+	if z.backfillMax <= 0 {
+		return ErrBackfillDisabled
+	}
+	if maxItems <= 0 || maxItems > z.backfillMax {
+		maxItems = z.backfillMax
+	}
+
+	z.backfillLock.Lock()
+	if z.backfillUsers[userID] >= maxUserBackfills {
+		z.backfillLock.Unlock()
+		return ErrTooManyBackfills
+	}
+	z.backfillUsers[userID]++
+	z.backfillLock.Unlock()
+
+	z.Lock()
+	running := z.running
+	output := z.output
+	if running {
+		z.senders.Add(1)
+	}
+	z.Unlock()
+	if !running {
+		z.endBackfill(userID)
+		return ErrNotRunning
+	}
+
+	go func() {
+		defer z.senders.Done()
+		defer z.endBackfill(userID)
+		z.backfill(output, feed, userID, maxItems)
+	}()
+
+	return nil
+
+}
+
+func (z *Service) backfill(output chan *model.Harvest, feed *model.Feed, userID string, maxItems int) {
+
+	// the current document, reaped as usual so that its new items reach all subscribers
+	current := *feed
+	current.ETag = ""
+	current.LastModified = time.Time{}
+	current.BodyHash = ""
+	if !z.waitForHost(current.URL) {
+		return
+	}
+	harvest := z.fetchFeed(&current, false)
+	harvest.BackfillUser = userID
+	if z.abandoned() || !z.send(output, harvest) {
+		return
+	}
+	if harvest.Transmission.Result != model.FetchResultOK {
+		log.Debugf("backfill of %s stopped: %s %s", feed.URL, harvest.Transmission.Result, harvest.Transmission.ResultMessage)
+		return
+	}
+
+	seen := make(map[string]bool)
+	for _, item := range harvest.Items {
+		seen[item.GUID] = true
+	}
+	visited := map[string]bool{current.URL: true}
+
+	var pages, total int
+	for page := 2; page <= maxBackfillPages && total < maxItems; page++ {
+
+		older := olderPage(current.URL, harvest, page)
+		if older == "" || visited[older] {
+			break
+		}
+		visited[older] = true
+
+		if !z.waitForHost(older) {
+			return
+		}
+		harvest = z.fetchArchive(feed, older)
+		harvest.BackfillUser = userID
+
+		// items of earlier pages are neither reaped again nor counted
+		unseen := model.Items{}
+		for _, item := range harvest.Items {
+			if !seen[item.GUID] {
+				seen[item.GUID] = true
+				unseen = append(unseen, item)
+			}
+		}
+		if remaining := maxItems - total; len(unseen) > remaining {
+			unseen = unseen[:remaining]
+		}
+		harvest.Items = unseen
+		total += len(unseen)
+		pages++
+
+		if z.abandoned() || !z.send(output, harvest) {
+			return
+		}
+		if harvest.Transmission.Result != model.FetchResultOK || len(unseen) == 0 {
+			break
+		}
+
+	}
+
+	log.Infof("backfilled %d items in %d pages: %s", total, pages, feed.URL)
+
+}
+
+// endBackfill releases the backfill slot of the user.
+func (z *Service) endBackfill(userID string) {
+	z.backfillLock.Lock()
+	defer z.backfillLock.Unlock()
+	z.backfillUsers[userID]--
+	if z.backfillUsers[userID] <= 0 {
+		delete(z.backfillUsers, userID)
+	}
+}
+
+// fetchArchive fetches a page with older items of the feed, using a copy of the feed so that the feed is not altered.
+func (z *Service) fetchArchive(feed *model.Feed, pageURL string) *model.Harvest {
+	page := *feed
+	page.URL = pageURL
+	page.ETag = ""
+	page.LastModified = time.Time{}
+	page.BodyHash = ""
+	harvest := z.fetchFeed(&page, false)
+	harvest.Archive = true
+	harvest.Transmission.Archive = true
+	return harvest
+}
+
+// waitForHost waits until the host of the URL may be contacted again by a backfill and reserves the next slot.
+// It returns false if the service stops in the meantime.
+func (z *Service) waitForHost(rawurl string) bool {
+
+	host := rawurl
+	if u, err := url.Parse(rawurl); err == nil {
+		host = strings.ToLower(u.Host)
+	}
+
+	now := time.Now()
+	z.backfillLock.Lock()
+	for h, next := range z.backfillHosts {
+		if next.Before(now) {
+			delete(z.backfillHosts, h)
+		}
+	}
+	next := z.backfillHosts[host]
+	if next.Before(now) {
+		next = now
+	}
+	z.backfillHosts[host] = next.Add(z.backfillDelay)
+	z.backfillLock.Unlock()
+
+	timer := time.NewTimer(next.Sub(now))
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return true
+	case <-z.stopping:
+		return false
+	case <-z.ctx.Done():
+		return false
+	}
+
+}
+
+// olderPage returns the URL of the page following the given harvest, from its archive links or,
+// lacking these, for WordPress feeds the feed URL with the number of the page as the paged query parameter.
+func olderPage(feedURL string, harvest *model.Harvest, page int) string {
+
+	if harvest.Older != "" {
+		return harvest.Older
+	}
+
+	if strings.Contains(strings.ToLower(harvest.Transmission.Generator), "wordpress") {
+		u, err := url.Parse(feedURL)
+		if err != nil {
+			return ""
+		}
+		query := u.Query()
+		query.Set(wordpressPaged, strconv.Itoa(page))
+		u.RawQuery = query.Encode()
+		return u.String()
+	}
+
+	return ""
+
+}
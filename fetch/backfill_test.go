@@ -0,0 +1,214 @@
+package fetch
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/kwo/rakewire/model"
+)
+
+func TestBackfillArchive(t *testing.T) {
+
+	t.Parallel()
+
+	page := func(prev string, ids ...int) string {
+		result := `<feed xmlns="http://www.w3.org/2005/Atom"><title>Archive</title><id>urn:archive</id>`
+		if prev != "" {
+			result += fmt.Sprintf(`<link rel="prev-archive" href="%s"/>`, prev)
+		}
+		for _, id := range ids {
+			result += fmt.Sprintf(`<entry><id>urn:%d</id><title>%d</title><updated>2016-03-0%dT10:00:00Z</updated></entry>`, id, id, id)
+		}
+		return result + `</feed>`
+	}
+	pages := map[string]string{
+		"/feed":      page("/archive/2", 6, 5),
+		"/archive/2": page("1", 5, 4, 3), // repeats an item of the current document
+		"/archive/1": page("", 2, 1),
+	}
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get(hIfNoneMatch) != "" {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		body, ok := pages[r.URL.Path]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		w.Header().Set(hContentType, "application/atom+xml")
+		w.Write([]byte(body))
+	}))
+	defer server.Close()
+
+	feed := model.F.New(server.URL + "/feed")
+	feed.ETag = "etag"
+
+	harvests := backfillHarvests(t, &Configuration{TimeoutSeconds: 5, BackfillMaxItems: 10}, feed, 3, 3)
+
+	current := harvests[0]
+	if current.Archive || current.Transmission.Archive || current.BackfillUser != "user" {
+		t.Errorf("Bad current harvest: archive %t, user %s", current.Archive, current.BackfillUser)
+	}
+	if current.Transmission.StatusCode != http.StatusOK || len(current.Items) != 2 {
+		t.Errorf("Current document not fetched unconditionally: %d, items: %d", current.Transmission.StatusCode, len(current.Items))
+	}
+	if current.Older != server.URL+"/archive/2" {
+		t.Errorf("Bad older page: %s", current.Older)
+	}
+
+	expected := []struct {
+		url   string
+		items int
+	}{
+		{server.URL + "/archive/2", 2},
+		{server.URL + "/archive/1", 1}, // truncated to the maximum
+	}
+	for i, e := range expected {
+		harvest := harvests[i+1]
+		if !harvest.Archive || !harvest.Transmission.Archive || harvest.BackfillUser != "user" {
+			t.Errorf("Harvest %d not an archive of the user", i+1)
+		}
+		if harvest.Transmission.URL != e.url || harvest.Transmission.FeedID != feed.ID {
+			t.Errorf("Bad transmission %d: %s %s", i+1, harvest.Transmission.URL, harvest.Transmission.FeedID)
+		}
+		if len(harvest.Items) != e.items {
+			t.Errorf("Bad item count %d, expected %d, actual %d", i+1, e.items, len(harvest.Items))
+		}
+	}
+
+	if feed.URL != server.URL+"/feed" || feed.ETag != "etag" {
+		t.Errorf("Feed modified: %s %s", feed.URL, feed.ETag)
+	}
+
+}
+
+func TestBackfillWordPress(t *testing.T) {
+
+	t.Parallel()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		paged := r.URL.Query().Get(wordpressPaged)
+		if paged == "3" {
+			http.NotFound(w, r)
+			return
+		}
+		if paged == "" {
+			paged = "1"
+		}
+		w.Header().Set(hContentType, "application/rss+xml")
+		fmt.Fprintf(w, `<rss version="2.0"><channel><title>Blog</title><generator>https://wordpress.org/?v=6.4</generator>
+			<item><guid>%s-a</guid></item><item><guid>%s-b</guid></item></channel></rss>`, paged, paged)
+	}))
+	defer server.Close()
+
+	harvests := backfillHarvests(t, &Configuration{TimeoutSeconds: 5, BackfillMaxItems: 10}, model.F.New(server.URL+"/?feed=rss2"), 0, 3)
+	if url := harvests[1].Transmission.URL; url != server.URL+"/?feed=rss2&paged=2" {
+		t.Errorf("Bad page URL: %s", url)
+	}
+	if len(harvests[1].Items) != 2 || harvests[1].Items[0].GUID != "2-a" {
+		t.Errorf("Bad items of page 2: %d", len(harvests[1].Items))
+	}
+	if result := harvests[2].Transmission.Result; result != model.FetchResultServerError {
+		t.Errorf("Bad result of page 3: %s", result)
+	}
+
+}
+
+func TestBackfillDisabled(t *testing.T) {
+
+	t.Parallel()
+
+	z := NewService(&Configuration{TimeoutSeconds: 5}, nil, make(chan *model.Harvest))
+	if err := z.Backfill(model.F.New("http://localhost/"), "user", 10); err != ErrBackfillDisabled {
+		t.Errorf("Expected backfill disabled error, actual: %v", err)
+	}
+
+	z = NewService(&Configuration{TimeoutSeconds: 5, BackfillMaxItems: 10}, nil, make(chan *model.Harvest))
+	if err := z.Backfill(model.F.New("http://localhost/"), "user", 10); err != ErrNotRunning {
+		t.Errorf("Expected not running error, actual: %v", err)
+	}
+
+}
+
+func TestBackfillUserLimit(t *testing.T) {
+
+	t.Parallel()
+
+	z := newTestService(t, &Configuration{TimeoutSeconds: 5, BackfillMaxItems: 10})
+	defer z.Stop()
+
+	z.backfillUsers["user"] = maxUserBackfills
+	if err := z.Backfill(model.F.New("http://localhost/"), "user", 10); err != ErrTooManyBackfills {
+		t.Errorf("Expected too many backfills error, actual: %v", err)
+	}
+
+	z.endBackfill("user")
+	z.endBackfill("user")
+	if len(z.backfillUsers) != 0 {
+		t.Errorf("Backfills of the user not released: %d", z.backfillUsers["user"])
+	}
+
+}
+
+func TestBackfillHostDelay(t *testing.T) {
+
+	t.Parallel()
+
+	z := newTestService(t, &Configuration{TimeoutSeconds: 5, BackfillMaxItems: 10})
+	defer z.Stop()
+	z.backfillDelay = 100 * time.Millisecond
+
+	start := time.Now()
+	if !z.waitForHost("http://example.com/feed") || !z.waitForHost("http://example.org/feed") {
+		t.Fatal("Wait aborted")
+	}
+	if elapsed := time.Since(start); elapsed >= z.backfillDelay {
+		t.Errorf("Different hosts delayed: %s", elapsed)
+	}
+	if !z.waitForHost("http://EXAMPLE.com/archive") {
+		t.Fatal("Wait aborted")
+	}
+	if elapsed := time.Since(start); elapsed < z.backfillDelay {
+		t.Errorf("Same host not delayed: %s", elapsed)
+	}
+
+}
+
+// backfillHarvests runs a backfill of the feed for "user" and returns the expected number of harvests
+// passed on to the reaper, failing if more follow.
+func backfillHarvests(t *testing.T, cfg *Configuration, feed *model.Feed, maxItems, count int) []*model.Harvest {
+
+	output := make(chan *model.Harvest)
+	z := NewService(cfg, nil, output)
+	if err := z.Start(); err != nil {
+		t.Fatalf("Cannot start service: %s", err.Error())
+	}
+
+	if err := z.Backfill(feed, "user", maxItems); err != nil {
+		t.Fatalf("Cannot backfill feed: %s", err.Error())
+	}
+
+	var harvests []*model.Harvest
+	timeout := time.After(5 * time.Second)
+	for len(harvests) < count {
+		select {
+		case harvest := <-output:
+			harvests = append(harvests, harvest)
+		case <-timeout:
+			t.Fatalf("Bad harvest count, expected %d, actual %d", count, len(harvests))
+		}
+	}
+
+	go z.Stop()
+	for harvest := range output {
+		t.Errorf("Unexpected harvest: %s", harvest.Transmission.URL)
+	}
+
+	return harvests
+
+}
@@ -1,8 +1,13 @@
 package fetch
 
 import (
+	"crypto/tls"
+	"crypto/x509"
 	"errors"
+	"fmt"
+	"io/ioutil"
 	"net/http"
+	"net/url"
 	"time"
 )
 
@@ -11,12 +16,139 @@ var (
 	ErrRedirected = errors.New("Fetch URL redirected")
 )
 
-func newInternalClient(timeoutSeconds int) *http.Client {
+// clientKey identifies a client by the per-feed settings which require a separate transport.
+type clientKey struct {
+	proxy    string
+	insecure bool
+}
+
+// NewClient returns a client with the timeout, proxy and TLS settings of the configuration
+// for the services which retrieve other resources than feeds, such as articles, icons or hub subscriptions.
+func NewClient(cfg *Configuration) (*http.Client, error) {
+
+	proxyURL, err := parseProxyURL(cfg.Proxy)
+	if err != nil {
+		return nil, err
+	}
+
+	tlsConfig, err := newTLSConfig(cfg.TLSCAFile, cfg.TLSCertFile, cfg.TLSKeyFile, false)
+	if err != nil {
+		return nil, err
+	}
+
+	transport := &http.Transport{TLSClientConfig: tlsConfig}
+	if proxyURL != nil {
+		transport.Proxy = http.ProxyURL(proxyURL)
+	} else {
+		transport.Proxy = http.ProxyFromEnvironment
+	}
+
 	return &http.Client{
-		Transport:     &internalTransport{},
+		Transport: transport,
+		Timeout:   time.Duration(cfg.TimeoutSeconds) * time.Second,
+	}, nil
+
+}
+
+func newInternalClient(timeoutSeconds int, proxyURL *url.URL, tlsConfig *tls.Config) *http.Client {
+
+	transport := &internalTransport{}
+	transport.TLSClientConfig = tlsConfig
+	if proxyURL != nil {
+		transport.Proxy = http.ProxyURL(proxyURL)
+	} else {
+		transport.Proxy = http.ProxyFromEnvironment
+	}
+
+	return &http.Client{
+		Transport:     transport,
 		CheckRedirect: noFlaggedRequestsRedirectPolicy,
 		Timeout:       time.Duration(timeoutSeconds) * time.Second,
 	}
+
+}
+
+func newTLSConfig(caFile, certFile, keyFile string, insecure bool) (*tls.Config, error) {
+
+	tlsConfig := &tls.Config{
+		InsecureSkipVerify: insecure,
+	}
+
+	if caFile != "" {
+		pool, err := x509.SystemCertPool()
+		if err != nil || pool == nil {
+			pool = x509.NewCertPool()
+		}
+		data, err := ioutil.ReadFile(caFile)
+		if err != nil {
+			return nil, err
+		}
+		if !pool.AppendCertsFromPEM(data) {
+			return nil, fmt.Errorf("No certificates found in CA file: %s", caFile)
+		}
+		tlsConfig.RootCAs = pool
+	}
+
+	if certFile != "" || keyFile != "" {
+		cert, err := tls.LoadX509KeyPair(certFile, keyFile)
+		if err != nil {
+			return nil, err
+		}
+		tlsConfig.Certificates = []tls.Certificate{cert}
+	}
+
+	return tlsConfig, nil
+
+}
+
+// parseProxyURL validates a proxy URL, supported schemes are http, https and socks5.
+func parseProxyURL(proxy string) (*url.URL, error) {
+
+	if proxy == "" {
+		return nil, nil
+	}
+
+	proxyURL, err := url.Parse(proxy)
+	if err != nil {
+		return nil, err
+	}
+
+	switch proxyURL.Scheme {
+	case "http", "https", "socks5":
+		if proxyURL.Host == "" {
+			return nil, fmt.Errorf("Missing proxy host: %s", proxy)
+		}
+	default:
+		return nil, fmt.Errorf("Unsupported proxy scheme: %s", proxy)
+	}
+
+	return proxyURL, nil
+
+}
+
+// CheckProxy reports why the fetcher cannot use the proxy URL, an empty URL selects the default proxy.
+func CheckProxy(proxy string) error {
+	_, err := parseProxyURL(proxy)
+	return err
+}
+
+// RedactProxy removes the credentials from a proxy URL so that it can be shown, it returns an empty string for invalid URLs.
+func RedactProxy(proxy string) string {
+	proxyURL, err := url.Parse(proxy)
+	if err != nil || proxy == "" {
+		return ""
+	}
+	return redactProxyURL(proxyURL)
+}
+
+// redactProxyURL removes credentials from the proxy URL so that it can be logged.
+func redactProxyURL(proxyURL *url.URL) string {
+	if proxyURL == nil {
+		return ""
+	}
+	u := *proxyURL
+	u.User = nil
+	return u.String()
 }
 
 func noFlaggedRequestsRedirectPolicy(req *http.Request, via []*http.Request) error {
@@ -0,0 +1,121 @@
+package fetch
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/kwo/rakewire/feedparser"
+	"github.com/kwo/rakewire/model"
+	"golang.org/x/net/context"
+)
+
+const (
+	maxDiscoveryRedirects = 5
+)
+
+var (
+	// ErrNoFeeds indicates that no feeds could be found at the given URL.
+	ErrNoFeeds = errors.New("No feeds found")
+	// commonFeedPaths are probed, relative to the web page, if the page does not advertise any feeds.
+	commonFeedPaths = []string{"feed", "rss", "feed.xml", "rss.xml", "atom.xml", "index.xml", "feeds/posts/default"}
+)
+
+// Discover finds the feeds available at the given URL.
+// If the URL points to a feed, that feed is the only candidate.
+// Otherwise the feeds advertised by the web page are returned or, if none, the first feed found at a common feed path.
+func (z *Service) Discover(ctx context.Context, rawurl string) ([]*feedparser.FeedLink, error) {
+
+	if !z.IsRunning() {
+		return nil, ErrNotRunning
+	}
+
+	// feeds of other sources cannot be discovered, the URL must be the feed itself
+	source, err := z.sourceFor(rawurl)
+	if err != nil {
+		return nil, err
+	}
+	if _, ok := source.(*httpSource); !ok {
+		return []*feedparser.FeedLink{{URL: rawurl}}, nil
+	}
+
+	client, _, err := z.clientFor(model.F.New(rawurl))
+	if err != nil {
+		return nil, err
+	}
+
+	body, finalURL, err := z.discoveryGet(ctx, client, rawurl)
+	if err != nil {
+		return nil, err
+	}
+
+	if feed, err := feedparser.NewParser().Parse(bytes.NewReader(body)); err == nil && feed != nil {
+		return []*feedparser.FeedLink{{URL: finalURL, Title: feed.Title}}, nil
+	}
+
+	// a web page cannot advertise local files or commands
+	var links []*feedparser.FeedLink
+	for _, link := range feedparser.FindFeedLinks(finalURL, string(body)) {
+		if !IsLocal(link.URL) {
+			links = append(links, link)
+		}
+	}
+	if len(links) > 0 {
+		return links, nil
+	}
+
+	for _, path := range commonFeedPaths {
+		body, feedURL, err := z.discoveryGet(ctx, client, resolveURL(finalURL, path))
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil, ctx.Err()
+			}
+			continue
+		}
+		if feed, err := feedparser.NewParser().Parse(bytes.NewReader(body)); err == nil && feed != nil {
+			return []*feedparser.FeedLink{{URL: feedURL, Title: feed.Title}}, nil
+		}
+	}
+
+	return nil, ErrNoFeeds
+
+}
+
+// discoveryGet retrieves the content at the given URL, following all redirects, and returns the final URL.
+func (z *Service) discoveryGet(ctx context.Context, client *http.Client, rawurl string) ([]byte, string, error) {
+
+	for i := 0; i <= maxDiscoveryRedirects; i++ {
+
+		req, err := http.NewRequest(mGET, rawurl, nil)
+		if err != nil {
+			return nil, rawurl, err
+		}
+		req = req.WithContext(ctx)
+		req.Header.Set(hUserAgent, z.userAgent)
+		req.Header.Set(hAcceptEncoding, "gzip")
+
+		rsp, err := client.Do(req)
+		if err != nil && (rsp == nil || rsp.StatusCode != http.StatusMovedPermanently) {
+			return nil, rawurl, err
+		}
+
+		if rsp.StatusCode == http.StatusMovedPermanently {
+			rsp.Body.Close()
+			rawurl = resolveURL(rawurl, rsp.Header.Get(hLocation))
+			continue
+		}
+
+		if rsp.StatusCode != http.StatusOK {
+			rsp.Body.Close()
+			return nil, rawurl, fmt.Errorf("Cannot retrieve %s: %s", rawurl, rsp.Status)
+		}
+
+		data, err := readAll(rsp, z.maxSize, z.maxDecompressed)
+		return data, rsp.Request.URL.String(), err
+
+	}
+
+	return nil, rawurl, fmt.Errorf("Too many redirects: %s", rawurl)
+
+}
@@ -0,0 +1,71 @@
+package fetch
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"golang.org/x/net/context"
+)
+
+func TestDiscover(t *testing.T) {
+
+	t.Parallel()
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set(hContentType, "text/html")
+		w.Write([]byte(`<html><head><link rel="alternate" type="application/atom+xml" title="Atom" href="/atom.xml"><link rel="alternate" type="application/rss+xml" title="RSS" href="/rss.xml"><link rel="alternate" type="application/rss+xml" title="Local" href="file:///etc/passwd"></head></html>`))
+	})
+	mux.HandleFunc("/nolinks/", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set(hContentType, "text/html")
+		w.Write([]byte(`<html><head><title>No Links</title></head></html>`))
+	})
+	mux.HandleFunc("/nolinks/feed.xml", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set(hContentType, "application/atom+xml")
+		w.Write([]byte(testFeed))
+	})
+	mux.HandleFunc("/atom.xml", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set(hContentType, "application/atom+xml")
+		w.Write([]byte(testFeed))
+	})
+	mux.HandleFunc("/moved.xml", func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, "/atom.xml", http.StatusMovedPermanently)
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	z := newTestService(t, &Configuration{TimeoutSeconds: 5})
+	ctx := context.Background()
+
+	// web page with several feeds
+	links, err := z.Discover(ctx, server.URL+"/")
+	if err != nil {
+		t.Fatalf("Cannot discover feeds: %s", err.Error())
+	}
+	if len(links) != 2 {
+		t.Fatalf("Bad candidate count, expected %d, actual %d", 2, len(links))
+	}
+	if links[0].URL != server.URL+"/atom.xml" || links[0].Title != "Atom" {
+		t.Errorf("Bad candidate: %s %s", links[0].URL, links[0].Title)
+	}
+
+	// feed URL, permanently redirected
+	links, err = z.Discover(ctx, server.URL+"/moved.xml")
+	if err != nil {
+		t.Fatalf("Cannot discover feeds: %s", err.Error())
+	}
+	if len(links) != 1 || links[0].URL != server.URL+"/atom.xml" || links[0].Title != "Test Feed" {
+		t.Errorf("Bad candidates: %v", links)
+	}
+
+	// web page without feed links, feed at common path
+	links, err = z.Discover(ctx, server.URL+"/nolinks/")
+	if err != nil {
+		t.Fatalf("Cannot discover feeds: %s", err.Error())
+	}
+	if len(links) != 1 || links[0].URL != server.URL+"/nolinks/feed.xml" {
+		t.Errorf("Bad candidates: %v", links)
+	}
+
+}
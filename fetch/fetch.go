@@ -1,27 +1,39 @@
 package fetch
 
 import (
+	"bytes"
+	"crypto/tls"
 	"errors"
 	"fmt"
+	"io"
 	"net/http"
 	"net/url"
+	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/kwo/rakewire/feedparser"
 	"github.com/kwo/rakewire/logger"
 	"github.com/kwo/rakewire/model"
+	"github.com/kwo/rakewire/scraper"
+	"golang.org/x/net/context"
 )
 
 const (
 	hAcceptEncoding  = "Accept-Encoding"
+	hAge             = "Age"
+	hCacheControl    = "Cache-Control"
 	hContentEncoding = "Content-Encoding"
 	hContentType     = "Content-Type"
+	hDate            = "Date"
 	hEtag            = "ETag"
+	hExpires         = "Expires"
 	hIfModifiedSince = "If-Modified-Since"
 	hIfNoneMatch     = "If-None-Match"
 	hLastModified    = "Last-Modified"
 	hLocation        = "Location"
+	hRetryAfter      = "Retry-After"
 	hUserAgent       = "User-Agent"
 	mGET             = "GET"
 )
@@ -29,37 +41,108 @@ const (
 var (
 	// ErrRestart indicates that the service cannot be started because it is already running.
 	ErrRestart = errors.New("The service is already started")
+	// ErrNotRunning indicates that the service cannot fetch feeds because it has not been started.
+	ErrNotRunning = errors.New("The service is not running")
+	// ErrTooLarge indicates that the response body exceeds the maximum size.
+	ErrTooLarge = errors.New("Response exceeds the maximum size")
+	// ErrBomb indicates that the decompressed response body exceeds the maximum size.
+	ErrBomb = errors.New("Decompressed response exceeds the maximum size")
+	// ErrNotFeed indicates that the response cannot be a feed.
+	ErrNotFeed = errors.New("Response is not a feed")
 	log        = logger.New("fetch")
 )
 
 // Configuration contains all parameters for the Fetch service
 type Configuration struct {
-	TimeoutSeconds int
-	Workers        int
-	UserAgent      string
+	TimeoutSeconds    int
+	Workers           int
+	UserAgent         string
+	Proxy             string            // http, https or socks5 URL, defaults to the environment
+	TLSCAFile         string            // PEM bundle added to the system certificate pool
+	TLSCertFile       string            // client certificate
+	TLSKeyFile        string            // client certificate key
+	MaxSizeKB         int               // maximum size of a response body as transferred, zero for no limit
+	MaxDecompressedKB int               // maximum size of a decompressed response body, zero for no limit
+	MaxDepth          int               // maximum nesting of feed elements, zero for no limit
+	MaxEntries        int               // maximum number of entries in a feed, zero for no limit
+	FileRoots         []string          // directories from which file:// feeds may be read, none to disable file feeds
+	Commands          map[string]string // command lines run by exec://name feeds, none to disable exec feeds
+	BackfillMaxItems  int               // maximum number of older items imported for a new subscription, zero to disable backfill
+	BackfillDelaySecs int               // minimum time between backfill requests to the same host
 }
 
 // Service fetches feeds
 type Service struct {
 	sync.Mutex
-	running   bool
-	input     chan *model.Feed
-	output    chan *model.Harvest
-	workers   int
-	latch     sync.WaitGroup
-	client    *http.Client
-	userAgent string
+	running         bool
+	input           chan *model.Feed
+	output          chan *model.Harvest
+	workers         int
+	latch           sync.WaitGroup  // workers
+	senders         sync.WaitGroup  // refreshes and pushes passing harvests to the reaper
+	ctx             context.Context // cancelled to abandon in-flight fetches
+	cancel          context.CancelFunc
+	stopping        chan struct{} // closed when the workers are to stop taking feeds
+	clientsLock     sync.Mutex
+	clients         map[clientKey]*http.Client
+	timeoutSeconds  int
+	proxy           string
+	proxyURL        *url.URL
+	tlsCAFile       string
+	tlsCertFile     string
+	tlsKeyFile      string
+	tlsConfig       *tls.Config
+	userAgent       string
+	maxSize         int64
+	maxDecompressed int64
+	maxDepth        int
+	maxEntries      int
+	sources         map[string]FeedSource // by URL scheme
+	backfillMax     int
+	backfillDelay   time.Duration
+	backfillLock    sync.Mutex
+	backfillHosts   map[string]time.Time // earliest time of the next backfill request per host
+	backfillUsers   map[string]int       // running backfills per user
+	activityLock    sync.Mutex
+	activity        []*model.WorkerActivity // state of each worker
+	queued          int32                   // harvests waiting for the reaper
 }
 
 // NewService create new fetcher service
 func NewService(cfg *Configuration, input chan *model.Feed, output chan *model.Harvest) *Service {
-	return &Service{
-		input:     input,
-		output:    output,
-		workers:   cfg.Workers,
-		client:    newInternalClient(cfg.TimeoutSeconds),
-		userAgent: cfg.UserAgent,
+	z := &Service{
+		input:           input,
+		output:          output,
+		workers:         cfg.Workers,
+		timeoutSeconds:  cfg.TimeoutSeconds,
+		proxy:           cfg.Proxy,
+		tlsCAFile:       cfg.TLSCAFile,
+		tlsCertFile:     cfg.TLSCertFile,
+		tlsKeyFile:      cfg.TLSKeyFile,
+		userAgent:       cfg.UserAgent,
+		maxSize:         int64(cfg.MaxSizeKB) * 1024,
+		maxDecompressed: int64(cfg.MaxDecompressedKB) * 1024,
+		maxDepth:        cfg.MaxDepth,
+		maxEntries:      cfg.MaxEntries,
+		backfillMax:     cfg.BackfillMaxItems,
+		backfillDelay:   time.Duration(cfg.BackfillDelaySecs) * time.Second,
+		backfillHosts:   make(map[string]time.Time),
+		backfillUsers:   make(map[string]int),
+		ctx:             context.Background(),
+		cancel:          func() {},
 	}
+	web := &httpSource{service: z}
+	z.sources = map[string]FeedSource{
+		"http":  web,
+		"https": web,
+	}
+	if len(cfg.FileRoots) > 0 {
+		z.sources["file"] = &fileSource{service: z, roots: cfg.FileRoots}
+	}
+	if len(cfg.Commands) > 0 {
+		z.sources["exec"] = &execSource{service: z, commands: cfg.Commands}
+	}
+	return z
 }
 
 // Start service
@@ -73,9 +156,60 @@ func (z *Service) Start() error {
 	}
 
 	log.Infof("starting...")
-	log.Infof("timeout:    %s", z.client.Timeout.String())
+	log.Infof("timeout:    %s", (time.Duration(z.timeoutSeconds) * time.Second).String())
 	log.Infof("workers:    %d", z.workers)
 	log.Infof("user agent: %s", z.userAgent)
+	if z.maxSize > 0 {
+		log.Infof("max size:   %d KB", z.maxSize/1024)
+	}
+	if z.maxDecompressed > 0 {
+		log.Infof("max gunzip: %d KB", z.maxDecompressed/1024)
+	}
+	if z.backfillMax > 0 {
+		log.Infof("backfill:   %d items, %s between requests", z.backfillMax, z.backfillDelay.String())
+	}
+	if source, ok := z.sources["file"].(*fileSource); ok {
+		log.Infof("file roots: %s", strings.Join(source.roots, ", "))
+	}
+	if source, ok := z.sources["exec"].(*execSource); ok {
+		for name, command := range source.commands {
+			log.Infof("exec:       %s = %s", name, command)
+		}
+	}
+
+	proxyURL, err := parseProxyURL(z.proxy)
+	if err != nil {
+		log.Debugf("cannot parse proxy: %s", err.Error())
+		return err
+	}
+	z.proxyURL = proxyURL
+	if z.proxyURL != nil {
+		log.Infof("proxy:      %s", redactProxyURL(z.proxyURL))
+	}
+
+	tlsConfig, err := newTLSConfig(z.tlsCAFile, z.tlsCertFile, z.tlsKeyFile, false)
+	if err != nil {
+		log.Debugf("cannot create tls configuration: %s", err.Error())
+		return err
+	}
+	z.tlsConfig = tlsConfig
+	z.clients = make(map[clientKey]*http.Client)
+	if z.tlsCAFile != "" {
+		log.Infof("tls ca:     %s", z.tlsCAFile)
+	}
+	if z.tlsCertFile != "" {
+		log.Infof("tls cert:   %s", z.tlsCertFile)
+	}
+
+	z.activityLock.Lock()
+	z.activity = make([]*model.WorkerActivity, z.workers)
+	for i := 0; i < z.workers; i++ {
+		z.activity[i] = &model.WorkerActivity{ID: i, State: model.WorkerIdle, Since: time.Now()}
+	}
+	z.activityLock.Unlock()
+
+	z.ctx, z.cancel = context.WithCancel(context.Background())
+	z.stopping = make(chan struct{})
 
 	for i := 0; i < z.workers; i++ {
 		z.latch.Add(1)
@@ -89,21 +223,57 @@ func (z *Service) Start() error {
 
 // Stop service
 func (z *Service) Stop() {
+	z.Shutdown(context.Background())
+}
+
+// Shutdown stops taking feeds and waits for the in-flight fetches to complete and be passed on to the reaper.
+// Once the context is done, in-flight fetches are abandoned: their harvests are dropped
+// so that the feeds are fetched again after restart. The output is closed when all harvests have been passed on.
+func (z *Service) Shutdown(ctx context.Context) error {
 
 	z.Lock()
-	defer z.Unlock()
 	if !z.running {
+		z.Unlock()
 		log.Debugf("service already stopped, exiting...")
-		return
+		return nil
 	}
+	z.running = false // refuse new refreshes and pushes
+	z.Unlock()
 
 	log.Debugf("stopping...")
-	z.latch.Wait()
+	close(z.stopping)
+
+	drained := make(chan struct{})
+	go func() {
+		z.latch.Wait()
+		z.senders.Wait()
+		close(drained)
+	}()
+
+	var err error
+	select {
+	case <-drained:
+	case <-ctx.Done():
+		log.Infof("abandoning in-flight fetches: %s", ctx.Err().Error())
+		err = ctx.Err()
+		z.cancel()
+		<-drained
+	}
+	z.cancel()
+
+	z.Lock()
+	close(z.output)
 	z.input = nil
 	z.output = nil
-	z.running = false
+	z.clients = nil
+	z.Unlock()
+	z.activityLock.Lock()
+	z.activity = nil
+	z.activityLock.Unlock()
 	log.Infof("stopped")
 
+	return err
+
 }
 
 // IsRunning indicated if the service is active or not.
@@ -113,12 +283,69 @@ func (z *Service) IsRunning() bool {
 	return z.running
 }
 
+// Activity reports what each worker is doing and how many harvests wait for the reaper.
+func (z *Service) Activity() *model.FetchActivity {
+
+	result := &model.FetchActivity{
+		Running: z.IsRunning(),
+		Queued:  int(atomic.LoadInt32(&z.queued)),
+	}
+
+	z.activityLock.Lock()
+	defer z.activityLock.Unlock()
+	for _, worker := range z.activity {
+		w := *worker
+		result.Workers = append(result.Workers, &w)
+	}
+
+	return result
+
+}
+
+func (z *Service) setActivity(id int, state string, feed *model.Feed) {
+
+	z.activityLock.Lock()
+	defer z.activityLock.Unlock()
+	if id < 0 || id >= len(z.activity) {
+		return
+	}
+
+	worker := &model.WorkerActivity{ID: id, State: state, Since: time.Now()}
+	if feed != nil {
+		worker.FeedID = feed.ID
+		worker.URL = feed.URL
+	}
+	z.activity[id] = worker
+
+}
+
+// send passes the harvest on to the reaper, counting it as queued until accepted, and returns false if it was abandoned instead.
+func (z *Service) send(output chan *model.Harvest, harvest *model.Harvest) bool {
+	atomic.AddInt32(&z.queued, 1)
+	defer atomic.AddInt32(&z.queued, -1)
+	select {
+	case output <- harvest:
+		return true
+	case <-z.ctx.Done():
+		return false
+	}
+}
+
 func (z *Service) run(id int) {
 
 	log.Debugf("fetcher %2d starting...", id)
 
-	for req := range z.input {
-		z.processFeed(req, id)
+run:
+	for {
+		select {
+		case feed, ok := <-z.input:
+			if !ok {
+				break run
+			}
+			z.processFeed(feed, id)
+		case <-z.stopping:
+			break run
+		}
 	}
 
 	log.Debugf("fetcher %2d exited", id)
@@ -126,56 +353,220 @@ func (z *Service) run(id int) {
 
 }
 
-func (z *Service) processFeed(feed *model.Feed, id int) {
+// abandoned reports if in-flight fetches are to be dropped because the shutdown deadline has passed.
+func (z *Service) abandoned() bool {
+	return z.ctx.Err() != nil
+}
 
-	harvest := &model.Harvest{
-		Feed: feed,
+// Refresh fetches the feed immediately, bypassing the poll interval, and waits until the harvest has been reaped.
+// If the context is done before, the harvest is still reaped but the transmission may not yet be complete.
+func (z *Service) Refresh(ctx context.Context, feed *model.Feed) (*model.Transmission, error) {
+
+	z.Lock()
+	running := z.running
+	output := z.output
+	if running {
+		z.senders.Add(1)
+	}
+	z.Unlock()
+	if !running {
+		return nil, ErrNotRunning
+	}
+
+	var harvest *model.Harvest
+	reaped := make(chan struct{})
+	abandoned := make(chan struct{})
+	go func() {
+		defer z.senders.Done()
+		h := z.fetchFeed(feed, false)
+		h.Reaped = reaped
+		harvest = h
+		if z.abandoned() || !z.send(output, h) {
+			close(abandoned)
+		}
+	}()
+
+	select {
+	case <-reaped:
+		return harvest.Transmission, nil
+	case <-abandoned:
+		return nil, ErrNotRunning
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+
+}
+
+// Background runs a task using the fetcher, such as discovering feeds, in the background.
+// The context of the task is cancelled when the service stops and the shutdown waits for the task to return.
+func (z *Service) Background(task func(ctx context.Context)) error {
+
+	z.Lock()
+	running := z.running
+	stopping := z.stopping
+	parent := z.ctx
+	if running {
+		z.senders.Add(1)
+	}
+	z.Unlock()
+	if !running {
+		return ErrNotRunning
+	}
+
+	ctx, cancel := context.WithCancel(parent)
+	go func() {
+		select {
+		case <-stopping:
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+
+	go func() {
+		defer z.senders.Done()
+		defer cancel()
+		task(ctx)
+	}()
+
+	return nil
+
+}
+
+// Preview fetches the feed and returns the harvest without passing it on to be reaped.
+func (z *Service) Preview(ctx context.Context, feed *model.Feed) (*model.Harvest, error) {
+	return z.preview(ctx, feed, false)
+}
+
+// Validate fetches the feed unconditionally and parses it in diagnostic mode,
+// returning the harvest and its warnings without passing it on to be reaped. The given feed is not modified.
+func (z *Service) Validate(ctx context.Context, feed *model.Feed) (*model.Harvest, error) {
+	probe := *feed
+	probe.ETag = ""
+	probe.LastModified = time.Time{}
+	probe.BodyHash = ""
+	return z.preview(ctx, &probe, true)
+}
+
+func (z *Service) preview(ctx context.Context, feed *model.Feed, diagnose bool) (*model.Harvest, error) {
+
+	if !z.IsRunning() {
+		return nil, ErrNotRunning
+	}
+
+	result := make(chan *model.Harvest, 1)
+	go func() {
+		result <- z.fetchFeed(feed, diagnose)
+	}()
+
+	select {
+	case harvest := <-result:
+		return harvest, nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+
+}
+
+// Push parses content delivered by a WebSub hub and passes it on to be reaped like a fetched feed.
+// Content which cannot be parsed is rejected without affecting the status of the feed.
+func (z *Service) Push(feed *model.Feed, body []byte) error {
+
+	z.Lock()
+	running := z.running
+	output := z.output
+	if running {
+		z.senders.Add(1)
 	}
+	z.Unlock()
+	if !running {
+		return ErrNotRunning
+	}
+	defer z.senders.Done()
 
 	startTime := time.Now().UTC().Truncate(time.Millisecond)
-	now := startTime.Truncate(time.Second)
 
+	xmlFeed, err := z.parseFeed(feed, bytes.NewReader(body), feed.URL, "", false)
+	if err != nil {
+		return err
+	}
+
+	harvest := &model.Harvest{
+		Feed:   feed,
+		Pushed: true,
+	}
 	harvest.Transmission = model.T.New(feed.ID)
 	harvest.Transmission.URL = feed.URL
-	harvest.Transmission.StartTime = now
+	harvest.Transmission.StartTime = startTime.Truncate(time.Second)
+	harvest.Transmission.Pushed = true
+	harvest.Transmission.Result = model.FetchResultOK
+	processFeedOKAndParse(harvest, len(body), xmlFeed)
+	finishFeed(harvest, startTime)
 
-	rsp, err := z.client.Do(z.newRequest(feed))
-	if err != nil && (rsp == nil || rsp.StatusCode != http.StatusMovedPermanently) {
-		processFeedClientError(harvest, err)
-	} else {
+	if !z.send(output, harvest) {
+		return ErrNotRunning // abandoned on shutdown, the hub will deliver again
+	}
+
+	return nil
+
+}
 
-		harvest.Transmission.StatusCode = rsp.StatusCode
+func (z *Service) processFeed(feed *model.Feed, id int) {
+	z.setActivity(id, model.WorkerFetching, feed)
+	harvest := z.fetchFeed(feed, false)
+	z.setActivity(id, model.WorkerWaiting, feed)
+	if z.abandoned() || !z.send(z.output, harvest) {
+		log.Debugf("fetcher %2d abandoned %s", id, feed.URL)
+	}
+	z.setActivity(id, model.WorkerIdle, nil)
+}
 
-		switch {
+func (z *Service) fetchFeed(feed *model.Feed, diagnose bool) *model.Harvest {
 
-		case rsp.StatusCode == http.StatusMovedPermanently:
-			processFeedMovedPermanently(harvest, rsp)
+	harvest := &model.Harvest{
+		Feed:     feed,
+		Diagnose: diagnose,
+	}
 
-		case rsp.StatusCode == http.StatusOK:
-			reader, _ := readBody(rsp)
-			body := &ReadCounter{ReadCloser: reader}
-			p := feedparser.NewParser()
-			xmlFeed, err := p.Parse(body)
+	startTime := time.Now().UTC().Truncate(time.Millisecond)
 
-			processFeedOK(harvest, rsp)
-			if err != nil || xmlFeed == nil {
-				processFeedOKButCannotParse(harvest, err)
-			} else {
-				processFeedOKAndParse(harvest, body.Size, xmlFeed)
-			}
+	harvest.Transmission = model.T.New(feed.ID)
+	harvest.Transmission.URL = feed.URL
+	harvest.Transmission.StartTime = startTime.Truncate(time.Second)
 
-		case rsp.StatusCode == http.StatusNotModified:
-			processFeedNotModified(harvest, rsp)
+	if source, err := z.sourceFor(feed.URL); err == nil {
+		source.Fetch(harvest)
+	} else {
+		processFeedClientError(harvest, err)
+	}
+
+	finishFeed(harvest, startTime)
+
+	return harvest
 
-		case rsp.StatusCode >= 400:
-			processFeedServerError(harvest, rsp)
+}
 
-		case true:
-			log.Debugf("Uncaught Status Code: %d", rsp.StatusCode)
+// parseFeed parses the body as a feed or, if the feed has scrape rules, as a web page.
+// The content type distinguishes JSON feeds, it may be empty. Scraped pages are not diagnosed.
+func (z *Service) parseFeed(feed *model.Feed, body io.Reader, base, contentType string, diagnose bool) (*feedparser.Feed, error) {
+	if feed.Scrape != nil {
+		s, err := scraper.New(feed.Scrape)
+		if err != nil {
+			return nil, err
+		}
+		s.Location = feed.DateLocation()
+		return s.Scrape(body, base)
+	}
+	parser := feedparser.NewParser()
+	parser.MaxDepth = z.maxDepth
+	parser.MaxEntries = z.maxEntries
+	parser.Location = feed.DateLocation()
+	parser.Diagnose = diagnose
+	return parser.ParseContentType(body, contentType)
+}
 
-		} // switch
+func finishFeed(harvest *model.Harvest, startTime time.Time) {
 
-	} // not err
+	feed := harvest.Feed
 
 	harvest.Transmission.Duration = time.Now().Truncate(time.Millisecond).Sub(startTime)
 	if feed.StatusSince.IsZero() || feed.Status != harvest.Transmission.Result {
@@ -184,10 +575,60 @@ func (z *Service) processFeed(feed *model.Feed, id int) {
 	feed.Status = harvest.Transmission.Result
 	feed.StatusMessage = harvest.Transmission.ResultMessage
 
-	z.output <- harvest
+}
+
+// clientFor returns the client for the proxy and TLS settings of the given feed.
+// The returned URL is the configured proxy, nil if the proxy is taken from the environment.
+func (z *Service) clientFor(feed *model.Feed) (*http.Client, *url.URL, error) {
+
+	proxyURL := z.proxyURL
+	if feed.Proxy != "" {
+		u, err := parseProxyURL(feed.Proxy)
+		if err != nil {
+			return nil, nil, err
+		}
+		proxyURL = u
+	}
+
+	key := clientKey{insecure: feed.Insecure}
+	if proxyURL != nil {
+		key.proxy = proxyURL.String()
+	}
+
+	z.clientsLock.Lock()
+	defer z.clientsLock.Unlock()
+
+	client, ok := z.clients[key]
+	if !ok {
+		tlsConfig := z.tlsConfig.Clone()
+		tlsConfig.InsecureSkipVerify = feed.Insecure
+		client = newInternalClient(z.timeoutSeconds, proxyURL, tlsConfig)
+		z.clients[key] = client
+	}
+
+	return client, proxyURL, nil
 
 }
 
+func processFeedTLS(harvest *model.Harvest, state *tls.ConnectionState) {
+	if state == nil {
+		return
+	}
+	harvest.Transmission.TLSVersion = tls.VersionName(state.Version)
+	harvest.Transmission.TLSCipherSuite = tls.CipherSuiteName(state.CipherSuite)
+	if len(state.PeerCertificates) > 0 {
+		cert := state.PeerCertificates[0]
+		harvest.Transmission.TLSSubject = cert.Subject.String()
+		harvest.Transmission.TLSIssuer = cert.Issuer.String()
+	}
+}
+
+// processFeedSchedulingHeaders records the earliest time the server wants to be fetched again.
+func processFeedSchedulingHeaders(harvest *model.Harvest, rsp *http.Response, now time.Time) {
+	harvest.RetryAfter = parseRetryAfter(rsp.Header.Get(hRetryAfter), now)
+	harvest.FreshUntil = parseFreshness(rsp.Header, now)
+}
+
 func processFeedOK(harvest *model.Harvest, rsp *http.Response) {
 	harvest.Transmission.Result = model.FetchResultOK
 	harvest.Transmission.ContentType = rsp.Header.Get(hContentType)
@@ -199,8 +640,31 @@ func processFeedOK(harvest *model.Harvest, rsp *http.Response) {
 }
 
 func processFeedOKButCannotParse(harvest *model.Harvest, err error) {
-	harvest.Transmission.Result = model.FetchResultFeedError
-	harvest.Transmission.ResultMessage = err.Error()
+	switch err {
+	case ErrTooLarge:
+		harvest.Transmission.Result = model.FetchResultTooLarge
+	case ErrBomb:
+		harvest.Transmission.Result = model.FetchResultBomb
+	case ErrNotFeed, feedparser.ErrJSONFeedVersion:
+		harvest.Transmission.Result = model.FetchResultNotFeed
+	case feedparser.ErrTooDeep:
+		harvest.Transmission.Result = model.FetchResultTooDeep
+	case feedparser.ErrTooManyEntries:
+		harvest.Transmission.Result = model.FetchResultTooMany
+	default:
+		harvest.Transmission.Result = model.FetchResultFeedError
+	}
+	if err != nil {
+		harvest.Transmission.ResultMessage = err.Error()
+	}
+	harvest.Feed.BodyHash = ""
+}
+
+// processFeedUnchanged treats a body identical to the last one parsed like a 304 response:
+// the server ignored or does not support the conditional request.
+func processFeedUnchanged(harvest *model.Harvest, size int) {
+	harvest.Transmission.ContentLength = size
+	harvest.Transmission.Unchanged = true
 }
 
 func processFeedOKAndParse(harvest *model.Harvest, size int, xmlFeed *feedparser.Feed) {
@@ -208,8 +672,24 @@ func processFeedOKAndParse(harvest *model.Harvest, size int, xmlFeed *feedparser
 	harvest.Transmission.Flavor = xmlFeed.Flavor
 	harvest.Transmission.Generator = xmlFeed.Generator
 	harvest.Transmission.Title = xmlFeed.Title
+	harvest.Transmission.Published = xmlFeed.Published
+	harvest.Transmission.BadDates = xmlFeed.BadDates
+	harvest.Warnings = xmlFeed.Warnings
 	harvest.Feed.Title = xmlFeed.Title
 	harvest.Feed.SiteURL = xmlFeed.LinkAlternate
+	harvest.Feed.SelfURL = xmlFeed.LinkSelf
+	harvest.Feed.Icon = xmlFeed.Icon
+	harvest.Feed.Language = xmlFeed.Language
+	harvest.Feed.Categories = xmlFeed.Categories
+	harvest.Feed.Hints = &model.PublisherHints{
+		TTL:          xmlFeed.TTL,
+		UpdatePeriod: xmlFeed.UpdatePeriod,
+		SkipHours:    xmlFeed.SkipHours,
+		SkipDays:     xmlFeed.SkipDays,
+	}
+	if harvest.Feed.Hints.IsEmpty() {
+		harvest.Feed.Hints = nil
+	}
 
 	// convert Items to Items
 	for _, xmlEntry := range xmlFeed.Entries {
@@ -217,6 +697,7 @@ func processFeedOKAndParse(harvest *model.Harvest, size int, xmlFeed *feedparser
 		item.Created = xmlEntry.Created
 		item.Updated = xmlEntry.Updated
 		item.Title = xmlEntry.Title
+		item.Categories = xmlEntry.Categories
 		item.URL = xmlEntry.LinkAlternate
 		if len(xmlEntry.Authors) > 0 {
 			item.Author = xmlEntry.Authors[0]
@@ -226,8 +707,44 @@ func processFeedOKAndParse(harvest *model.Harvest, size int, xmlFeed *feedparser
 		} else {
 			item.Content = xmlEntry.Summary
 		}
+		item.Episode = xmlEntry.Episode
+		item.Image = xmlEntry.Image
+		for _, xmlEnclosure := range xmlEntry.Enclosures {
+			item.Enclosures = append(item.Enclosures, &model.Enclosure{
+				URL:      xmlEnclosure.URL,
+				Type:     xmlEnclosure.Type,
+				Length:   xmlEnclosure.Length,
+				Duration: xmlEnclosure.Duration,
+			})
+		}
+	}
+
+}
+
+// processFeedHub records the WebSub hub of the feed, the topic being the self link of the feed if present.
+func processFeedHub(harvest *model.Harvest, xmlFeed *feedparser.Feed) {
+	if harvest.Feed.Scrape != nil {
+		return
+	}
+	topic := xmlFeed.LinkSelf
+	if topic == "" {
+		topic = harvest.Feed.URL
 	}
+	harvest.Feed.SetHub(xmlFeed.LinkHub, topic)
+}
 
+// processFeedOlder records the page with older items: the prev-archive link of an archived feed
+// or the next link of a paged feed (RFC 5005), resolved against the URL of the document.
+func processFeedOlder(harvest *model.Harvest, xmlFeed *feedparser.Feed, base string) {
+	if harvest.Feed.Scrape != nil {
+		return
+	}
+	for _, rel := range []string{"prev-archive", "next"} {
+		if href := xmlFeed.Links[rel]; href != "" {
+			harvest.Older = resolveURL(base, href)
+			return
+		}
+	}
 }
 
 func processFeedClientError(harvest *model.Harvest, err error) {
@@ -239,6 +756,13 @@ func processFeedServerError(harvest *model.Harvest, rsp *http.Response) {
 	harvest.Transmission.Result = model.FetchResultServerError
 }
 
+func processFeedRateLimited(harvest *model.Harvest, rsp *http.Response) {
+	harvest.Transmission.Result = model.FetchResultRateLimited
+	if !harvest.RetryAfter.IsZero() {
+		harvest.Transmission.ResultMessage = fmt.Sprintf("retry after %s", harvest.RetryAfter.UTC().Format(time.RFC3339))
+	}
+}
+
 func processFeedMovedPermanently(harvest *model.Harvest, rsp *http.Response) {
 	harvest.Transmission.Result = model.FetchResultRedirect
 	newURL := rsp.Header.Get(hLocation)
@@ -254,6 +778,7 @@ func processFeedNotModified(harvest *model.Harvest, rsp *http.Response) {
 
 func (z *Service) newRequest(feed *model.Feed) *http.Request {
 	req, _ := http.NewRequest(mGET, feed.URL, nil)
+	req = req.WithContext(z.ctx)
 	req.Header.Set(hUserAgent, z.userAgent)
 	req.Header.Set(hAcceptEncoding, "gzip")
 	if !feed.LastModified.IsZero() {
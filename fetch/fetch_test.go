@@ -1,10 +1,19 @@
 package fetch
 
 import (
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
 	"net/url"
+	"strings"
+	"sync/atomic"
 	"testing"
+	"time"
 
 	"github.com/kwo/rakewire/model"
+	"golang.org/x/net/context"
 )
 
 func TestInterfaceService(t *testing.T) {
@@ -61,3 +70,624 @@ func TestURL2(t *testing.T) {
 	}
 
 }
+
+func TestRetryAfter(t *testing.T) {
+
+	t.Parallel()
+
+	now := time.Date(2016, time.March, 1, 10, 0, 0, 0, time.UTC)
+
+	if value := parseRetryAfter("120", now); !value.Equal(now.Add(2 * time.Minute)) {
+		t.Errorf("Bad retry after seconds: %s", value)
+	}
+
+	if value := parseRetryAfter("Tue, 01 Mar 2016 11:00:00 GMT", now); !value.Equal(now.Add(time.Hour)) {
+		t.Errorf("Bad retry after date: %s", value)
+	}
+
+	for _, header := range []string{"", "-1", "soon"} {
+		if value := parseRetryAfter(header, now); !value.IsZero() {
+			t.Errorf("Expected zero retry after for %q: %s", header, value)
+		}
+	}
+
+}
+
+func TestFreshness(t *testing.T) {
+
+	t.Parallel()
+
+	now := time.Date(2016, time.March, 1, 10, 0, 0, 0, time.UTC)
+
+	header := http.Header{}
+	header.Set(hCacheControl, "public, max-age=600")
+	header.Set(hAge, "60")
+	header.Set(hExpires, "Tue, 01 Mar 2016 12:00:00 GMT")
+	if value := parseFreshness(header, now); !value.Equal(now.Add(9 * time.Minute)) {
+		t.Errorf("Bad max-age freshness: %s", value)
+	}
+
+	header = http.Header{}
+	header.Set(hDate, "Tue, 01 Mar 2016 09:00:00 GMT") // server clock one hour behind
+	header.Set(hExpires, "Tue, 01 Mar 2016 09:30:00 GMT")
+	if value := parseFreshness(header, now); !value.Equal(now.Add(30 * time.Minute)) {
+		t.Errorf("Bad expires freshness: %s", value)
+	}
+
+	header = http.Header{}
+	header.Set(hCacheControl, "no-cache")
+	header.Set(hExpires, "Tue, 01 Mar 2016 12:00:00 GMT")
+	if value := parseFreshness(header, now); !value.IsZero() {
+		t.Errorf("Expected zero freshness for no-cache: %s", value)
+	}
+
+}
+
+func TestRateLimited(t *testing.T) {
+
+	t.Parallel()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set(hRetryAfter, "3600")
+		w.WriteHeader(http.StatusTooManyRequests)
+	}))
+	defer server.Close()
+
+	z := newTestService(t, &Configuration{TimeoutSeconds: 5})
+	harvest := z.testFetch(model.F.New(server.URL))
+
+	if harvest.Transmission.Result != model.FetchResultRateLimited {
+		t.Errorf("Bad result, expected %s, actual %s", model.FetchResultRateLimited, harvest.Transmission.Result)
+	}
+	if harvest.NotBefore().Before(time.Now().Add(59 * time.Minute)) {
+		t.Errorf("Bad not before time: %s", harvest.NotBefore())
+	}
+
+}
+
+func TestRefresh(t *testing.T) {
+
+	t.Parallel()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set(hContentType, "application/atom+xml")
+		w.Write([]byte(testFeed))
+	}))
+	defer server.Close()
+
+	z := NewService(&Configuration{TimeoutSeconds: 5}, nil, make(chan *model.Harvest))
+	feed := model.F.New(server.URL)
+
+	if _, err := z.Refresh(context.Background(), feed); err != ErrNotRunning {
+		t.Errorf("Expected not running error, actual: %v", err)
+	}
+
+	if err := z.Start(); err != nil {
+		t.Fatalf("Cannot start service: %s", err.Error())
+	}
+
+	// reaper
+	go func() {
+		harvest := <-z.output
+		harvest.Transmission.NewItems = len(harvest.Items)
+		close(harvest.Reaped)
+	}()
+
+	transmission, err := z.Refresh(context.Background(), feed)
+	if err != nil {
+		t.Fatalf("Cannot refresh feed: %s", err.Error())
+	}
+	if transmission.Result != model.FetchResultOK {
+		t.Errorf("Bad result: %s %s", transmission.Result, transmission.ResultMessage)
+	}
+	if transmission.NewItems != 1 {
+		t.Errorf("Bad new item count, expected %d, actual %d", 1, transmission.NewItems)
+	}
+
+	// context expires before the harvest is reaped
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	go func() {
+		<-z.output
+	}()
+	if _, err := z.Refresh(ctx, feed); err != context.DeadlineExceeded {
+		t.Errorf("Expected deadline exceeded, actual: %v", err)
+	}
+
+}
+
+func TestBackground(t *testing.T) {
+
+	t.Parallel()
+
+	z := NewService(&Configuration{TimeoutSeconds: 5}, nil, make(chan *model.Harvest))
+	if err := z.Background(func(ctx context.Context) {}); err != ErrNotRunning {
+		t.Errorf("Expected not running error, actual: %v", err)
+	}
+
+	if err := z.Start(); err != nil {
+		t.Fatalf("Cannot start service: %s", err.Error())
+	}
+
+	started := make(chan struct{})
+	var done int32
+	if err := z.Background(func(ctx context.Context) {
+		close(started)
+		<-ctx.Done()
+		time.Sleep(50 * time.Millisecond)
+		atomic.StoreInt32(&done, 1)
+	}); err != nil {
+		t.Fatalf("Cannot run task: %s", err.Error())
+	}
+
+	<-started
+	z.Stop()
+	if atomic.LoadInt32(&done) != 1 {
+		t.Error("Stopped before the task returned")
+	}
+
+}
+
+func TestScrape(t *testing.T) {
+
+	t.Parallel()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set(hContentType, "text/html")
+		w.Write([]byte(`<html><head><title>Blog</title></head><body><article><a href="/posts/1">Post 1</a></article><article><a href="/posts/2">Post 2</a></article></body></html>`))
+	}))
+	defer server.Close()
+
+	z := newTestService(t, &Configuration{TimeoutSeconds: 5})
+
+	feed := model.F.New(server.URL)
+	feed.Scrape = &model.ScrapeRules{Item: "article"}
+	harvest, err := z.Preview(context.Background(), feed)
+	if err != nil {
+		t.Fatalf("Cannot preview feed: %s", err.Error())
+	}
+
+	if harvest.Transmission.Result != model.FetchResultOK {
+		t.Errorf("Bad result: %s %s", harvest.Transmission.Result, harvest.Transmission.ResultMessage)
+	}
+	if harvest.Transmission.Flavor != "scrape" {
+		t.Errorf("Bad flavor: %s", harvest.Transmission.Flavor)
+	}
+	if len(harvest.Items) != 2 {
+		t.Fatalf("Bad item count, expected %d, actual %d", 2, len(harvest.Items))
+	}
+	if harvest.Items[1].GUID != server.URL+"/posts/2" || harvest.Items[1].Title != "Post 2" {
+		t.Errorf("Bad item: %s %s", harvest.Items[1].GUID, harvest.Items[1].Title)
+	}
+
+	// without rules, the page is not a feed
+	harvest, _ = z.Preview(context.Background(), model.F.New(server.URL))
+	if harvest.Transmission.Result != model.FetchResultNotFeed {
+		t.Errorf("Expected not a feed, actual: %s", harvest.Transmission.Result)
+	}
+
+}
+
+func TestJSONFeed(t *testing.T) {
+
+	t.Parallel()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/feed.json":
+			w.Header().Set(hContentType, "application/feed+json")
+			w.Write([]byte(`{"version": "https://jsonfeed.org/version/1.1", "title": "JSON", "language": "en", "items": [{"id": "1", "content_html": "<p>Hello</p>", "date_published": "2020-02-01T10:00:00Z"}]}`))
+		case "/data.json":
+			w.Header().Set(hContentType, "application/json")
+			w.Write([]byte(`{"data": []}`))
+		}
+	}))
+	defer server.Close()
+
+	z := newTestService(t, &Configuration{TimeoutSeconds: 5})
+
+	harvest, err := z.Preview(context.Background(), model.F.New(server.URL+"/feed.json"))
+	if err != nil {
+		t.Fatalf("Cannot preview feed: %s", err.Error())
+	}
+	if harvest.Transmission.Result != model.FetchResultOK || harvest.Transmission.Flavor != "json1.1" {
+		t.Errorf("Bad result: %s %s, flavor: %s", harvest.Transmission.Result, harvest.Transmission.ResultMessage, harvest.Transmission.Flavor)
+	}
+	if len(harvest.Items) != 1 || harvest.Items[0].Content != "<p>Hello</p>" {
+		t.Errorf("Bad items: %v", harvest.Items)
+	}
+	if harvest.Feed.Language != "en" || harvest.Feed.Hints != nil {
+		t.Errorf("Bad feed metadata: %s %v", harvest.Feed.Language, harvest.Feed.Hints)
+	}
+
+	harvest, _ = z.Preview(context.Background(), model.F.New(server.URL+"/data.json"))
+	if harvest.Transmission.Result != model.FetchResultNotFeed {
+		t.Errorf("Expected not a feed, actual: %s", harvest.Transmission.Result)
+	}
+
+}
+
+func TestValidate(t *testing.T) {
+
+	t.Parallel()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get(hIfNoneMatch) != "" {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		w.Header().Set(hContentType, "application/rss+xml")
+		w.Write([]byte(`<rss version="2.0"><channel><title>Validate</title><item><title>no id</title><category>Go</category></item></channel></rss>`))
+	}))
+	defer server.Close()
+
+	z := newTestService(t, &Configuration{TimeoutSeconds: 5})
+
+	feed := model.F.New(server.URL)
+	feed.ETag = "etag"
+	feed.Status = model.FetchResultServerError
+
+	harvest, err := z.Validate(context.Background(), feed)
+	if err != nil {
+		t.Fatalf("Cannot validate feed: %s", err.Error())
+	}
+	if harvest.Transmission.Result != model.FetchResultOK || len(harvest.Items) != 1 {
+		t.Errorf("Bad result: %s %s, items: %d", harvest.Transmission.Result, harvest.Transmission.ResultMessage, len(harvest.Items))
+	}
+	if len(harvest.Warnings) != 1 {
+		t.Errorf("Bad warnings: %v", harvest.Warnings)
+	}
+	if len(harvest.Items) == 1 && strings.Join(harvest.Items[0].Categories, ",") != "Go" {
+		t.Errorf("Bad categories: %v", harvest.Items[0].Categories)
+	}
+	if feed.ETag != "etag" || feed.Status != model.FetchResultServerError {
+		t.Errorf("Feed modified: %s %s", feed.ETag, feed.Status)
+	}
+
+	// no diagnostics on preview
+	if harvest, _ = z.Preview(context.Background(), model.F.New(server.URL)); len(harvest.Warnings) != 0 {
+		t.Errorf("Unexpected warnings: %v", harvest.Warnings)
+	}
+
+}
+
+func TestActivity(t *testing.T) {
+
+	t.Parallel()
+
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-release
+		w.Header().Set(hContentType, "application/atom+xml")
+		w.Write([]byte(testFeed))
+	}))
+	defer server.Close()
+
+	input := make(chan *model.Feed)
+	output := make(chan *model.Harvest)
+	z := NewService(&Configuration{TimeoutSeconds: 5, Workers: 1}, input, output)
+	if err := z.Start(); err != nil {
+		t.Fatalf("Cannot start service: %s", err.Error())
+	}
+
+	waitFor := func(state string) *model.FetchActivity {
+		for i := 0; i < 100; i++ {
+			if activity := z.Activity(); activity.Workers[0].State == state {
+				return activity
+			}
+			time.Sleep(10 * time.Millisecond)
+		}
+		t.Fatalf("Worker not %s", state)
+		return nil
+	}
+
+	if activity := z.Activity(); len(activity.Workers) != 1 || !activity.Running {
+		t.Fatalf("Bad activity: %v", activity)
+	}
+	waitFor(model.WorkerIdle)
+
+	feed := model.F.New(server.URL)
+	input <- feed
+	if activity := waitFor(model.WorkerFetching); activity.Workers[0].URL != server.URL {
+		t.Errorf("Bad worker URL: %s", activity.Workers[0].URL)
+	}
+
+	close(release)
+	if activity := waitFor(model.WorkerWaiting); activity.Queued != 1 {
+		t.Errorf("Bad queue depth, expected %d, actual %d", 1, activity.Queued)
+	}
+
+	<-output
+	if activity := waitFor(model.WorkerIdle); activity.Queued != 0 || activity.Workers[0].FeedID != "" {
+		t.Errorf("Bad idle activity, queued: %d, feed: %s", activity.Queued, activity.Workers[0].FeedID)
+	}
+
+	close(input)
+	z.Stop()
+
+}
+
+func TestLimits(t *testing.T) {
+
+	t.Parallel()
+
+	var compressed bytes.Buffer
+	gz := gzip.NewWriter(&compressed)
+	gz.Write(bytes.Repeat([]byte(" "), 1024*1024))
+	gz.Close()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/large":
+			w.Write(bytes.Repeat([]byte(" "), 8*1024))
+		case "/bomb":
+			w.Header().Set(hContentEncoding, "gzip")
+			w.Write(compressed.Bytes())
+		case "/html":
+			w.Header().Set(hContentType, "text/html")
+			w.Write([]byte("<!DOCTYPE html><html><body>Hello</body></html>"))
+		case "/image":
+			w.Header().Set(hContentType, "image/png")
+			w.Write([]byte(testFeed))
+		case "/deep":
+			w.Write([]byte(`<feed xmlns="http://www.w3.org/2005/Atom"><x><x><x><x></x></x></x></x></feed>`))
+		case "/many":
+			w.Write([]byte(`<feed xmlns="http://www.w3.org/2005/Atom"><entry><id>1</id></entry><entry><id>2</id></entry><entry><id>3</id></entry></feed>`))
+		default:
+			w.Write([]byte(testFeed))
+		}
+	}))
+	defer server.Close()
+
+	z := newTestService(t, &Configuration{TimeoutSeconds: 5, MaxSizeKB: 4, MaxDecompressedKB: 64, MaxDepth: 4, MaxEntries: 2})
+
+	results := map[string]string{
+		"/feed":  model.FetchResultOK,
+		"/large": model.FetchResultTooLarge,
+		"/bomb":  model.FetchResultBomb,
+		"/html":  model.FetchResultNotFeed,
+		"/image": model.FetchResultNotFeed,
+		"/deep":  model.FetchResultTooDeep,
+		"/many":  model.FetchResultTooMany,
+	}
+	for path, expected := range results {
+		harvest := z.testFetch(model.F.New(server.URL + path))
+		if harvest.Transmission.Result != expected {
+			t.Errorf("Bad result for %s, expected %s, actual %s %s", path, expected, harvest.Transmission.Result, harvest.Transmission.ResultMessage)
+		}
+		if expected != model.FetchResultOK && harvest.Transmission.ResultMessage == "" {
+			t.Errorf("Missing result message for %s", path)
+		}
+	}
+
+}
+
+func TestUnchangedBody(t *testing.T) {
+
+	t.Parallel()
+
+	// server ignores conditional requests
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set(hContentType, "application/atom+xml")
+		w.Write([]byte(testFeed))
+	}))
+	defer server.Close()
+
+	z := newTestService(t, &Configuration{TimeoutSeconds: 5})
+	feed := model.F.New(server.URL)
+
+	harvest := z.testFetch(feed)
+	if harvest.Transmission.Unchanged || len(harvest.Items) != 1 {
+		t.Fatalf("Expected first fetch to be parsed, unchanged: %t, items: %d", harvest.Transmission.Unchanged, len(harvest.Items))
+	}
+	if feed.BodyHash == "" {
+		t.Fatal("Body hash not recorded")
+	}
+
+	harvest = z.testFetch(feed)
+	if harvest.Transmission.Result != model.FetchResultOK {
+		t.Errorf("Bad result: %s %s", harvest.Transmission.Result, harvest.Transmission.ResultMessage)
+	}
+	if !harvest.Transmission.Unchanged {
+		t.Error("Expected identical body to be recognized")
+	}
+	if len(harvest.Items) != 0 || harvest.Transmission.Flavor != "" {
+		t.Errorf("Expected identical body not to be parsed, items: %d", len(harvest.Items))
+	}
+
+	feed.BodyHash = "0"
+	if harvest = z.testFetch(feed); harvest.Transmission.Unchanged || len(harvest.Items) != 1 {
+		t.Errorf("Expected changed body to be parsed, unchanged: %t, items: %d", harvest.Transmission.Unchanged, len(harvest.Items))
+	}
+
+}
+
+func TestPush(t *testing.T) {
+
+	t.Parallel()
+
+	hubFeed := strings.Replace(testFeed, "<title>Test Feed</title>", `<title>Test Feed</title>
+	<link rel="hub" href="https://hub.example.com/"/>
+	<link rel="self" href="http://example.com/feed"/>`, 1)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set(hContentType, "application/atom+xml")
+		w.Write([]byte(hubFeed))
+	}))
+	defer server.Close()
+
+	z := NewService(&Configuration{TimeoutSeconds: 5}, nil, make(chan *model.Harvest, 1))
+	feed := model.F.New(server.URL)
+
+	if err := z.Push(feed, []byte(hubFeed)); err != ErrNotRunning {
+		t.Errorf("Expected not running error, actual: %v", err)
+	}
+
+	if err := z.Start(); err != nil {
+		t.Fatalf("Cannot start service: %s", err.Error())
+	}
+
+	// fetching records the hub
+	harvest := z.fetchFeed(feed, false)
+	if push := harvest.Feed.Push; push == nil {
+		t.Fatal("Missing push subscription")
+	} else if push.Hub != "https://hub.example.com/" || push.Topic != "http://example.com/feed" {
+		t.Errorf("Bad push subscription: %s %s", push.Hub, push.Topic)
+	} else if len(push.Secret) == 0 || push.Next.IsZero() {
+		t.Error("Subscription not scheduled")
+	}
+
+	if err := z.Push(feed, []byte("not a feed")); err == nil {
+		t.Error("Expected error pushing bad content")
+	}
+
+	if err := z.Push(feed, []byte(hubFeed)); err != nil {
+		t.Fatalf("Cannot push content: %s", err.Error())
+	}
+	harvest = <-z.output
+	if !harvest.Pushed || !harvest.Transmission.Pushed {
+		t.Error("Harvest not marked as pushed")
+	}
+	if harvest.Transmission.Result != model.FetchResultOK {
+		t.Errorf("Bad result: %s", harvest.Transmission.Result)
+	}
+	if len(harvest.Items) != 1 {
+		t.Errorf("Bad item count, expected %d, actual %d", 1, len(harvest.Items))
+	}
+
+}
+
+func TestShutdownUnderLoad(t *testing.T) {
+
+	t.Parallel()
+
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/stuck" {
+			select {
+			case <-release:
+			case <-r.Context().Done():
+			}
+		} else {
+			time.Sleep(20 * time.Millisecond)
+		}
+		w.Header().Set(hContentType, "application/atom+xml")
+		w.Write([]byte(testFeed))
+	}))
+	defer server.Close()
+	defer close(release)
+
+	input := make(chan *model.Feed, 50)
+	output := make(chan *model.Harvest)
+	z := NewService(&Configuration{TimeoutSeconds: 10, Workers: 4}, input, output)
+	if err := z.Start(); err != nil {
+		t.Fatalf("Cannot start service: %s", err.Error())
+	}
+
+	input <- model.F.New(server.URL + "/stuck")
+	for i := 0; i < 40; i++ {
+		input <- model.F.New(fmt.Sprintf("%s/feed%d.xml", server.URL, i))
+	}
+
+	// slow reaper
+	reaped := make(chan int)
+	go func() {
+		count := 0
+		for harvest := range output {
+			if harvest.Transmission.Result != model.FetchResultOK {
+				t.Errorf("Bad result: %s %s", harvest.Transmission.Result, harvest.Transmission.ResultMessage)
+			}
+			count++
+			time.Sleep(5 * time.Millisecond)
+		}
+		reaped <- count
+	}()
+
+	for i := 0; i < 100 && len(input) > 30; i++ {
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 500*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	if err := z.Shutdown(ctx); err != context.DeadlineExceeded {
+		t.Errorf("Expected deadline exceeded, actual %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > 2*time.Second {
+		t.Errorf("Shutdown took too long: %s", elapsed)
+	}
+
+	// completed fetches are reaped, the stuck fetch is abandoned, queued feeds are left for the next poll
+	count := <-reaped
+	if count == 0 || count >= 40 {
+		t.Errorf("Bad reaped count: %d", count)
+	}
+	if count+len(input) > 40 {
+		t.Errorf("Fetched feeds lost or duplicated, reaped: %d, queued: %d", count, len(input))
+	}
+
+	if z.IsRunning() {
+		t.Error("Service still running")
+	}
+	if _, err := z.Refresh(context.Background(), model.F.New(server.URL+"/feed.xml")); err != ErrNotRunning {
+		t.Errorf("Expected not running, actual %v", err)
+	}
+	z.Stop()
+
+}
+
+func TestShutdownDrain(t *testing.T) {
+
+	t.Parallel()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(50 * time.Millisecond)
+		w.Header().Set(hContentType, "application/atom+xml")
+		w.Write([]byte(testFeed))
+	}))
+	defer server.Close()
+
+	input := make(chan *model.Feed)
+	output := make(chan *model.Harvest)
+	z := NewService(&Configuration{TimeoutSeconds: 10, Workers: 2}, input, output)
+	if err := z.Start(); err != nil {
+		t.Fatalf("Cannot start service: %s", err.Error())
+	}
+
+	input <- model.F.New(server.URL + "/feed1.xml")
+	input <- model.F.New(server.URL + "/feed2.xml")
+
+	// refreshes in flight are drained too
+	refreshed := make(chan error)
+	go func() {
+		_, err := z.Refresh(context.Background(), model.F.New(server.URL+"/feed3.xml"))
+		refreshed <- err
+	}()
+	time.Sleep(20 * time.Millisecond)
+
+	shutdown := make(chan error)
+	go func() {
+		shutdown <- z.Shutdown(context.Background())
+	}()
+
+	count := 0
+	for harvest := range output {
+		if harvest.Transmission.Result != model.FetchResultOK {
+			t.Errorf("Bad result: %s", harvest.Transmission.Result)
+		}
+		if harvest.Reaped != nil {
+			close(harvest.Reaped)
+		}
+		count++
+	}
+	if count != 3 {
+		t.Errorf("Bad reaped count, expected %d, actual %d", 3, count)
+	}
+	if err := <-shutdown; err != nil {
+		t.Errorf("Cannot shutdown: %s", err.Error())
+	}
+	if err := <-refreshed; err != nil {
+		t.Errorf("Cannot refresh: %s", err.Error())
+	}
+
+}
@@ -12,8 +12,6 @@ type ReadCounter struct {
 
 func (z *ReadCounter) Read(p []byte) (int, error) {
 	n, err := z.ReadCloser.Read(p)
-	if err == nil {
-		z.Size += n
-	}
+	z.Size += n // bytes returned along with an error are valid too
 	return n, err
 }
@@ -0,0 +1,79 @@
+package fetch
+
+import (
+	"bytes"
+	"encoding/xml"
+	"mime"
+	"strings"
+
+	"golang.org/x/net/html/charset"
+)
+
+var (
+	// media types which are never feeds
+	notFeedTypes = []string{"image/", "audio/", "video/", "font/", "application/pdf", "application/zip", "application/javascript", "text/javascript", "text/css"}
+	utf8BOM      = []byte{0xef, 0xbb, 0xbf}
+)
+
+// sniffContentType rejects responses whose content type cannot be a feed, before the body is read.
+// Missing or unknown content types are accepted, as many servers label feeds incorrectly.
+func sniffContentType(contentType string) error {
+
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return nil
+	}
+
+	for _, prefix := range notFeedTypes {
+		if strings.HasPrefix(mediaType, prefix) {
+			return ErrNotFeed
+		}
+	}
+
+	return nil
+
+}
+
+// sniffBody rejects bodies which are neither XML nor JSON, or whose root element is an HTML document.
+func sniffBody(body []byte) error {
+
+	content := bytes.TrimLeft(bytes.TrimPrefix(body, utf8BOM), " \t\r\n")
+
+	// UTF-16 byte order marks, leave these to the parser
+	if bytes.HasPrefix(content, []byte{0xfe, 0xff}) || bytes.HasPrefix(content, []byte{0xff, 0xfe}) {
+		return nil
+	}
+
+	if len(content) > 0 && content[0] == '{' {
+		return nil // JSON feed, other JSON documents are rejected by the parser
+	}
+
+	if len(content) == 0 || content[0] != '<' {
+		return ErrNotFeed
+	}
+
+	decoder := xml.NewDecoder(bytes.NewReader(content))
+	decoder.CharsetReader = charset.NewReaderLabel
+	decoder.Strict = false
+	decoder.AutoClose = xml.HTMLAutoClose
+	decoder.Entity = xml.HTMLEntity
+
+	for {
+		token, err := decoder.Token()
+		if err != nil {
+			return nil // leave malformed documents to the parser
+		}
+		switch t := token.(type) {
+		case xml.Directive:
+			if fields := strings.Fields(strings.ToLower(string(t))); len(fields) > 1 && fields[0] == "doctype" && fields[1] == "html" {
+				return ErrNotFeed
+			}
+		case xml.StartElement:
+			if strings.ToLower(t.Name.Local) == "html" {
+				return ErrNotFeed
+			}
+			return nil
+		}
+	}
+
+}
@@ -0,0 +1,59 @@
+package fetch
+
+import (
+	"testing"
+)
+
+func TestSniffContentType(t *testing.T) {
+
+	t.Parallel()
+
+	accepted := []string{"", "application/rss+xml", "application/atom+xml; charset=utf-8", "text/xml", "text/html", "text/plain", "application/octet-stream", "invalid;;"}
+	for _, contentType := range accepted {
+		if err := sniffContentType(contentType); err != nil {
+			t.Errorf("Expected %q to be accepted", contentType)
+		}
+	}
+
+	rejected := []string{"image/png", "video/mp4", "application/pdf", "text/css", "audio/mpeg; codecs=mp3"}
+	for _, contentType := range rejected {
+		if err := sniffContentType(contentType); err != ErrNotFeed {
+			t.Errorf("Expected %q to be rejected", contentType)
+		}
+	}
+
+}
+
+func TestSniffBody(t *testing.T) {
+
+	t.Parallel()
+
+	accepted := []string{
+		`<?xml version="1.0"?><rss version="2.0"><channel/></rss>`,
+		"\xef\xbb\xbf\n  <feed xmlns=\"http://www.w3.org/2005/Atom\"/>",
+		`<!-- generated --><feed/>`,
+		`<rss><channel><title>unclosed`,
+		` {"version": "https://jsonfeed.org/version/1.1"}`,
+	}
+	for _, body := range accepted {
+		if err := sniffBody([]byte(body)); err != nil {
+			t.Errorf("Expected %q to be accepted", body)
+		}
+	}
+
+	rejected := []string{
+		``,
+		`   `,
+		`[{"version": "1"}]`,
+		`plain text`,
+		`<!DOCTYPE html><html><head><title>Page</title></head><body></body></html>`,
+		`<HTML><BODY>Not found</BODY></HTML>`,
+		"\x89PNG\r\n\x1a\n",
+	}
+	for _, body := range rejected {
+		if err := sniffBody([]byte(body)); err != ErrNotFeed {
+			t.Errorf("Expected %q to be rejected", body)
+		}
+	}
+
+}
@@ -0,0 +1,75 @@
+package fetch
+
+import (
+	"bytes"
+	"errors"
+	"net/url"
+	"strings"
+
+	"github.com/kwo/rakewire/model"
+)
+
+var (
+	// ErrUnsupportedScheme indicates that no source is configured for the scheme of a feed URL.
+	ErrUnsupportedScheme = errors.New("Unsupported URL scheme")
+)
+
+// FeedSource retrieves feeds and records the outcome on the harvest.
+// The source of a feed is selected by the scheme of its URL.
+type FeedSource interface {
+	Fetch(harvest *model.Harvest)
+}
+
+// IsLocal tests if the URL selects a local file or command rather than a network resource.
+func IsLocal(rawurl string) bool {
+	u, err := url.Parse(rawurl)
+	if err != nil {
+		return false
+	}
+	scheme := strings.ToLower(u.Scheme)
+	return scheme == "file" || scheme == "exec"
+}
+
+// sourceFor returns the source for the scheme of the given URL.
+func (z *Service) sourceFor(rawurl string) (FeedSource, error) {
+	u, err := url.Parse(rawurl)
+	if err != nil {
+		return nil, err
+	}
+	if source, ok := z.sources[strings.ToLower(u.Scheme)]; ok {
+		return source, nil
+	}
+	return nil, ErrUnsupportedScheme
+}
+
+// processBody parses the retrieved content of a feed unless it is identical to the content parsed last time.
+func (z *Service) processBody(harvest *model.Harvest, body []byte, base string) {
+
+	feed := harvest.Feed
+	harvest.Transmission.Result = model.FetchResultOK
+
+	if feed.Scrape == nil {
+		if err := sniffBody(body); err != nil {
+			processFeedOKButCannotParse(harvest, err)
+			return
+		}
+	}
+
+	bodyHash := hashBody(body)
+	if bodyHash == feed.BodyHash {
+		processFeedUnchanged(harvest, len(body))
+		return
+	}
+
+	xmlFeed, err := z.parseFeed(feed, bytes.NewReader(body), base, harvest.Transmission.ContentType, harvest.Diagnose)
+	if err != nil || xmlFeed == nil {
+		processFeedOKButCannotParse(harvest, err)
+		return
+	}
+
+	processFeedOKAndParse(harvest, len(body), xmlFeed)
+	processFeedHub(harvest, xmlFeed)
+	processFeedOlder(harvest, xmlFeed, base)
+	feed.BodyHash = bodyHash
+
+}
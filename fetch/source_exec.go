@@ -0,0 +1,87 @@
+package fetch
+
+import (
+	"bytes"
+	"fmt"
+	"net/url"
+	"os/exec"
+	"strings"
+	"time"
+
+	"github.com/kwo/rakewire/model"
+	"golang.org/x/net/context"
+)
+
+const (
+	maxStderrSize = 512 // bytes of the error output kept in the result message
+)
+
+// execSource runs configured commands and reads the feed from their standard output.
+// Feed URLs name the command, for example exec://report, arbitrary commands cannot be run.
+type execSource struct {
+	service  *Service
+	commands map[string]string // command lines by name
+}
+
+func (z *execSource) Fetch(harvest *model.Harvest) {
+
+	u, err := url.Parse(harvest.Feed.URL)
+	if err != nil {
+		processFeedClientError(harvest, err)
+		return
+	}
+	name := u.Host
+	if name == "" {
+		name = u.Opaque
+	}
+
+	args := strings.Fields(z.commands[name])
+	if len(args) == 0 {
+		processFeedClientError(harvest, fmt.Errorf("Unknown command: %s", name))
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(z.service.ctx, time.Duration(z.service.timeoutSeconds)*time.Second)
+	defer cancel()
+
+	stdout := &limitedBuffer{max: z.service.maxSize}
+	stderr := &limitedBuffer{max: maxStderrSize}
+	cmd := exec.CommandContext(ctx, args[0], args[1:]...)
+	cmd.Stdout = stdout
+	cmd.Stderr = stderr
+
+	if err := cmd.Run(); err != nil {
+		if message := strings.TrimSpace(stderr.String()); message != "" {
+			err = fmt.Errorf("%s: %s", err.Error(), message)
+		}
+		processFeedClientError(harvest, err)
+		return
+	}
+
+	if stdout.overflow {
+		processFeedOKButCannotParse(harvest, ErrTooLarge)
+		return
+	}
+
+	z.service.processBody(harvest, stdout.Bytes(), harvest.Feed.URL)
+
+}
+
+// limitedBuffer keeps the first max bytes written, discarding the rest. A max of zero means no limit.
+type limitedBuffer struct {
+	bytes.Buffer
+	max      int64
+	overflow bool
+}
+
+func (z *limitedBuffer) Write(p []byte) (int, error) {
+	n := len(p)
+	if z.max > 0 {
+		if remaining := z.max - int64(z.Len()); int64(len(p)) > remaining {
+			p = p[:remaining]
+			z.overflow = true
+		}
+	}
+	z.Buffer.Write(p)
+	return n, nil
+}
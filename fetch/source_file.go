@@ -0,0 +1,218 @@
+package fetch
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"mime"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/kwo/rakewire/feedparser"
+	"github.com/kwo/rakewire/model"
+)
+
+var (
+	// ErrOutsideRoots indicates that a file feed is not located in one of the configured directories.
+	ErrOutsideRoots = errors.New("File is outside of the permitted directories")
+	// feedExtensions are the files read from a directory feed
+	feedExtensions = map[string]bool{".atom": true, ".json": true, ".rss": true, ".xml": true}
+)
+
+// fileSource reads feeds from local files.
+// A directory is read as a single feed containing the entries of all feed files within.
+// Directories are not watched: they are read again when the feed is polled, unchanged if no file was added, removed or modified.
+// Only files within the configured root directories can be read.
+type fileSource struct {
+	service *Service
+	roots   []string
+}
+
+func (z *fileSource) Fetch(harvest *model.Harvest) {
+
+	filename, err := z.resolve(harvest.Feed.URL)
+	if err != nil {
+		processFeedClientError(harvest, err)
+		return
+	}
+
+	info, err := os.Stat(filename)
+	if err != nil {
+		processFeedClientError(harvest, err)
+		return
+	}
+
+	if info.IsDir() {
+		z.fetchDirectory(harvest, filename, info)
+	} else {
+		z.fetchFile(harvest, filename, info)
+	}
+
+}
+
+func (z *fileSource) fetchFile(harvest *model.Harvest, filename string, info os.FileInfo) {
+
+	modified := info.ModTime().UTC().Truncate(time.Second)
+	if z.notModified(harvest, modified) {
+		return
+	}
+
+	body, err := z.read(filename, info)
+	if err != nil {
+		processFeedOKButCannotParse(harvest, err)
+		return
+	}
+
+	harvest.Transmission.ContentType = mime.TypeByExtension(filepath.Ext(filename))
+	z.service.processBody(harvest, body, harvest.Feed.URL)
+	if harvest.Transmission.Result == model.FetchResultOK {
+		harvest.Feed.LastModified = modified
+	}
+
+}
+
+// fetchDirectory merges the feed files of a directory, files which cannot be parsed are skipped.
+func (z *fileSource) fetchDirectory(harvest *model.Harvest, dirname string, info os.FileInfo) {
+
+	feed := harvest.Feed
+
+	infos, err := ioutil.ReadDir(dirname)
+	if err != nil {
+		processFeedClientError(harvest, err)
+		return
+	}
+
+	// the directory is modified when files are added or removed, the files when they are changed
+	modified := info.ModTime()
+	var files []os.FileInfo
+	for _, fi := range infos {
+		if fi.Mode().IsRegular() && feedExtensions[strings.ToLower(filepath.Ext(fi.Name()))] {
+			files = append(files, fi)
+			if fi.ModTime().After(modified) {
+				modified = fi.ModTime()
+			}
+		}
+	}
+
+	modified = modified.UTC().Truncate(time.Second)
+	if z.notModified(harvest, modified) {
+		return
+	}
+
+	hash := sha256.New()
+	bodies := make([][]byte, len(files))
+	size := int64(0)
+	for i, fi := range files {
+		body, err := z.read(filepath.Join(dirname, fi.Name()), fi)
+		size += int64(len(body))
+		if err == nil && z.service.maxSize > 0 && size > z.service.maxSize {
+			err = ErrTooLarge
+		}
+		if err != nil {
+			processFeedOKButCannotParse(harvest, err)
+			return
+		}
+		io.WriteString(hash, fi.Name())
+		hash.Write(body)
+		bodies[i] = body
+	}
+
+	harvest.Transmission.Result = model.FetchResultOK
+
+	bodyHash := hex.EncodeToString(hash.Sum(nil))
+	if bodyHash == feed.BodyHash {
+		processFeedUnchanged(harvest, int(size))
+		feed.LastModified = modified
+		return
+	}
+
+	merged := &feedparser.Feed{
+		Flavor: "directory",
+		Title:  filepath.Base(dirname),
+		Links:  make(map[string]string),
+	}
+	var failed []string
+	for i, body := range bodies {
+		base := (&url.URL{Scheme: "file", Path: filepath.ToSlash(filepath.Join(dirname, files[i].Name()))}).String()
+		xmlFeed, err := z.service.parseFeed(feed, bytes.NewReader(body), base, mime.TypeByExtension(filepath.Ext(files[i].Name())), harvest.Diagnose)
+		if err != nil || xmlFeed == nil {
+			failed = append(failed, files[i].Name())
+			continue
+		}
+		merged.Entries = append(merged.Entries, xmlFeed.Entries...)
+		for _, warning := range xmlFeed.Warnings {
+			merged.Warnings = append(merged.Warnings, files[i].Name()+": "+warning)
+		}
+	}
+
+	if z.service.maxEntries > 0 && len(merged.Entries) > z.service.maxEntries {
+		processFeedOKButCannotParse(harvest, feedparser.ErrTooManyEntries)
+		return
+	}
+	if len(failed) > 0 && len(failed) == len(files) {
+		processFeedOKButCannotParse(harvest, fmt.Errorf("Cannot parse %s", strings.Join(failed, ", ")))
+		return
+	}
+
+	processFeedOKAndParse(harvest, int(size), merged)
+	if len(failed) > 0 {
+		harvest.Transmission.ResultMessage = fmt.Sprintf("Cannot parse %s", strings.Join(failed, ", "))
+	}
+	feed.BodyHash = bodyHash
+	feed.LastModified = modified
+
+}
+
+// notModified records the modification time and reports whether it is unchanged since the last successful fetch.
+func (z *fileSource) notModified(harvest *model.Harvest, modified time.Time) bool {
+	harvest.Transmission.LastModified = modified
+	if !harvest.Feed.LastModified.IsZero() && harvest.Feed.LastModified.Equal(modified) {
+		harvest.Transmission.Result = model.FetchResultOK
+		return true
+	}
+	return false
+}
+
+func (z *fileSource) read(filename string, info os.FileInfo) ([]byte, error) {
+	if z.service.maxSize > 0 && info.Size() > z.service.maxSize {
+		return nil, ErrTooLarge
+	}
+	return ioutil.ReadFile(filename)
+}
+
+// resolve returns the local path of a file URL, if it is within one of the root directories.
+func (z *fileSource) resolve(rawurl string) (string, error) {
+
+	u, err := url.Parse(rawurl)
+	if err != nil {
+		return "", err
+	}
+	if u.Host != "" && u.Host != "localhost" {
+		return "", fmt.Errorf("Cannot read files from host %s", u.Host)
+	}
+
+	filename, err := filepath.EvalSymlinks(filepath.FromSlash(u.Path))
+	if err != nil {
+		return "", err
+	}
+
+	for _, root := range z.roots {
+		root, err := filepath.EvalSymlinks(root)
+		if err != nil {
+			continue
+		}
+		if rel, err := filepath.Rel(root, filename); err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+			return filename, nil
+		}
+	}
+
+	return "", ErrOutsideRoots
+
+}
@@ -0,0 +1,79 @@
+package fetch
+
+import (
+	"net/http"
+
+	"github.com/kwo/rakewire/model"
+)
+
+// httpSource retrieves feeds from web servers, making use of conditional requests.
+type httpSource struct {
+	service *Service
+}
+
+func (z *httpSource) Fetch(harvest *model.Harvest) {
+
+	feed := harvest.Feed
+	now := harvest.Transmission.StartTime
+
+	client, proxyURL, err := z.service.clientFor(feed)
+	if err != nil {
+		processFeedClientError(harvest, err)
+		return
+	}
+
+	req := z.service.newRequest(feed)
+	if proxyURL == nil {
+		proxyURL, _ = http.ProxyFromEnvironment(req)
+	}
+	harvest.Transmission.Proxy = redactProxyURL(proxyURL)
+	harvest.Transmission.TLSInsecure = feed.Insecure
+
+	rsp, err := client.Do(req)
+	if err != nil && (rsp == nil || rsp.StatusCode != http.StatusMovedPermanently) {
+		processFeedClientError(harvest, err)
+		return
+	}
+	defer rsp.Body.Close()
+
+	harvest.Transmission.StatusCode = rsp.StatusCode
+	processFeedTLS(harvest, rsp.TLS)
+	processFeedSchedulingHeaders(harvest, rsp, now)
+
+	switch {
+
+	case rsp.StatusCode == http.StatusMovedPermanently:
+		processFeedMovedPermanently(harvest, rsp)
+
+	case rsp.StatusCode == http.StatusOK:
+		processFeedOK(harvest, rsp)
+		if feed.Scrape == nil {
+			if err := sniffContentType(harvest.Transmission.ContentType); err != nil {
+				processFeedOKButCannotParse(harvest, err)
+				break
+			}
+		}
+
+		body, err := readAll(rsp, z.service.maxSize, z.service.maxDecompressed)
+		if err != nil {
+			processFeedOKButCannotParse(harvest, err)
+			break
+		}
+
+		z.service.processBody(harvest, body, rsp.Request.URL.String())
+
+	case rsp.StatusCode == http.StatusNotModified:
+		processFeedNotModified(harvest, rsp)
+
+	case rsp.StatusCode == http.StatusTooManyRequests:
+		processFeedRateLimited(harvest, rsp)
+
+	case rsp.StatusCode >= 400:
+		processFeedServerError(harvest, rsp)
+
+	case true:
+		log.Debugf("Uncaught Status Code: %d", rsp.StatusCode)
+
+	} // switch
+
+}
@@ -0,0 +1,139 @@
+package fetch
+
+import (
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	"github.com/kwo/rakewire/model"
+	"golang.org/x/net/context"
+)
+
+func TestFileSource(t *testing.T) {
+
+	t.Parallel()
+
+	root, err := ioutil.TempDir("", "rakewire-")
+	if err != nil {
+		t.Fatalf("Cannot create temp dir: %s", err.Error())
+	}
+	defer os.RemoveAll(root)
+
+	reports := filepath.Join(root, "reports")
+	os.Mkdir(reports, 0755)
+	writeFile(t, filepath.Join(reports, "a.atom"), testFeed)
+	writeFile(t, filepath.Join(reports, "b.xml"), `<rss version="2.0"><channel><title>B</title><item><guid>b1</guid><title>B1</title></item></channel></rss>`)
+	writeFile(t, filepath.Join(reports, "notes.txt"), "ignored")
+
+	z := newTestService(t, &Configuration{TimeoutSeconds: 5, FileRoots: []string{reports}})
+
+	// single file
+	feed := model.F.New("file://" + filepath.ToSlash(filepath.Join(reports, "a.atom")))
+	harvest := z.testFetch(feed)
+	if harvest.Transmission.Result != model.FetchResultOK || len(harvest.Items) != 1 {
+		t.Fatalf("Bad file harvest: %s %s, items: %d", harvest.Transmission.Result, harvest.Transmission.ResultMessage, len(harvest.Items))
+	}
+	if feed.LastModified.IsZero() {
+		t.Error("Modification time not recorded")
+	}
+	if harvest = z.testFetch(feed); harvest.Transmission.Result != model.FetchResultOK || len(harvest.Items) != 0 {
+		t.Errorf("Expected unmodified file not to be read, items: %d", len(harvest.Items))
+	}
+
+	// directory
+	feed = model.F.New("file://" + filepath.ToSlash(reports))
+	harvest = z.testFetch(feed)
+	if harvest.Transmission.Result != model.FetchResultOK || len(harvest.Items) != 2 {
+		t.Fatalf("Bad directory harvest: %s %s, items: %d", harvest.Transmission.Result, harvest.Transmission.ResultMessage, len(harvest.Items))
+	}
+	if feed.Title != "reports" {
+		t.Errorf("Bad directory title: %s", feed.Title)
+	}
+
+	// outside of the roots
+	writeFile(t, filepath.Join(root, "secret.xml"), testFeed)
+	for _, rawurl := range []string{"file://" + filepath.ToSlash(filepath.Join(root, "secret.xml")), "file://" + filepath.ToSlash(reports) + "/../secret.xml"} {
+		if harvest = z.testFetch(model.F.New(rawurl)); harvest.Transmission.Result != model.FetchResultClientError {
+			t.Errorf("Expected %s to be refused, actual %s", rawurl, harvest.Transmission.Result)
+		}
+	}
+
+	// discovery returns file feeds as they are
+	if links, err := z.Discover(context.Background(), feed.URL); err != nil || len(links) != 1 || links[0].URL != feed.URL {
+		t.Errorf("Bad discovery of file feed: %v", err)
+	}
+
+}
+
+func TestExecSource(t *testing.T) {
+
+	t.Parallel()
+
+	cat, err := exec.LookPath("cat")
+	if err != nil {
+		t.Skip("cat not available")
+	}
+
+	dir, err := ioutil.TempDir("", "rakewire-")
+	if err != nil {
+		t.Fatalf("Cannot create temp dir: %s", err.Error())
+	}
+	defer os.RemoveAll(dir)
+	filename := filepath.Join(dir, "feed.atom")
+	writeFile(t, filename, testFeed)
+
+	z := newTestService(t, &Configuration{TimeoutSeconds: 5, Commands: map[string]string{
+		"report":  cat + " " + filename,
+		"missing": cat + " " + filepath.Join(dir, "missing.atom"),
+	}})
+
+	harvest := z.testFetch(model.F.New("exec://report"))
+	if harvest.Transmission.Result != model.FetchResultOK || len(harvest.Items) != 1 {
+		t.Errorf("Bad exec harvest: %s %s, items: %d", harvest.Transmission.Result, harvest.Transmission.ResultMessage, len(harvest.Items))
+	}
+
+	harvest = z.testFetch(model.F.New("exec://missing"))
+	if harvest.Transmission.Result != model.FetchResultClientError || harvest.Transmission.ResultMessage == "" {
+		t.Errorf("Expected failing command to be a client error, actual %s %s", harvest.Transmission.Result, harvest.Transmission.ResultMessage)
+	}
+
+	harvest = z.testFetch(model.F.New("exec://unknown"))
+	if harvest.Transmission.Result != model.FetchResultClientError {
+		t.Errorf("Expected unknown command to be refused, actual %s", harvest.Transmission.Result)
+	}
+
+	// sources are disabled unless configured
+	harvest = newTestService(t, &Configuration{TimeoutSeconds: 5}).testFetch(model.F.New("exec://report"))
+	if harvest.Transmission.ResultMessage != ErrUnsupportedScheme.Error() {
+		t.Errorf("Expected unsupported scheme, actual %s %s", harvest.Transmission.Result, harvest.Transmission.ResultMessage)
+	}
+
+}
+
+func TestIsLocal(t *testing.T) {
+
+	t.Parallel()
+
+	tests := map[string]bool{
+		"file:///var/feeds/feed.xml": true,
+		"FILE:///var/feeds/":         true,
+		"exec://weather":             true,
+		"http://localhost/feed.xml":  false,
+		"https://example.com/":       false,
+		"%":                          false,
+	}
+	for rawurl, expected := range tests {
+		if actual := IsLocal(rawurl); actual != expected {
+			t.Errorf("Bad local test for %s, expected %t, actual %t", rawurl, expected, actual)
+		}
+	}
+
+}
+
+func writeFile(t *testing.T, filename, content string) {
+	if err := ioutil.WriteFile(filename, []byte(content), 0644); err != nil {
+		t.Fatalf("Cannot write %s: %s", filename, err.Error())
+	}
+}
@@ -2,8 +2,12 @@ package fetch
 
 import (
 	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
 	"io"
+	"io/ioutil"
 	"net/http"
+	"strconv"
 	"strings"
 	"time"
 )
@@ -17,26 +21,123 @@ func parseDateHeader(value string) time.Time {
 	return result
 }
 
+// parseRetryAfter interprets the Retry-After header, either delay-seconds or an HTTP-date.
+func parseRetryAfter(value string, now time.Time) time.Time {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return time.Time{}
+	}
+	if seconds, err := strconv.Atoi(value); err == nil {
+		if seconds < 0 {
+			return time.Time{}
+		}
+		return now.Add(time.Duration(seconds) * time.Second)
+	}
+	return parseDateHeader(value)
+}
+
+// parseFreshness returns the time until which the response is considered fresh,
+// using Cache-Control max-age or, lacking that, the Expires header.
+func parseFreshness(header http.Header, now time.Time) time.Time {
+
+	for _, directive := range strings.Split(header.Get(hCacheControl), ",") {
+		directive = strings.ToLower(strings.TrimSpace(directive))
+		switch {
+		case directive == "no-cache" || directive == "no-store":
+			return time.Time{}
+		case strings.HasPrefix(directive, "max-age="):
+			seconds, err := strconv.Atoi(strings.Trim(strings.TrimPrefix(directive, "max-age="), `"`))
+			if err != nil || seconds <= 0 {
+				return time.Time{}
+			}
+			if age, err := strconv.Atoi(strings.TrimSpace(header.Get(hAge))); err == nil && age > 0 {
+				seconds -= age
+			}
+			if seconds <= 0 {
+				return time.Time{}
+			}
+			return now.Add(time.Duration(seconds) * time.Second)
+		}
+	}
+
+	expires := parseDateHeader(header.Get(hExpires))
+	if expires.IsZero() {
+		return time.Time{}
+	}
+
+	// correct for clock skew using the server date
+	if date := parseDateHeader(header.Get(hDate)); !date.IsZero() {
+		expires = now.Add(expires.Sub(date))
+	}
+
+	if !expires.After(now) {
+		return time.Time{}
+	}
+
+	return expires
+
+}
+
 func usesGzip(header string) bool {
 	return strings.Contains(header, "gzip")
 }
 
-func readBody(rsp *http.Response) (io.ReadCloser, error) {
+// ReadAll reads the complete, decompressed body of the response and closes it,
+// within the size limits of the configuration.
+func ReadAll(rsp *http.Response, cfg *Configuration) ([]byte, error) {
+	return readAll(rsp, int64(cfg.MaxSizeKB)*1024, int64(cfg.MaxDecompressedKB)*1024)
+}
+
+// readAll reads the complete, decompressed body of the response and closes it.
+// A limit of zero means no limit: maxSize bounds the body as transferred, maxDecompressed bounds it after decompression.
+func readAll(rsp *http.Response, maxSize, maxDecompressed int64) ([]byte, error) {
 
 	if rsp.Body == nil {
 		return nil, nil
 	}
+	defer rsp.Body.Close()
+
+	// reject early if the server announces the size
+	if maxSize > 0 && rsp.ContentLength > maxSize {
+		return nil, ErrTooLarge
+	}
+
+	raw := &ReadCounter{ReadCloser: rsp.Body}
+	var reader io.Reader = raw
+	if maxSize > 0 {
+		reader = io.LimitReader(raw, maxSize+1)
+	}
 
-	if usesGzip(rsp.Header.Get(hContentEncoding)) {
-		var reader io.ReadCloser
-		var err error
-		reader, err = gzip.NewReader(rsp.Body)
+	gzipped := usesGzip(rsp.Header.Get(hContentEncoding))
+	if gzipped {
+		gz, err := gzip.NewReader(reader)
 		if err != nil {
 			return nil, err
 		}
-		return reader, nil
+		defer gz.Close()
+		reader = gz
+		if maxDecompressed > 0 {
+			reader = io.LimitReader(reader, maxDecompressed+1)
+		}
 	}
 
-	return rsp.Body, nil
+	body, err := ioutil.ReadAll(reader)
+	if maxSize > 0 && int64(raw.Size) > maxSize {
+		return nil, ErrTooLarge
+	}
+	if err != nil {
+		return nil, err
+	}
+	if gzipped && maxDecompressed > 0 && int64(len(body)) > maxDecompressed {
+		return nil, ErrBomb
+	}
+
+	return body, nil
+
+}
 
+// hashBody returns the hex encoded SHA-256 digest of the body.
+func hashBody(body []byte) string {
+	sum := sha256.Sum256(body)
+	return hex.EncodeToString(sum[:])
 }
@@ -11,6 +11,7 @@ func (z *API) getFeeds(userID string, tx model.Transaction) ([]*Feed, []*FeedGro
 	mGroups := model.G.GetForUser(tx, userID)
 	mSubscriptions := model.S.GetForUser(tx, userID)
 	mFeedsByID := model.F.GetBySubscriptions(tx, mSubscriptions).ByID()
+	mFaviconsByFeedID := model.V.GetBySubscriptions(tx, mSubscriptions).ByFeedID()
 
 	feeds := []*Feed{}
 	for _, mSubscription := range mSubscriptions {
@@ -23,6 +24,9 @@ func (z *API) getFeeds(userID string, tx model.Transaction) ([]*Feed, []*FeedGro
 			IsSpark:     0,
 			LastUpdated: mFeedsByID[mSubscription.FeedID].LastUpdated.Unix(),
 		}
+		if mFavicon := mFaviconsByFeedID[mSubscription.FeedID]; mFavicon != nil && mFavicon.HasData() {
+			feed.FaviconID = parseID(mFavicon.FeedID)
+		}
 		feeds = append(feeds, feed)
 	}
 
@@ -32,6 +36,24 @@ func (z *API) getFeeds(userID string, tx model.Transaction) ([]*Feed, []*FeedGro
 
 }
 
+func (z *API) getFavicons(userID string, tx model.Transaction) ([]*Favicon, error) {
+
+	mSubscriptions := model.S.GetForUser(tx, userID)
+
+	favicons := []*Favicon{}
+	for _, mFavicon := range model.V.GetBySubscriptions(tx, mSubscriptions) {
+		if mFavicon.HasData() {
+			favicons = append(favicons, &Favicon{
+				ID:   parseID(mFavicon.FeedID),
+				Data: mFavicon.DataURI(),
+			})
+		}
+	}
+
+	return favicons, nil
+
+}
+
 func (z *API) getGroups(userID string, tx model.Transaction) ([]*Group, []*FeedGroup, error) {
 
 	mGroups := model.G.GetForUser(tx, userID)
@@ -8,10 +8,11 @@ func (z *API) getItemsAll(userID string, tx model.Transaction) ([]*Item, error)
 
 	entries := model.E.Range(tx, userID)
 	itemsByID := model.I.GetByEntries(tx, entries).ByID()
+	fullText := fullTextFeeds(userID, tx)
 
 	feverItems := []*Item{}
 	for _, entry := range entries {
-		feverItem := toItem(entry, itemsByID[entry.ItemID])
+		feverItem := toItem(entry, itemsByID[entry.ItemID], fullText[entry.FeedID])
 		feverItems = append(feverItems, feverItem)
 	}
 	return feverItems, nil
@@ -27,10 +28,11 @@ func (z *API) getItemsNext(userID, minID string, tx model.Transaction) ([]*Item,
 
 	entries := model.E.Range(tx, userID, formatID(min), formatID(max)).Limit(50)
 	itemsByID := model.I.GetByEntries(tx, entries).ByID()
+	fullText := fullTextFeeds(userID, tx)
 
 	feverItems := []*Item{}
 	for _, entry := range entries {
-		feverItem := toItem(entry, itemsByID[entry.ItemID])
+		feverItem := toItem(entry, itemsByID[entry.ItemID], fullText[entry.FeedID])
 		feverItems = append(feverItems, feverItem)
 	}
 	return feverItems, nil
@@ -51,10 +53,11 @@ func (z *API) getItemsPrev(userID, maxID string, tx model.Transaction) ([]*Item,
 
 	entries := model.E.Range(tx, userID, formatID(min), formatID(max)).Reverse().Limit(50)
 	itemsByID := model.I.GetByEntries(tx, entries).ByID()
+	fullText := fullTextFeeds(userID, tx)
 
 	feverItems := []*Item{}
 	for _, entry := range entries {
-		feverItem := toItem(entry, itemsByID[entry.ItemID])
+		feverItem := toItem(entry, itemsByID[entry.ItemID], fullText[entry.FeedID])
 		feverItems = append(feverItems, feverItem)
 	}
 	return feverItems, nil
@@ -64,11 +67,12 @@ func (z *API) getItemsPrev(userID, maxID string, tx model.Transaction) ([]*Item,
 func (z *API) getItemsByIds(userID string, ids []string, tx model.Transaction) ([]*Item, error) {
 
 	feverItems := []*Item{}
+	fullText := fullTextFeeds(userID, tx)
 	for _, id := range ids {
 		itemID := encodeID(id)
 		if entry := model.E.Get(tx, userID, itemID); entry != nil {
 			item := model.I.Get(tx, itemID)
-			feverItem := toItem(entry, item)
+			feverItem := toItem(entry, item, fullText[entry.FeedID])
 			feverItems = append(feverItems, feverItem)
 		}
 	}
@@ -77,16 +81,26 @@ func (z *API) getItemsByIds(userID string, ids []string, tx model.Transaction) (
 
 }
 
-func toItem(entry *model.Entry, item *model.Item) *Item {
+// fullTextFeeds returns the IDs of the feeds for which the user has chosen to read the extracted articles.
+func fullTextFeeds(userID string, tx model.Transaction) map[string]bool {
+	result := make(map[string]bool)
+	for feedID, subscriptions := range model.S.GetForUser(tx, userID).ByFeedID() {
+		result[feedID] = subscriptions.HasFullText()
+	}
+	return result
+}
+
+func toItem(entry *model.Entry, item *model.Item, fullText bool) *Item {
 	return &Item{
 		ID:             parseID(entry.ItemID),
 		SubscriptionID: parseID(entry.FeedID),
 		Title:          item.Title,
 		Author:         item.Author,
-		HTML:           item.Content,
+		HTML:           item.BestContent(fullText),
 		URL:            item.URL,
 		IsSaved:        boolToUint8(entry.Star),
 		IsRead:         boolToUint8(entry.Read),
 		Created:        item.Created.Unix(),
+		Categories:     item.Categories,
 	}
 }
@@ -102,6 +102,13 @@ func (z *API) ServeHTTPC(ctx context.Context, w http.ResponseWriter, r *http.Req
 						log.Debugf("error retrieving feeds and feed_groups: %s", err.Error())
 					}
 
+				case "favicons":
+					if favicons, err := z.getFavicons(user.ID, tx); err == nil {
+						rsp.Favicons = favicons
+					} else {
+						log.Debugf("error retrieving favicons: %s", err.Error())
+					}
+
 				case "groups":
 					if groups, feedGroups, err := z.getGroups(user.ID, tx); err == nil {
 						rsp.Groups = groups
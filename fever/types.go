@@ -8,6 +8,7 @@ type Response struct {
 	Groups        []*Group     `json:"groups,omitempty"`
 	FeedGroups    []*FeedGroup `json:"feeds_groups,omitempty"`
 	Feeds         []*Feed      `json:"feeds,omitempty"`
+	Favicons      []*Favicon   `json:"favicons,omitempty"`
 	Items         []*Item      `json:"items,omitempty"`
 	ItemCount     uint         `json:"total_items,omitempty"`
 	UnreadItemIDs string       `json:"unread_item_ids,omitempty"`
@@ -37,15 +38,22 @@ type Feed struct {
 	LastUpdated int64  `json:"last_updated_on_time,string"`
 }
 
+// Favicon is a fever favicon construct
+type Favicon struct {
+	ID   uint64 `json:"id"`
+	Data string `json:"data"` // base64 encoded image prefixed with its MIME type, e.g. image/png;base64,...
+}
+
 // Item is a fever item construct
 type Item struct {
-	ID             uint64 `json:"id"`
-	SubscriptionID uint64 `json:"feed_id"`
-	Title          string `json:"title"`
-	Author         string `json:"author"`
-	HTML           string `json:"html"`
-	URL            string `json:"url"`
-	IsSaved        uint8  `json:"is_saved"`
-	IsRead         uint8  `json:"is_read"`
-	Created        int64  `json:"created_on_time"`
+	ID             uint64   `json:"id"`
+	SubscriptionID uint64   `json:"feed_id"`
+	Title          string   `json:"title"`
+	Author         string   `json:"author"`
+	HTML           string   `json:"html"`
+	URL            string   `json:"url"`
+	IsSaved        uint8    `json:"is_saved"`
+	IsRead         uint8    `json:"is_read"`
+	Created        int64    `json:"created_on_time"`
+	Categories     []string `json:"categories,omitempty"`
 }
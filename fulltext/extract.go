@@ -0,0 +1,199 @@
+package fulltext
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"regexp"
+	"strings"
+
+	"github.com/kwo/rakewire/feedparser"
+	"golang.org/x/net/html"
+)
+
+const (
+	minParagraphLength = 25  // shorter paragraphs do not count towards a candidate score
+	minArticleLength   = 250 // extracted text must be at least this long
+)
+
+var (
+	// ErrNoArticle indicates that no article could be found in the page.
+	ErrNoArticle = errors.New("No article found")
+
+	unlikelyTags = map[string]bool{
+		"aside": true, "button": true, "footer": true, "form": true, "header": true, "iframe": true,
+		"nav": true, "noscript": true, "script": true, "style": true, "svg": true,
+	}
+	negativeNames = regexp.MustCompile(`(?i)ad-|advert|banner|combx|comment|cookie|disqus|footer|menu|meta|nav|popup|promo|related|share|sidebar|social|sponsor|subscribe|widget`)
+	positiveNames = regexp.MustCompile(`(?i)article|body|blog|content|entry|main|page|post|story|text`)
+)
+
+// Extract finds the main article of a web page, readability-style, and returns it as HTML with absolute URLs.
+func Extract(reader io.Reader, base string) (string, error) {
+
+	doc, err := html.Parse(reader)
+	if err != nil {
+		return "", err
+	}
+
+	removeUnlikely(doc)
+
+	// score the parents of paragraphs
+	scores := make(map[*html.Node]float64)
+	candidates := []*html.Node{} // in order of discovery so that ties are resolved consistently
+	addCandidate := func(n *html.Node) {
+		if _, ok := scores[n]; !ok {
+			scores[n] = initialScore(n)
+			candidates = append(candidates, n)
+		}
+	}
+	var walk func(n *html.Node)
+	walk = func(n *html.Node) {
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			walk(c)
+		}
+		if n.Type != html.ElementNode || (n.Data != "p" && n.Data != "pre" && n.Data != "td") {
+			return
+		}
+		text := getText(n)
+		if len(text) < minParagraphLength {
+			return
+		}
+		score := 1 + float64(strings.Count(text, ",")) + float64(min(len(text)/100, 3))
+		if parent := n.Parent; parent != nil {
+			addCandidate(parent)
+			scores[parent] += score
+			if grandparent := parent.Parent; grandparent != nil {
+				addCandidate(grandparent)
+				scores[grandparent] += score / 2
+			}
+		}
+	}
+	walk(doc)
+
+	var top *html.Node
+	var topScore float64
+	for _, n := range candidates {
+		score := scores[n] * (1 - linkDensity(n))
+		if top == nil || score > topScore {
+			top = n
+			topScore = score
+		}
+	}
+
+	if top == nil || len(getText(top)) < minArticleLength {
+		return "", ErrNoArticle
+	}
+
+	buf := &bytes.Buffer{}
+	for c := top.FirstChild; c != nil; c = c.NextSibling {
+		if err := html.Render(buf, c); err != nil {
+			return "", err
+		}
+	}
+
+	return feedparser.RewriteContentWithAbsoluteURLs(base, strings.TrimSpace(buf.String())), nil
+
+}
+
+// removeUnlikely removes elements which are unlikely to be part of the article.
+func removeUnlikely(n *html.Node) {
+	for c := n.FirstChild; c != nil; {
+		next := c.NextSibling
+		if c.Type == html.CommentNode || (c.Type == html.ElementNode && isUnlikely(c)) {
+			n.RemoveChild(c)
+		} else {
+			removeUnlikely(c)
+		}
+		c = next
+	}
+}
+
+func isUnlikely(n *html.Node) bool {
+	if unlikelyTags[n.Data] {
+		return true
+	}
+	if n.Data == "body" || n.Data == "article" || n.Data == "main" {
+		return false
+	}
+	names := getAttr(n, "class") + " " + getAttr(n, "id")
+	return negativeNames.MatchString(names) && !positiveNames.MatchString(names)
+}
+
+func initialScore(n *html.Node) float64 {
+	var score float64
+	switch n.Data {
+	case "article", "main":
+		score += 10
+	case "div":
+		score += 5
+	case "pre", "td", "blockquote":
+		score += 3
+	case "form", "ol", "ul", "dl", "dd", "dt", "li":
+		score -= 3
+	case "h1", "h2", "h3", "h4", "h5", "h6", "th":
+		score -= 5
+	}
+	names := getAttr(n, "class") + " " + getAttr(n, "id")
+	if negativeNames.MatchString(names) {
+		score -= 25
+	}
+	if positiveNames.MatchString(names) {
+		score += 25
+	}
+	return score
+}
+
+// linkDensity returns the fraction of text within the node that is link text.
+func linkDensity(n *html.Node) float64 {
+	textLength := len(getText(n))
+	if textLength == 0 {
+		return 0
+	}
+	linkLength := 0
+	var walk func(n *html.Node)
+	walk = func(n *html.Node) {
+		if n.Type == html.ElementNode && n.Data == "a" {
+			linkLength += len(getText(n))
+			return
+		}
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			walk(c)
+		}
+	}
+	walk(n)
+	return float64(linkLength) / float64(textLength)
+}
+
+func getAttr(n *html.Node, key string) string {
+	for _, attr := range n.Attr {
+		if attr.Key == key {
+			return attr.Val
+		}
+	}
+	return ""
+}
+
+// getText returns the text content of the node with whitespace collapsed.
+func getText(n *html.Node) string {
+	buf := &bytes.Buffer{}
+	var walk func(n *html.Node)
+	walk = func(n *html.Node) {
+		if n.Type == html.TextNode {
+			buf.WriteString(n.Data)
+			buf.WriteString(" ")
+		}
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			walk(c)
+		}
+	}
+	walk(n)
+	return strings.Join(strings.Fields(buf.String()), " ")
+}
+
+func min(a, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
@@ -0,0 +1,56 @@
+package fulltext
+
+import (
+	"strings"
+	"testing"
+)
+
+const testArticle = `<html>
+<head><title>Article</title><script>var x = "ignore, ignore, ignore, ignore, ignore, ignore";</script></head>
+<body>
+	<div id="nav"><ul><li><a href="/">Home</a></li><li><a href="/about">About</a></li></ul></div>
+	<div class="sidebar"><p>Subscribe to our newsletter, it is great, really, we promise, honestly.</p></div>
+	<div class="post-content">
+		<h1>The Headline</h1>
+		<p>The first paragraph of the article, which is long enough to count, with a few commas, here and there.</p>
+		<p>The second paragraph continues the story, adding more detail, more words, and an <img src="/photo.jpg"> image.</p>
+		<p>The third paragraph concludes the article, wrapping everything up, so that readers are satisfied, hopefully.</p>
+	</div>
+	<div id="comments"><p>A comment which is long enough to count as a paragraph, with commas, too, yes.</p></div>
+	<footer><p>Copyright notice which is long enough to count as a paragraph, and more, and more.</p></footer>
+</body>
+</html>`
+
+func TestExtract(t *testing.T) {
+
+	t.Parallel()
+
+	content, err := Extract(strings.NewReader(testArticle), "http://example.com/2016/article.html")
+	if err != nil {
+		t.Fatalf("Cannot extract article: %s", err.Error())
+	}
+
+	for _, expected := range []string{"The Headline", "first paragraph", "second paragraph", "third paragraph", `src="http://example.com/photo.jpg"`} {
+		if !strings.Contains(content, expected) {
+			t.Errorf("Missing from article: %s", expected)
+		}
+	}
+
+	for _, unexpected := range []string{"newsletter", "comment", "Copyright", "About", "ignore"} {
+		if strings.Contains(content, unexpected) {
+			t.Errorf("Unexpected in article: %s", unexpected)
+		}
+	}
+
+}
+
+func TestExtractNoArticle(t *testing.T) {
+
+	t.Parallel()
+
+	page := `<html><body><div><a href="/1">Link one</a> <a href="/2">Link two</a></div><p>Too short.</p></body></html>`
+	if _, err := Extract(strings.NewReader(page), "http://example.com/"); err != ErrNoArticle {
+		t.Errorf("Expected no article error, actual: %v", err)
+	}
+
+}
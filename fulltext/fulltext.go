@@ -0,0 +1,298 @@
+// Package fulltext extracts the full article of items from feeds which publish only summaries.
+package fulltext
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/kwo/rakewire/logger"
+	"github.com/kwo/rakewire/model"
+	"github.com/kwo/rakewire/sanitize"
+)
+
+const (
+	hContentType   = "Content-Type"
+	hUserAgent     = "User-Agent"
+	maxAttempts    = 5               // extraction is abandoned after this many failures
+	maxPageSize    = 5 * 1024 * 1024 // larger pages are truncated
+	retryBaseDelay = 10 * time.Minute
+)
+
+var (
+	// ErrRestart indicates that the service cannot be started because it is already running.
+	ErrRestart = errors.New("The service is already started")
+	log        = logger.New("fulltext")
+)
+
+// Configuration contains all parameters for the FullText service
+type Configuration struct {
+	Workers          int
+	IntervalSeconds  int // how often to look for pending items
+	HostDelaySeconds int // minimum time between requests to the same host
+	TimeoutSeconds   int
+	UserAgent        string
+	Sanitize         *sanitize.Policy // applied to the extracted articles, nil for the default policy
+	Client           *http.Client     // carries the proxy and TLS settings of the fetcher, nil for a plain client
+}
+
+// Service extracts the full articles of pending items
+type Service struct {
+	sync.Mutex
+	database     model.Database
+	client       *http.Client
+	workers      int
+	pollInterval time.Duration
+	hostDelay    time.Duration
+	userAgent    string
+	policy       *sanitize.Policy
+	running      bool
+	queue        chan *model.Item
+	killsignal   chan bool
+	latch        sync.WaitGroup
+	inflight     map[string]bool      // IDs of items being extracted
+	hosts        map[string]time.Time // earliest time of the next request per host
+}
+
+// NewService creates a new fulltext service
+func NewService(cfg *Configuration, database model.Database) *Service {
+	policy := cfg.Sanitize
+	if policy == nil {
+		policy = &sanitize.Policy{}
+	}
+	client := cfg.Client
+	if client == nil {
+		client = &http.Client{Timeout: time.Duration(cfg.TimeoutSeconds) * time.Second}
+	}
+	return &Service{
+		database:     database,
+		client:       client,
+		workers:      cfg.Workers,
+		pollInterval: time.Duration(cfg.IntervalSeconds) * time.Second,
+		hostDelay:    time.Duration(cfg.HostDelaySeconds) * time.Second,
+		userAgent:    cfg.UserAgent,
+		policy:       policy,
+	}
+}
+
+// Start service
+func (z *Service) Start() error {
+
+	z.Lock()
+	defer z.Unlock()
+	if z.running {
+		log.Debugf("service already started, exiting...")
+		return ErrRestart
+	}
+
+	log.Infof("starting...")
+	log.Infof("workers:    %d", z.workers)
+	log.Infof("interval:   %s", z.pollInterval.String())
+	log.Infof("host delay: %s", z.hostDelay.String())
+
+	z.queue = make(chan *model.Item, z.workers)
+	z.killsignal = make(chan bool)
+	z.inflight = make(map[string]bool)
+	z.hosts = make(map[string]time.Time)
+
+	z.latch.Add(z.workers + 1)
+	for i := 0; i < z.workers; i++ {
+		go z.work(i)
+	}
+	go z.run()
+
+	z.running = true
+	log.Infof("started")
+
+	return nil
+
+}
+
+// Stop service
+func (z *Service) Stop() {
+
+	z.Lock()
+	if !z.running {
+		z.Unlock()
+		log.Debugf("service already stopped, exiting...")
+		return
+	}
+	z.Unlock()
+
+	log.Debugf("stopping...")
+	close(z.killsignal)
+	z.latch.Wait()
+
+	z.Lock()
+	z.running = false
+	z.Unlock()
+	log.Infof("stopped")
+
+}
+
+// IsRunning indicated if the service is active or not.
+func (z *Service) IsRunning() bool {
+	z.Lock()
+	defer z.Unlock()
+	return z.running
+}
+
+func (z *Service) run() {
+
+	log.Debugf("run starting...")
+
+	ticker := time.NewTicker(z.pollInterval)
+
+run:
+	for {
+		select {
+		case tick := <-ticker.C:
+			z.poll(tick)
+		case <-z.killsignal:
+			break run
+		}
+	}
+
+	ticker.Stop()
+	close(z.queue)
+
+	z.latch.Done()
+	log.Debugf("run exited")
+
+}
+
+// poll queues pending items, skipping items of hosts which have been contacted too recently.
+func (z *Service) poll(now time.Time) {
+
+	var items model.Items
+	if err := z.database.Select(func(tx model.Transaction) error {
+		items = model.I.GetExtractable(tx, now)
+		return nil
+	}); err != nil {
+		log.Infof("Error polling items: %s", err.Error())
+		return
+	}
+
+	for _, item := range items {
+
+		host := hostOf(item.URL)
+
+		z.Lock()
+		if z.inflight[item.ID] || z.hosts[host].After(now) {
+			z.Unlock()
+			continue
+		}
+		z.inflight[item.ID] = true
+		z.hosts[host] = now.Add(z.hostDelay)
+		z.Unlock()
+
+		select {
+		case z.queue <- item:
+		default:
+			// workers busy, try again next time
+			z.Lock()
+			delete(z.inflight, item.ID)
+			z.Unlock()
+			return
+		}
+
+	}
+
+}
+
+func (z *Service) work(id int) {
+
+	log.Debugf("worker %2d starting...", id)
+
+	for item := range z.queue {
+		content, err := z.extract(item.URL)
+		z.save(item.ID, content, err)
+		z.Lock()
+		delete(z.inflight, item.ID)
+		z.Unlock()
+	}
+
+	z.latch.Done()
+	log.Debugf("worker %2d exited", id)
+
+}
+
+func (z *Service) extract(rawurl string) (string, error) {
+
+	req, err := http.NewRequest(http.MethodGet, rawurl, nil)
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set(hUserAgent, z.userAgent)
+
+	rsp, err := z.client.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer rsp.Body.Close()
+
+	if rsp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("Cannot retrieve article: %s", rsp.Status)
+	}
+	if contentType := rsp.Header.Get(hContentType); len(contentType) > 0 && !strings.Contains(contentType, "html") {
+		return "", fmt.Errorf("Not an HTML page: %s", contentType)
+	}
+
+	return Extract(io.LimitReader(rsp.Body, maxPageSize), rsp.Request.URL.String())
+
+}
+
+// save stores the extracted article or schedules another attempt.
+func (z *Service) save(itemID, content string, extractErr error) {
+
+	err := z.database.Update(func(tx model.Transaction) error {
+
+		item := model.I.Get(tx, itemID)
+		if item == nil {
+			return nil
+		}
+
+		if extractErr == nil {
+			item.FullContent = content
+			item.RawFullContent = ""
+			item.SanitizeFullContent(z.policy.Sanitize)
+			item.ExtractNext = time.Time{}
+			item.ExtractAttempts = 0
+			log.Debugf("extracted %s", item.URL)
+		} else {
+			item.ExtractAttempts++
+			if item.ExtractAttempts >= maxAttempts {
+				item.ExtractNext = time.Time{}
+				log.Infof("Giving up extraction of %s: %s", item.URL, extractErr.Error())
+			} else {
+				item.ExtractNext = time.Now().Add(retryDelay(item.ExtractAttempts))
+				log.Debugf("Cannot extract %s, attempt %d: %s", item.URL, item.ExtractAttempts, extractErr.Error())
+			}
+		}
+
+		return model.I.Save(tx, item)
+
+	})
+
+	if err != nil {
+		log.Infof("Error saving item %s: %s", itemID, err.Error())
+	}
+
+}
+
+// retryDelay returns the time to wait before the next extraction attempt after the given number of failures.
+func retryDelay(attempts int) time.Duration {
+	return time.Duration(attempts*attempts) * retryBaseDelay
+}
+
+func hostOf(rawurl string) string {
+	if u, err := url.Parse(rawurl); err == nil {
+		return strings.ToLower(u.Host)
+	}
+	return ""
+}
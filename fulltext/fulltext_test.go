@@ -0,0 +1,128 @@
+package fulltext
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/kwo/rakewire/model"
+)
+
+func TestInterfaceService(t *testing.T) {
+
+	var s model.Service = &Service{}
+	if s == nil {
+		t.Fatal("Does not implement model.Service interface.")
+	}
+
+}
+
+func TestExtractItems(t *testing.T) {
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/article":
+			w.Header().Set(hContentType, "text/html")
+			w.Write([]byte(testArticle))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	database := openTestDatabase(t)
+	defer closeTestDatabase(t, database)
+
+	now := time.Now()
+	var good, bad *model.Item
+	if err := database.Update(func(tx model.Transaction) error {
+		good = model.I.New("0000000001", "good")
+		good.URL = server.URL + "/article"
+		good.Content = "summary"
+		good.ExtractNext = now
+		bad = model.I.New("0000000001", "bad")
+		bad.URL = server.URL + "/missing"
+		bad.ExtractNext = now
+		return model.I.SaveAll(tx, model.Items{good, bad})
+	}); err != nil {
+		t.Fatalf("Cannot save items: %s", err.Error())
+	}
+
+	z := NewService(&Configuration{Workers: 1, IntervalSeconds: 3600, TimeoutSeconds: 5}, database)
+	if err := z.Start(); err != nil {
+		t.Fatalf("Cannot start service: %s", err.Error())
+	}
+
+	// both items share a host: the second is only queued once the host delay has passed
+	z.poll(now)
+	z.poll(now)
+	z.hostDelay = 0
+	z.hosts = make(map[string]time.Time)
+	time.Sleep(200 * time.Millisecond)
+	z.poll(now)
+	time.Sleep(200 * time.Millisecond)
+	z.Stop()
+
+	if err := database.Select(func(tx model.Transaction) error {
+
+		item := model.I.Get(tx, good.ID)
+		if item.Content != "summary" {
+			t.Errorf("Original content not kept: %s", item.Content)
+		}
+		if len(item.FullContent) == 0 || item.BestContent(true) != item.FullContent || item.BestContent(false) != item.Content {
+			t.Error("Missing full content")
+		}
+		if !item.ExtractNext.IsZero() {
+			t.Errorf("Extraction still pending: %s", item.ExtractNext)
+		}
+
+		item = model.I.Get(tx, bad.ID)
+		if item.ExtractAttempts != 1 {
+			t.Errorf("Bad extract attempts, expected %d, actual %d", 1, item.ExtractAttempts)
+		}
+		if item.ExtractNext.Before(now.Add(retryDelay(1) - time.Minute)) {
+			t.Errorf("Retry not delayed: %s", item.ExtractNext)
+		}
+
+		return nil
+
+	}); err != nil {
+		t.Fatalf("Cannot get items: %s", err.Error())
+	}
+
+}
+
+func openTestDatabase(t *testing.T) model.Database {
+
+	f, err := ioutil.TempFile("", "bolt-")
+	if err != nil {
+		t.Fatalf("Cannot acquire temp file: %s", err.Error())
+	}
+	f.Close()
+	location := f.Name()
+
+	boltDB, err := model.Instance.Open(location)
+	if err != nil {
+		t.Fatalf("Cannot open database: %s", err.Error())
+	}
+
+	return boltDB
+
+}
+
+func closeTestDatabase(t *testing.T, d model.Database) {
+
+	location := d.Location()
+
+	if err := model.Instance.Close(d); err != nil {
+		t.Errorf("Cannot close database: %s", err.Error())
+	}
+
+	if err := os.Remove(location); err != nil {
+		t.Errorf("Cannot remove temp file: %s", err.Error())
+	}
+
+}
@@ -14,6 +14,7 @@ import (
 	"github.com/kwo/rakewire/logger"
 	"github.com/kwo/rakewire/model"
 	"github.com/kwo/rakewire/web"
+	"github.com/kwo/rakewire/websub"
 	"golang.org/x/net/context"
 )
 
@@ -39,26 +40,35 @@ type Service struct {
 	cancel         context.CancelFunc
 	database       model.Database
 	debugMode      bool
+	fetcher        api.Fetcher
+	monitor        api.Monitor
 	listener       net.Listener
 	listenHostPort string // listening address
 	publicHostPort string
 	running        bool
+	server         *http.Server
 	tlsCertFile    string
 	tlsKeyFile     string
 	version        string
+	websub         HandlerC
 }
 
 // NewService creates a new httpd service.
-func NewService(cfg *Configuration, database model.Database, version string, appStart int64) *Service {
+// The websub handler serves the WebSub callback endpoint, which is disabled if nil.
+// The monitor reports pipeline activity to the API, which is disabled if nil.
+func NewService(cfg *Configuration, database model.Database, fetcher api.Fetcher, monitor api.Monitor, websub HandlerC, version string, appStart int64) *Service {
 	return &Service{
 		database:       database,
 		debugMode:      cfg.DebugMode,
+		fetcher:        fetcher,
+		monitor:        monitor,
 		listenHostPort: cfg.ListenHostPort,
 		publicHostPort: cfg.PublicHostPort,
 		tlsCertFile:    cfg.TLSCertFile,
 		tlsKeyFile:     cfg.TLSKeyFile,
 		version:        version,
 		appstart:       appStart,
+		websub:         websub,
 	}
 }
 
@@ -100,13 +110,13 @@ func (z *Service) Start() error {
 
 	handler := z.newHandler()
 
-	server := http.Server{
+	z.server = &http.Server{
 		Addr:      z.listenHostPort,
 		Handler:   handler,
 		TLSConfig: tlsConfig,
 	}
 
-	go server.Serve(z.listener)
+	go z.server.Serve(z.listener)
 
 	log.Infof("listening on %s, reachable at https://%s/", z.listenHostPort, z.publicHostPort)
 
@@ -118,28 +128,38 @@ func (z *Service) Start() error {
 
 // Stop stop the server
 func (z *Service) Stop() {
+	z.Shutdown(context.Background())
+}
+
+// Shutdown stops accepting connections and waits for the active requests to complete.
+// Once the context is done, the remaining connections are closed.
+func (z *Service) Shutdown(ctx context.Context) error {
 
 	z.Lock()
 	defer z.Unlock()
 	if !z.running {
 		log.Debugf("service already stopped, exiting...")
-		return
+		return nil
 	}
 
-	if err := z.listener.Close(); err != nil {
-		log.Debugf("error stopping httpd: %s", err.Error())
+	err := z.server.Shutdown(ctx)
+	if err != nil {
+		log.Infof("closing active connections: %s", err.Error())
+		z.server.Close()
 	}
 
 	// cancel top-level context
 	z.cancel()
-	// TODO: how to wait for all child contexts to finish?
 
 	z.cancel = nil
 	z.listener = nil
+	z.server = nil
 	z.running = false
 
 	log.Infof("stopped")
 
+	return err
+
 }
 
 // IsRunning indicates if server is running or not
@@ -152,7 +172,7 @@ func (z *Service) IsRunning() bool {
 func (z *Service) newHandler() http.Handler {
 
 	apiPath := "/api/"
-	apiHandler := Chain(api.New(z.database, apiPath, z.version, z.appstart), Authorize())
+	apiHandler := Chain(api.New(z.database, z.fetcher, z.monitor, apiPath, z.version, z.appstart), Authorize())
 	feverPath := "/fever/"
 	feverHandler := fever.New(z.database)
 	webHandler := web.New(z.debugMode)
@@ -162,6 +182,8 @@ func (z *Service) newHandler() http.Handler {
 			apiHandler.ServeHTTPC(ctx, w, r)
 		} else if strings.HasPrefix(r.URL.Path, feverPath) {
 			feverHandler.ServeHTTPC(ctx, w, r)
+		} else if strings.HasPrefix(r.URL.Path, websub.Path) && z.websub != nil {
+			z.websub.ServeHTTPC(ctx, w, r)
 		} else {
 			webHandler.ServeHTTPC(ctx, w, r)
 		}
@@ -28,7 +28,7 @@ func TestSilk(t *testing.T) {
 		PublicHostPort: testHostPort,
 	}
 
-	server := NewService(cfg, db, "Rakewire", time.Now().Unix())
+	server := NewService(cfg, db, nil, nil, nil, "Rakewire", time.Now().Unix())
 	if err := server.Start(); err != nil {
 		t.Fatalf("Cannot start httpd: %s", err.Error())
 	}
@@ -0,0 +1,58 @@
+package model
+
+import (
+	"time"
+)
+
+// Worker states
+const (
+	WorkerIdle     = "idle"
+	WorkerFetching = "fetching"
+	WorkerWaiting  = "waiting" // waiting for the reaper to accept the harvest
+)
+
+// Activity is the live state of the poll, fetch and reap pipeline
+type Activity struct {
+	Poll  *PollActivity
+	Fetch *FetchActivity
+	Reap  *ReapActivity
+}
+
+// PollActivity is the live state of the poll service
+type PollActivity struct {
+	Running  bool
+	Polling  bool      // a poll is in progress
+	LastPoll time.Time // start of the most recent poll
+	Queued   int       // polled feeds waiting for a fetcher
+}
+
+// FetchActivity is the live state of the fetch service
+type FetchActivity struct {
+	Running bool
+	Workers []*WorkerActivity
+	Queued  int // harvests waiting for the reaper, including refreshed and pushed feeds
+}
+
+// WorkerActivity is the state of a single fetch worker
+type WorkerActivity struct {
+	ID     int
+	State  string
+	Since  time.Time // time the worker entered the state
+	FeedID string    // empty if idle
+	URL    string
+}
+
+// ReapActivity is the live state of the reaper service
+type ReapActivity struct {
+	Running    bool
+	LastReaped time.Time // completion of the most recent harvest
+	PerMinute  int       // harvests reaped during the last minute
+	Total      int64     // harvests reaped since start
+	Batches    int64     // transactions committed since start
+	LastBatch  int       // harvests in the most recent transaction
+	AvgBatch   float64   // average harvests per transaction
+	LastCommit time.Duration
+	AvgCommit  time.Duration
+	Isolated   int64 // harvests reaped alone after their batch failed
+	Failed     int64 // harvests which could not be saved
+}
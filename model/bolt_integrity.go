@@ -525,12 +525,13 @@ func (z *boltInstance) rebuildIndexes(db Database) error {
 			for k, v := c.First(); k != nil; k, v = c.Next() {
 				if err := entity.decode(v); err == nil {
 					// save new indexes
-					for indexName, indexKeys := range entity.indexes() {
+					for indexName, keys := range indexKeys(entity) {
 						bIndex := bEntityIndex.Bucket(indexName)
-						key := keyEncode(indexKeys...)
 						value := entity.GetID()
-						if err := bIndex.Put([]byte(key), []byte(value)); err != nil {
-							return err
+						for _, key := range keys {
+							if err := bIndex.Put([]byte(key), []byte(value)); err != nil {
+								return err
+							}
 						}
 					} // indexes
 				} else {
@@ -832,6 +833,9 @@ func (z *boltInstance) removeFeedsWithoutSubscription(db Database) error {
 			if err := F.Delete(tx, id); err != nil {
 				return err
 			}
+			if err := V.Delete(tx, id); err != nil {
+				return err
+			}
 		}
 
 		return nil
@@ -0,0 +1,16 @@
+package model
+
+import (
+	"time"
+)
+
+// Enclosure is a media file attached to an item, such as a podcast episode.
+type Enclosure struct {
+	URL      string        `json:"url"`
+	Type     string        `json:"type,omitempty"`     // MIME type
+	Length   int64         `json:"length,omitempty"`   // size in bytes
+	Duration time.Duration `json:"duration,omitempty"` // playing time of audio and video files
+}
+
+// Enclosures is a collection of Enclosure objects
+type Enclosures []*Enclosure
@@ -11,11 +11,12 @@ var E = &entryStore{}
 type entryStore struct{}
 
 type entryQuery struct {
-	tx     Transaction
-	userID string
-	feedID string
-	min    time.Time
-	max    time.Time
+	tx       Transaction
+	userID   string
+	feedID   string
+	category string
+	min      time.Time
+	max      time.Time
 }
 
 func (z *entryStore) AddItems(tx Transaction, allItems Items) error {
@@ -28,8 +29,8 @@ func (z *entryStore) AddItems(tx Transaction, allItems Items) error {
 			for _, item := range items {
 				entry := z.New(subscription.UserID, item.ID, subscription.FeedID)
 				entry.Updated = item.Updated
-				entry.Read = subscription.AutoRead
-				entry.Star = subscription.AutoStar
+				entry.Read = subscription.AutoRead || item.HasCategory(subscription.AutoReadCategories...)
+				entry.Star = subscription.AutoStar || item.HasCategory(subscription.AutoStarCategories...)
 				if err := z.Save(tx, entry); err != nil {
 					return err
 				}
@@ -40,6 +41,26 @@ func (z *entryStore) AddItems(tx Transaction, allItems Items) error {
 	return nil
 }
 
+// AddItemsForSubscription creates entries for the given items for the subscriber only,
+// skipping items for which the subscriber already has an entry.
+func (z *entryStore) AddItemsForSubscription(tx Transaction, subscription *Subscription, items Items) error {
+
+	for _, item := range items {
+		if item.FeedID != subscription.FeedID || z.Get(tx, subscription.UserID, item.ID) != nil {
+			continue
+		}
+		entry := z.New(subscription.UserID, item.ID, subscription.FeedID)
+		entry.Updated = item.Updated
+		entry.Read = subscription.AutoRead || item.HasCategory(subscription.AutoReadCategories...)
+		entry.Star = subscription.AutoStar || item.HasCategory(subscription.AutoStarCategories...)
+		if err := z.Save(tx, entry); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
 func (z *entryStore) Delete(tx Transaction, id string) error {
 	return deleteObject(tx, entityEntry, id)
 }
@@ -125,6 +146,12 @@ func (z *entryStore) Query(tx Transaction, userID string) *entryQuery {
 	}
 }
 
+// Category restricts the query to entries whose item has the given category, ignoring case.
+func (z *entryQuery) Category(category string) *entryQuery {
+	z.category = category
+	return z
+}
+
 func (z *entryQuery) Feed(feedID string) *entryQuery {
 	z.feedID = feedID
 	return z
@@ -150,6 +177,7 @@ func (z *entryQuery) Count() uint {
 
 	var c Cursor
 	var min, nxt []byte
+	itemIDs := z.categoryItemIDs()
 
 	if z.feedID != empty {
 		// index Entry FeedUpdated = UserID|FeedID|Updated|ItemID : ItemID
@@ -166,7 +194,9 @@ func (z *entryQuery) Count() uint {
 	//fmt.Printf("min: %s, max: %s\n", string(min), string(max))
 	for k, _ := c.Seek(min); k != nil && bytes.Compare(k, nxt) < 0; k, _ = c.Next() {
 		//fmt.Printf("key: %s\n", string(k))
-		result++
+		if keyItemIn(k, itemIDs) {
+			result++
+		}
 	}
 
 	return result
@@ -179,6 +209,7 @@ func (z *entryQuery) Get() Entries {
 
 	var c Cursor
 	var min, nxt []byte
+	itemIDs := z.categoryItemIDs()
 
 	if z.feedID != empty {
 		// index Entry FeedUpdated = UserID|FeedID|Updated|ItemID : ItemID
@@ -193,6 +224,9 @@ func (z *entryQuery) Get() Entries {
 	}
 
 	for k, v := c.Seek(min); k != nil && bytes.Compare(k, nxt) < 0; k, v = c.Next() {
+		if !keyItemIn(k, itemIDs) {
+			continue
+		}
 		entryID := string(v)
 		if entry := E.Get(z.tx, entryID); entry != nil {
 			entries = append(entries, entry)
@@ -209,6 +243,7 @@ func (z *entryQuery) Starred() Entries {
 
 	var c Cursor
 	var min, nxt []byte
+	itemIDs := z.categoryItemIDs()
 
 	if z.feedID != empty {
 		// index Entry FeedStarUpdated = UserID|FeedID|Star|Updated|ItemID : ItemID
@@ -223,6 +258,9 @@ func (z *entryQuery) Starred() Entries {
 	}
 
 	for k, v := c.Seek(min); k != nil && bytes.Compare(k, nxt) < 0; k, v = c.Next() {
+		if !keyItemIn(k, itemIDs) {
+			continue
+		}
 		entryID := string(v)
 		if entry := E.Get(z.tx, entryID); entry != nil {
 			entries = append(entries, entry)
@@ -239,6 +277,7 @@ func (z *entryQuery) Unread() Entries {
 
 	var c Cursor
 	var min, nxt []byte
+	itemIDs := z.categoryItemIDs()
 
 	if z.feedID != empty {
 		// index Entry FeedReadUpdated = UserID|FeedID|Read|Updated|ItemID : ItemID
@@ -253,6 +292,9 @@ func (z *entryQuery) Unread() Entries {
 	}
 
 	for k, v := c.Seek(min); k != nil && bytes.Compare(k, nxt) < 0; k, v = c.Next() {
+		if !keyItemIn(k, itemIDs) {
+			continue
+		}
 		entryID := string(v)
 		if entry := E.Get(z.tx, entryID); entry != nil {
 			entries = append(entries, entry)
@@ -262,3 +304,39 @@ func (z *entryQuery) Unread() Entries {
 	return entries
 
 }
+
+// categoryItemIDs returns the IDs of the items having the category of the query,
+// within the feed of the query or else all feeds of the user, nil if the query has no category.
+func (z *entryQuery) categoryItemIDs() map[string]bool {
+
+	if z.category == empty {
+		return nil
+	}
+
+	var feedIDs []string
+	if z.feedID != empty {
+		feedIDs = append(feedIDs, z.feedID)
+	} else {
+		for _, subscription := range S.GetForUser(z.tx, z.userID) {
+			feedIDs = append(feedIDs, subscription.FeedID)
+		}
+	}
+
+	result := make(map[string]bool)
+	for _, feedID := range feedIDs {
+		for _, itemID := range I.getIDsForFeedCategory(z.tx, feedID, z.category) {
+			result[itemID] = true
+		}
+	}
+
+	return result
+
+}
+
+// keyItemIn tests if the item ID at the end of the index key is in the given set, a nil set contains all items.
+func keyItemIn(key []byte, itemIDs map[string]bool) bool {
+	if itemIDs == nil {
+		return true
+	}
+	return itemIDs[string(key[bytes.LastIndex(key, []byte(chSep))+1:])]
+}
@@ -778,3 +778,184 @@ func TestEntryUnread(t *testing.T) {
 	}
 
 }
+
+func TestEntryCategory(t *testing.T) {
+
+	t.Parallel()
+
+	db := openTestDatabase(t)
+	defer closeTestDatabase(t, db)
+
+	var user *User
+	var feed *Feed
+	if err := db.Update(func(tx Transaction) error {
+
+		user = U.New("User01", "abcdefg")
+		if err := U.Save(tx, user); err != nil {
+			return err
+		}
+		feed = F.New("Feed01")
+		if err := F.Save(tx, feed); err != nil {
+			return err
+		}
+		other := F.New("Feed02")
+		if err := F.Save(tx, other); err != nil {
+			return err
+		}
+
+		subscription := S.New(user.ID, feed.ID)
+		subscription.AutoReadCategories = []string{"ads"}
+		subscription.AutoStarCategories = []string{"Go"}
+		if err := S.Save(tx, subscription); err != nil {
+			return err
+		}
+		if err := S.Save(tx, S.New(user.ID, other.ID)); err != nil {
+			return err
+		}
+
+		var items Items
+		for i, categories := range [][]string{{"go"}, {"Ads"}, nil} {
+			item := I.New(feed.ID, fmt.Sprintf("guid%d", i+1))
+			item.Updated = time.Now().Add(time.Duration(-i) * time.Hour).Truncate(time.Second)
+			item.Categories = categories
+			items = append(items, item)
+		}
+		item := I.New(other.ID, "guid1")
+		item.Updated = time.Now().Truncate(time.Second)
+		item.Categories = []string{"go"}
+		items = append(items, item)
+		if err := I.SaveAll(tx, items); err != nil {
+			return err
+		}
+
+		return E.AddItems(tx, items)
+
+	}); err != nil {
+		t.Fatalf("Cannot add entries: %s", err.Error())
+	}
+
+	if err := db.Select(func(tx Transaction) error {
+
+		entries := E.Query(tx, user.ID).Feed(feed.ID).Get()
+		if len(entries) != 3 {
+			t.Fatalf("Bad entry count, expected %d, actual %d", 3, len(entries))
+		}
+		items := I.GetByEntries(tx, entries).ByID()
+		for _, entry := range entries {
+			item := items[entry.ItemID]
+			if entry.Read != item.HasCategory("ads") || entry.Star != item.HasCategory("go") {
+				t.Errorf("Bad entry %s: read %t, star %t", item.GUID, entry.Read, entry.Star)
+			}
+		}
+
+		if entries := E.Query(tx, user.ID).Feed(feed.ID).Category("GO").Get(); len(entries) != 1 {
+			t.Errorf("Bad feed category count, expected %d, actual %d", 1, len(entries))
+		}
+		if entries := E.Query(tx, user.ID).Category("go").Get(); len(entries) != 2 {
+			t.Errorf("Bad category count, expected %d, actual %d", 2, len(entries))
+		}
+		if count := E.Query(tx, user.ID).Category("go").Count(); count != 2 {
+			t.Errorf("Bad category count, expected %d, actual %d", 2, count)
+		}
+		if entries := E.Query(tx, user.ID).Category("go").Starred(); len(entries) != 1 {
+			t.Errorf("Bad starred category count, expected %d, actual %d", 1, len(entries))
+		}
+		if entries := E.Query(tx, user.ID).Category("ads").Unread(); len(entries) != 0 {
+			t.Errorf("Bad unread category count, expected %d, actual %d", 0, len(entries))
+		}
+		if entries := E.Query(tx, user.ID).Category("none").Get(); len(entries) != 0 {
+			t.Errorf("Bad category count, expected %d, actual %d", 0, len(entries))
+		}
+
+		return nil
+
+	}); err != nil {
+		t.Fatalf("Cannot query entries: %s", err.Error())
+	}
+
+}
+
+func TestEntryAddItemsForSubscription(t *testing.T) {
+
+	t.Parallel()
+
+	db := openTestDatabase(t)
+	defer closeTestDatabase(t, db)
+
+	var user1, user2 *User
+	var feed *Feed
+	if err := db.Update(func(tx Transaction) error {
+
+		user1 = U.New("User01", "abcdefg")
+		if err := U.Save(tx, user1); err != nil {
+			return err
+		}
+		user2 = U.New("User02", "abcdefg")
+		if err := U.Save(tx, user2); err != nil {
+			return err
+		}
+		feed = F.New("Feed01")
+		if err := F.Save(tx, feed); err != nil {
+			return err
+		}
+		if err := S.Save(tx, S.New(user1.ID, feed.ID)); err != nil {
+			return err
+		}
+		subscription := S.New(user2.ID, feed.ID)
+		subscription.AutoRead = true
+		if err := S.Save(tx, subscription); err != nil {
+			return err
+		}
+
+		var items Items
+		for i := 0; i < 3; i++ {
+			item := I.New(feed.ID, fmt.Sprintf("guid%d", i+1))
+			item.Updated = time.Now().Add(time.Duration(-i) * time.Hour).Truncate(time.Second)
+			items = append(items, item)
+		}
+		if err := I.SaveAll(tx, items); err != nil {
+			return err
+		}
+
+		// first item known to both users, the first entry of user2 is starred
+		if err := E.AddItems(tx, items[:1]); err != nil {
+			return err
+		}
+		entry := E.Get(tx, user2.ID, items[0].ID)
+		entry.Star = true
+		if err := E.Save(tx, entry); err != nil {
+			return err
+		}
+
+		return E.AddItemsForSubscription(tx, subscription, items)
+
+	}); err != nil {
+		t.Fatalf("Cannot add entries: %s", err.Error())
+	}
+
+	if err := db.Select(func(tx Transaction) error {
+
+		if entries := E.Query(tx, user1.ID).Get(); len(entries) != 1 {
+			t.Errorf("Bad entry count of other subscriber, expected %d, actual %d", 1, len(entries))
+		}
+
+		entries := E.Query(tx, user2.ID).Get()
+		if len(entries) != 3 {
+			t.Fatalf("Bad entry count, expected %d, actual %d", 3, len(entries))
+		}
+		for _, entry := range entries {
+			if !entry.Read {
+				t.Errorf("Entry %s not marked read", entry.ItemID)
+			}
+		}
+		if starred := E.Query(tx, user2.ID).Starred(); len(starred) != 1 {
+			t.Errorf("Existing entry overwritten, expected %d starred, actual %d", 1, len(starred))
+		}
+
+		return nil
+
+	}); err != nil {
+		t.Fatalf("Error selecting entries: %s", err.Error())
+	}
+
+}
@@ -0,0 +1,93 @@
+package model
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"time"
+)
+
+const (
+	entityFavicon       = "Favicon"
+	indexFaviconExpires = "Expires"
+)
+
+var (
+	indexesFavicon = []string{
+		indexFaviconExpires,
+	}
+)
+
+// Favicon is the small image representing a feed, one per feed.
+type Favicon struct {
+	FeedID  string    `json:"feedId"`
+	URL     string    `json:"url,omitempty"`  // location the icon was retrieved from
+	Type    string    `json:"type,omitempty"` // MIME type of Data
+	Data    []byte    `json:"data,omitempty"` // normalized image, empty if no icon could be found
+	Updated time.Time `json:"updated,omitempty"`
+	Expires time.Time `json:"expires,omitempty"` // time after which the icon is to be retrieved again
+}
+
+// DataURI returns the image formatted as a data URI without the data: prefix, as expected by Fever clients.
+func (z *Favicon) DataURI() string {
+	if len(z.Data) == 0 {
+		return empty
+	}
+	return z.Type + ";base64," + base64.StdEncoding.EncodeToString(z.Data)
+}
+
+// GetID returns the unique ID for the object
+func (z *Favicon) GetID() string {
+	return z.FeedID
+}
+
+// HasData tests if an icon was found for the feed.
+func (z *Favicon) HasData() bool {
+	return len(z.Data) > 0
+}
+
+func (z *Favicon) clear() {
+	z.FeedID = empty
+	z.URL = empty
+	z.Type = empty
+	z.Data = nil
+	z.Updated = time.Time{}
+	z.Expires = time.Time{}
+}
+
+func (z *Favicon) decode(data []byte) error {
+	z.clear()
+	if err := json.Unmarshal(data, z); err != nil {
+		return err
+	}
+	return nil
+}
+
+func (z *Favicon) encode() ([]byte, error) {
+	return json.Marshal(z)
+}
+
+func (z *Favicon) hasIncrementingID() bool {
+	return false
+}
+
+func (z *Favicon) indexes() map[string][]string {
+	result := make(map[string][]string)
+	result[indexFaviconExpires] = []string{keyEncodeTime(z.Expires), z.FeedID}
+	return result
+}
+
+func (z *Favicon) setID(tx Transaction) error {
+	return nil
+}
+
+// Favicons is a collection of Favicon objects
+type Favicons []*Favicon
+
+// ByFeedID maps favicons to their FeedID
+func (z Favicons) ByFeedID() map[string]*Favicon {
+	result := make(map[string]*Favicon)
+	for _, favicon := range z {
+		result[favicon.FeedID] = favicon
+	}
+	return result
+}
@@ -0,0 +1,76 @@
+package model
+
+import (
+	"bytes"
+	"time"
+)
+
+// V groups all favicon database methods
+var V = &faviconStore{}
+
+type faviconStore struct{}
+
+func (z *faviconStore) Delete(tx Transaction, feedID string) error {
+	return deleteObject(tx, entityFavicon, feedID)
+}
+
+func (z *faviconStore) Get(tx Transaction, feedID string) *Favicon {
+	bData := tx.Bucket(bucketData, entityFavicon)
+	if data := bData.Get([]byte(feedID)); data != nil {
+		favicon := &Favicon{}
+		if err := favicon.decode(data); err == nil {
+			return favicon
+		}
+	}
+	return nil
+}
+
+func (z *faviconStore) GetBySubscriptions(tx Transaction, subscriptions Subscriptions) Favicons {
+	result := Favicons{}
+	for _, subscription := range subscriptions {
+		if favicon := z.Get(tx, subscription.FeedID); favicon != nil {
+			result = append(result, favicon)
+		}
+	}
+	return result
+}
+
+// GetStale returns the feeds which have no favicon yet or whose favicon expires by the given max time.
+func (z *faviconStore) GetStale(tx Transaction, maxTime time.Time) Feeds {
+
+	feeds := Feeds{}
+
+	// feeds without a favicon
+	bFavicons := tx.Bucket(bucketData, entityFavicon)
+	c := tx.Bucket(bucketData, entityFeed).Cursor()
+	for k, v := c.First(); k != nil; k, v = c.Next() {
+		if bFavicons.Get(k) == nil {
+			feed := &Feed{}
+			if err := feed.decode(v); err == nil {
+				feeds = append(feeds, feed)
+			}
+		}
+	}
+
+	// index Favicon Expires = Expires|FeedID : FeedID
+	nxt := []byte(keyEncodeTime(maxTime.Add(1 * time.Second).Truncate(time.Second)))
+	c = tx.Bucket(bucketIndex, entityFavicon, indexFaviconExpires).Cursor()
+	for k, v := c.First(); k != nil && bytes.Compare(k, nxt) < 0; k, v = c.Next() {
+		if feed := F.Get(tx, string(v)); feed != nil {
+			feeds = append(feeds, feed)
+		}
+	}
+
+	return feeds
+
+}
+
+func (z *faviconStore) New(feedID string) *Favicon {
+	return &Favicon{
+		FeedID: feedID,
+	}
+}
+
+func (z *faviconStore) Save(tx Transaction, favicon *Favicon) error {
+	return saveObject(tx, entityFavicon, favicon)
+}
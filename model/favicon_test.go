@@ -0,0 +1,93 @@
+package model
+
+import (
+	"testing"
+	"time"
+)
+
+func TestFaviconSetup(t *testing.T) {
+
+	t.Parallel()
+
+	if obj := getObject(entityFavicon); obj == nil {
+		t.Error("missing getObject entry")
+	} else if obj.hasIncrementingID() {
+		t.Error("favicons do not have incrementing IDs")
+	}
+
+	if obj := allEntities[entityFavicon]; obj == nil {
+		t.Error("missing allEntities entry")
+	}
+
+}
+
+func TestFaviconDataURI(t *testing.T) {
+
+	t.Parallel()
+
+	favicon := V.New("0000000001")
+	if value := favicon.DataURI(); value != "" {
+		t.Errorf("Expected empty data URI, actual %s", value)
+	}
+
+	favicon.Type = "image/png"
+	favicon.Data = []byte("abc")
+	if value, expected := favicon.DataURI(), "image/png;base64,YWJj"; value != expected {
+		t.Errorf("Bad data URI, expected %s, actual %s", expected, value)
+	}
+
+}
+
+func TestFaviconGetStale(t *testing.T) {
+
+	t.Parallel()
+
+	db := openTestDatabase(t)
+	defer closeTestDatabase(t, db)
+
+	now := time.Now().Truncate(time.Second)
+
+	var feedIDs []string
+	if err := db.Update(func(tx Transaction) error {
+		for _, url := range []string{"http://localhost/a", "http://localhost/b", "http://localhost/c"} {
+			feed := F.New(url)
+			if err := F.Save(tx, feed); err != nil {
+				return err
+			}
+			feedIDs = append(feedIDs, feed.ID)
+		}
+		// a: expired, b: current, c: none
+		for i, expires := range []time.Time{now.Add(-time.Minute), now.Add(time.Hour)} {
+			favicon := V.New(feedIDs[i])
+			favicon.Expires = expires
+			if err := V.Save(tx, favicon); err != nil {
+				return err
+			}
+		}
+		return nil
+	}); err != nil {
+		t.Fatalf("error adding feeds: %s", err.Error())
+	}
+
+	if err := db.Select(func(tx Transaction) error {
+
+		feeds := V.GetStale(tx, now)
+		if len(feeds) != 2 {
+			t.Fatalf("Bad stale count, expected %d, actual %d", 2, len(feeds))
+		}
+		byID := feeds.ByID()
+		if byID[feedIDs[0]] == nil || byID[feedIDs[2]] == nil {
+			t.Errorf("Expected feeds a and c to be stale")
+		}
+
+		if favicon := V.Get(tx, feedIDs[1]); favicon == nil || !favicon.Expires.Equal(now.Add(time.Hour)) {
+			t.Errorf("Bad favicon: %v", favicon)
+		}
+
+		return nil
+
+	}); err != nil {
+		t.Fatalf("error getting stale favicons: %s", err.Error())
+	}
+
+}
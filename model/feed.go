@@ -10,29 +10,131 @@ import (
 const (
 	entityFeed         = "Feed"
 	indexFeedNextFetch = "NextFetch"
+	indexFeedPushNext  = "PushNext"
 	indexFeedURL       = "URL"
 )
 
 var (
 	indexesFeed = []string{
-		indexFeedNextFetch, indexFeedURL,
+		indexFeedNextFetch, indexFeedPushNext, indexFeedURL,
 	}
 )
 
 // Feed feed descriptor
 type Feed struct {
-	ID            string    `json:"id"`
-	URL           string    `json:"url"`
-	SiteURL       string    `json:"siteURL,omitempty"`
-	ETag          string    `json:"etag,omitempty"`
-	LastModified  time.Time `json:"lastModified,omitempty"`
-	LastUpdated   time.Time `json:"lastUpdated,omitempty"`
-	NextFetch     time.Time `json:"nextFetch,omitempty"`
-	Notes         string    `json:"notes,omitempty"`
-	Title         string    `json:"title,omitempty"`
-	Status        string    `json:"status,omitempty"`
-	StatusMessage string    `json:"statusMessage,omitempty"`
-	StatusSince   time.Time `json:"statusSince,omitempty"` // time of last status
+	ID            string          `json:"id"`
+	URL           string          `json:"url"`
+	SiteURL       string          `json:"siteURL,omitempty"`
+	ETag          string          `json:"etag,omitempty"`
+	LastModified  time.Time       `json:"lastModified,omitempty"`
+	BodyHash      string          `json:"bodyHash,omitempty"` // digest of the last successfully parsed response body
+	LastUpdated   time.Time       `json:"lastUpdated,omitempty"`
+	NextFetch     time.Time       `json:"nextFetch,omitempty"`
+	Notes         string          `json:"notes,omitempty"`
+	Title         string          `json:"title,omitempty"`
+	Status        string          `json:"status,omitempty"`
+	StatusMessage string          `json:"statusMessage,omitempty"`
+	StatusSince   time.Time       `json:"statusSince,omitempty"` // time of last status
+	Proxy         string          `json:"proxy,omitempty"`       // overrides the fetcher proxy
+	Insecure      bool            `json:"insecure,omitempty"`    // skip verification of the TLS certificate
+	Frequency     *Frequency      `json:"frequency,omitempty"`   // learned publishing pattern
+	Interval      time.Duration   `json:"interval,omitempty"`    // fixed fetch interval, overrides the learned frequency
+	Windows       FetchWindows    `json:"windows,omitempty"`     // times during which the feed may be fetched
+	TimeZone      string          `json:"timezone,omitempty"`    // location of the fetch windows, defaults to UTC
+	DateZone      string          `json:"dateZone,omitempty"`    // location of feed dates without a zone, defaults to UTC
+	Paused        bool            `json:"paused,omitempty"`      // do not fetch
+	Scrape        *ScrapeRules    `json:"scrape,omitempty"`      // extract items from a web page rather than parse a feed
+	Icon          string          `json:"icon,omitempty"`        // icon or image URL declared by the feed
+	Push          *Push           `json:"push,omitempty"`        // WebSub subscription, if the feed advertises a hub
+	SelfURL       string          `json:"selfURL,omitempty"`     // canonical URL declared by the feed
+	Language      string          `json:"language,omitempty"`    // language declared by the feed
+	Categories    []string        `json:"categories,omitempty"`  // categories declared by the feed
+	Hints         *PublisherHints `json:"hints,omitempty"`       // scheduling hints declared by the feed
+}
+
+// AdaptFetchTime schedules the FetchTime at the time the next item is expected to be published,
+// bounded by min and max (if not zero). Feeds without enough history fall back to UpdateFetchTime.
+func (z *Feed) AdaptFetchTime(min, max time.Duration) {
+
+	now := time.Now()
+
+	var predicted time.Time
+	var ok bool
+	if z.Frequency != nil {
+		predicted, ok = z.Frequency.Predict(now)
+	}
+
+	var next time.Time
+	switch {
+	case !ok:
+		z.UpdateFetchTime(z.LastUpdated)
+		next = z.NextFetch
+	case predicted.IsZero():
+		next = now.Add(week)
+	default:
+		next = predicted
+	}
+
+	if min > 0 && next.Before(now.Add(min)) {
+		next = now.Add(min)
+	}
+	if max > 0 && next.After(now.Add(max)) {
+		next = now.Add(max)
+	}
+
+	z.NextFetch = next.Truncate(time.Second)
+
+}
+
+// AddPublication records the publication time of a new item in the feed frequency.
+func (z *Feed) AddPublication(published time.Time) {
+	if z.Frequency == nil {
+		z.Frequency = &Frequency{}
+	}
+	z.Frequency.Add(published, time.Now())
+}
+
+// ApplyHints postpones the FetchTime to honor the publisher hints, but no further than max into the future.
+func (z *Feed) ApplyHints(max time.Duration) {
+	if z.Hints.IsEmpty() {
+		return
+	}
+	if interval := z.Hints.MinInterval(); interval > 0 {
+		z.DelayFetchTime(time.Now().Add(interval), max)
+	}
+	z.DelayFetchTime(z.Hints.Next(z.NextFetch), max)
+}
+
+// ApplyFetchWindows postpones the FetchTime to the next opening of the fetch windows, if necessary.
+func (z *Feed) ApplyFetchWindows() {
+	if len(z.Windows) > 0 {
+		z.NextFetch = z.Windows.Next(z.NextFetch.In(z.Location())).Truncate(time.Second)
+	}
+}
+
+// InFetchWindow tests if the feed may be fetched at the given time.
+func (z *Feed) InFetchWindow(t time.Time) bool {
+	return z.Windows.Contains(t.In(z.Location()))
+}
+
+// Location returns the time zone of the fetch windows.
+func (z *Feed) Location() *time.Location {
+	if z.TimeZone != empty {
+		if location, err := time.LoadLocation(z.TimeZone); err == nil {
+			return location
+		}
+	}
+	return time.UTC
+}
+
+// DateLocation returns the time zone of feed dates which do not specify one.
+func (z *Feed) DateLocation() *time.Location {
+	if z.DateZone != empty {
+		if location, err := time.LoadLocation(z.DateZone); err == nil {
+			return location
+		}
+	}
+	return time.UTC
 }
 
 // AdjustFetchTime sets the FetchTime to interval units in the future.
@@ -40,6 +142,16 @@ func (z *Feed) AdjustFetchTime(interval time.Duration) {
 	z.NextFetch = time.Now().Add(interval).Truncate(time.Second)
 }
 
+// DelayFetchTime postpones the FetchTime to notBefore, if later, but no further than max into the future.
+func (z *Feed) DelayFetchTime(notBefore time.Time, max time.Duration) {
+	if limit := time.Now().Add(max); notBefore.After(limit) {
+		notBefore = limit
+	}
+	if notBefore.After(z.NextFetch) {
+		z.NextFetch = notBefore.Truncate(time.Second)
+	}
+}
+
 // GetID returns the unique ID for the object
 func (z *Feed) GetID() string {
 	return z.ID
@@ -77,6 +189,7 @@ func (z *Feed) clear() {
 	z.SiteURL = empty
 	z.ETag = empty
 	z.LastModified = time.Time{}
+	z.BodyHash = empty
 	z.LastUpdated = time.Time{}
 	z.NextFetch = time.Time{}
 	z.Notes = empty
@@ -84,6 +197,21 @@ func (z *Feed) clear() {
 	z.Status = empty
 	z.StatusMessage = empty
 	z.StatusSince = time.Time{}
+	z.Proxy = empty
+	z.Insecure = false
+	z.Frequency = nil
+	z.Interval = 0
+	z.Windows = nil
+	z.TimeZone = empty
+	z.DateZone = empty
+	z.Paused = false
+	z.Scrape = nil
+	z.Icon = empty
+	z.Push = nil
+	z.SelfURL = empty
+	z.Language = empty
+	z.Categories = nil
+	z.Hints = nil
 }
 
 func (z *Feed) decode(data []byte) error {
@@ -106,6 +234,9 @@ func (z *Feed) indexes() map[string][]string {
 	result := make(map[string][]string)
 	result[indexFeedNextFetch] = []string{keyEncodeTime(z.NextFetch), z.ID}
 	result[indexFeedURL] = []string{strings.ToLower(z.URL)}
+	if z.Push != nil && !z.Push.Next.IsZero() {
+		result[indexFeedPushNext] = []string{keyEncodeTime(z.Push.Next), z.ID}
+	}
 	return result
 }
 
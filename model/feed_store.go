@@ -46,16 +46,32 @@ func (z *feedStore) GetBySubscriptions(tx Transaction, subscriptions Subscriptio
 }
 
 // GetNext returns all feeds which are due to be fetched within the given max time.
+// Paused feeds and feeds outside of their fetch windows are skipped.
 func (z *feedStore) GetNext(tx Transaction, maxTime time.Time) Feeds {
 	// index Feed NextFetch = FetchTime|FeedID : FeedID
 	feeds := Feeds{}
+	now := time.Now()
 	nxtTime := maxTime.Add(1 * time.Second).Truncate(time.Second)
 	nxt := []byte(keyEncodeTime(nxtTime))
 	b := tx.Bucket(bucketIndex, entityFeed, indexFeedNextFetch)
 	c := b.Cursor()
 	for k, v := c.First(); k != nil && bytes.Compare(k, nxt) < 0; k, v = c.Next() {
 		feedID := string(v)
-		if feed := z.Get(tx, feedID); feed != nil {
+		if feed := z.Get(tx, feedID); feed != nil && !feed.Paused && feed.InFetchWindow(now) {
+			feeds = append(feeds, feed)
+		}
+	}
+	return feeds
+}
+
+// GetPushNext returns all feeds whose WebSub subscription is due to be requested or renewed within the given max time.
+func (z *feedStore) GetPushNext(tx Transaction, maxTime time.Time) Feeds {
+	// index Feed PushNext = PushNext|FeedID : FeedID
+	feeds := Feeds{}
+	nxt := []byte(keyEncodeTime(maxTime.Add(1 * time.Second).Truncate(time.Second)))
+	c := tx.Bucket(bucketIndex, entityFeed, indexFeedPushNext).Cursor()
+	for k, v := c.First(); k != nil && bytes.Compare(k, nxt) < 0; k, v = c.Next() {
+		if feed := z.Get(tx, string(v)); feed != nil {
 			feeds = append(feeds, feed)
 		}
 	}
@@ -402,3 +402,32 @@ func TestUpdateFetchTime4(t *testing.T) {
 	}
 
 }
+
+func TestFeedDelayFetchTime(t *testing.T) {
+
+	t.Parallel()
+
+	now := time.Now().Truncate(time.Second)
+
+	feed := F.New("http://localhost/")
+	feed.NextFetch = now.Add(15 * time.Minute)
+
+	// earlier than scheduled, ignored
+	feed.DelayFetchTime(now.Add(5*time.Minute), 24*time.Hour)
+	if !feed.NextFetch.Equal(now.Add(15 * time.Minute)) {
+		t.Errorf("NextFetch moved forward: %s", feed.NextFetch)
+	}
+
+	// later than scheduled
+	feed.DelayFetchTime(now.Add(2*time.Hour), 24*time.Hour)
+	if !feed.NextFetch.Equal(now.Add(2 * time.Hour)) {
+		t.Errorf("NextFetch not delayed: %s", feed.NextFetch)
+	}
+
+	// capped by max
+	feed.DelayFetchTime(now.Add(72*time.Hour), 24*time.Hour)
+	if feed.NextFetch.After(time.Now().Add(24 * time.Hour)) {
+		t.Errorf("NextFetch not capped: %s", feed.NextFetch)
+	}
+
+}
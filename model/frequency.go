@@ -0,0 +1,114 @@
+package model
+
+import (
+	"time"
+)
+
+const (
+	hoursPerWeek        = 7 * 24
+	week                = 7 * 24 * time.Hour
+	frequencyWindow     = 8 * week // older observations are decayed
+	frequencyMinSamples = 5        // minimum observations needed for a prediction
+)
+
+// Frequency counts feed items by hour of the week (UTC, Sunday 00:00 first)
+// in order to learn the publishing pattern of a feed.
+type Frequency struct {
+	Counts []float64 `json:"counts"`
+	Since  time.Time `json:"since"` // start of the observation period
+}
+
+// Add records an item published at the given time.
+// Items older than the observation window are ignored.
+func (z *Frequency) Add(published, now time.Time) {
+
+	if len(z.Counts) != hoursPerWeek {
+		z.Counts = make([]float64, hoursPerWeek)
+	}
+
+	windowStart := now.Add(-frequencyWindow)
+	if published.Before(windowStart) || published.After(now) {
+		return
+	}
+
+	if z.Since.IsZero() || published.Before(z.Since) {
+		z.Since = published
+	}
+
+	z.Counts[hourOfWeek(published)]++
+
+	// decay counts so that the window slides forward
+	if z.Since.Before(windowStart) {
+		factor := float64(frequencyWindow) / float64(now.Sub(z.Since))
+		for i := range z.Counts {
+			z.Counts[i] *= factor
+		}
+		z.Since = windowStart
+	}
+
+}
+
+// Rates returns the average number of items per week for each hour of the week.
+func (z *Frequency) Rates(now time.Time) []float64 {
+	rates := make([]float64, hoursPerWeek)
+	if len(z.Counts) != hoursPerWeek || z.Since.IsZero() {
+		return rates
+	}
+	weeks := float64(now.Sub(z.Since)) / float64(week)
+	if weeks < 1 {
+		weeks = 1
+	}
+	for i, count := range z.Counts {
+		rates[i] = count / weeks
+	}
+	return rates
+}
+
+// Total returns the number of observations.
+func (z *Frequency) Total() float64 {
+	var total float64
+	for _, count := range z.Counts {
+		total += count
+	}
+	return total
+}
+
+// Predict returns the time at which the next item is expected to be published.
+// If there are not enough observations, ok is false: a feed must have been observed
+// for at least a week or have published a minimum number of items.
+// If no item is expected within the coming week, the time is zero.
+func (z *Frequency) Predict(now time.Time) (next time.Time, ok bool) {
+
+	if z.Since.IsZero() || (z.Total() < frequencyMinSamples && now.Sub(z.Since) < week) {
+		return time.Time{}, false
+	}
+
+	rates := z.Rates(now)
+	var expected float64
+
+	// first partial hour
+	hour := now.Truncate(time.Hour)
+	remaining := float64(hour.Add(time.Hour).Sub(now)) / float64(time.Hour)
+	expected += rates[hourOfWeek(now)] * remaining
+	if expected >= 0.5 {
+		return now.Add(time.Duration((0.5 / expected) * remaining * float64(time.Hour))), true
+	}
+
+	for i := 1; i <= hoursPerWeek; i++ {
+		t := hour.Add(time.Duration(i) * time.Hour)
+		rate := rates[hourOfWeek(t)]
+		if expected+rate >= 0.5 {
+			fraction := (0.5 - expected) / rate
+			return t.Add(time.Duration(fraction * float64(time.Hour))), true
+		}
+		expected += rate
+	}
+
+	return time.Time{}, true
+
+}
+
+func hourOfWeek(t time.Time) int {
+	t = t.UTC()
+	return int(t.Weekday())*24 + t.Hour()
+}
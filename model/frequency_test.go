@@ -0,0 +1,124 @@
+package model
+
+import (
+	"testing"
+	"time"
+)
+
+func TestFrequencyHourOfWeek(t *testing.T) {
+
+	t.Parallel()
+
+	sunday := time.Date(2016, time.March, 6, 0, 30, 0, 0, time.UTC)
+	if h := hourOfWeek(sunday); h != 0 {
+		t.Errorf("Bad hour of week, expected %d, actual %d", 0, h)
+	}
+
+	saturday := time.Date(2016, time.March, 12, 23, 59, 0, 0, time.UTC)
+	if h := hourOfWeek(saturday); h != hoursPerWeek-1 {
+		t.Errorf("Bad hour of week, expected %d, actual %d", hoursPerWeek-1, h)
+	}
+
+}
+
+func TestFrequencyPredict(t *testing.T) {
+
+	t.Parallel()
+
+	now := time.Date(2016, time.March, 9, 12, 0, 0, 0, time.UTC) // Wednesday noon
+
+	// not enough observations
+	f := &Frequency{}
+	f.Add(now.Add(-24*time.Hour), now)
+	if _, ok := f.Predict(now); ok {
+		t.Error("Expected no prediction with too few observations")
+	}
+
+	// published every day at 08:00 for four weeks
+	f = &Frequency{}
+	for day := 1; day <= 28; day++ {
+		f.Add(time.Date(2016, time.March, 9-day, 8, 0, 0, 0, time.UTC), now)
+	}
+
+	next, ok := f.Predict(now)
+	if !ok {
+		t.Fatal("Expected prediction")
+	}
+	expected := time.Date(2016, time.March, 10, 8, 0, 0, 0, time.UTC)
+	if next.Before(expected) || next.After(expected.Add(time.Hour)) {
+		t.Errorf("Bad prediction, expected %s, actual %s", expected, next)
+	}
+
+	// observations older than the window are ignored
+	f = &Frequency{}
+	f.Add(now.Add(-frequencyWindow-time.Hour), now)
+	if total := f.Total(); total != 0 {
+		t.Errorf("Expected old observation to be ignored, total: %f", total)
+	}
+
+}
+
+func TestFrequencyDecay(t *testing.T) {
+
+	t.Parallel()
+
+	start := time.Date(2016, time.January, 1, 8, 0, 0, 0, time.UTC)
+	f := &Frequency{}
+	for day := 0; day < 100; day++ {
+		published := start.Add(time.Duration(day) * 24 * time.Hour)
+		f.Add(published, published)
+	}
+
+	now := start.Add(99 * 24 * time.Hour)
+	if f.Since.Before(now.Add(-frequencyWindow)) {
+		t.Errorf("Observation period exceeds window: %s", f.Since)
+	}
+
+	rates := f.Rates(now)
+	var total float64
+	for _, rate := range rates {
+		total += rate
+	}
+	if total < 6.5 || total > 7.5 {
+		t.Errorf("Bad weekly rate, expected about %d, actual %f", 7, total)
+	}
+
+}
+
+func TestFeedAdaptFetchTime(t *testing.T) {
+
+	t.Parallel()
+
+	now := time.Now()
+
+	// dormant feed, scheduled at max
+	feed := F.New("http://localhost/")
+	feed.Frequency = &Frequency{}
+	for i := 0; i < 2; i++ {
+		feed.Frequency.Add(now.Add(-7*week+time.Duration(i)*time.Minute), now)
+	}
+	feed.AdaptFetchTime(5*time.Minute, 6*time.Hour)
+	if d := feed.NextFetch.Sub(now); d < 5*time.Hour || d > 6*time.Hour {
+		t.Errorf("Expected dormant feed to be scheduled at max interval: %s", d)
+	}
+
+	// busy feed, scheduled at min
+	feed = F.New("http://localhost/")
+	feed.Frequency = &Frequency{}
+	for i := 0; i < 7*24*10; i++ {
+		feed.Frequency.Add(now.Add(-time.Duration(i)*6*time.Minute), now)
+	}
+	feed.AdaptFetchTime(5*time.Minute, 6*time.Hour)
+	if d := feed.NextFetch.Sub(now); d < 4*time.Minute || d > 6*time.Minute {
+		t.Errorf("Expected busy feed to be scheduled at min interval: %s", d)
+	}
+
+	// no history, fall back
+	feed = F.New("http://localhost/")
+	feed.LastUpdated = now.Add(-30 * time.Minute)
+	feed.AdaptFetchTime(5*time.Minute, 6*time.Hour)
+	if d := feed.NextFetch.Sub(now); d < 4*time.Minute || d > 16*time.Minute {
+		t.Errorf("Expected fallback schedule for feed without history: %s", d)
+	}
+
+}
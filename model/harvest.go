@@ -1,10 +1,31 @@
 package model
 
+import (
+	"time"
+)
+
 // Harvest represents a polled feed complete with items and transmission data.
 type Harvest struct {
 	Feed         *Feed
 	Items        Items
 	Transmission *Transmission
+	RetryAfter   time.Time     // from the Retry-After header
+	FreshUntil   time.Time     // from the Cache-Control max-age or Expires headers
+	Reaped       chan struct{} // closed, if not nil, once the harvest has been reaped
+	Pushed       bool          // content was delivered by a WebSub hub rather than fetched
+	Diagnose     bool          // parse in diagnostic mode, collecting Warnings
+	Warnings     []string      // problems found in the content of the feed, if Diagnose is set
+	Older        string        // page with older items: the RFC 5005 prev-archive or next link of the feed
+	Archive      bool          // content is an older page of the feed, which does not alter the feed itself
+	BackfillUser string        // ID of the user whose subscription receives entries for all items of the harvest
+}
+
+// NotBefore returns the earliest time the server permits the feed to be fetched again.
+func (z *Harvest) NotBefore() time.Time {
+	if z.RetryAfter.After(z.FreshUntil) {
+		return z.RetryAfter
+	}
+	return z.FreshUntil
 }
 
 // AddItem appends a new item to the item collection
@@ -14,5 +35,39 @@ func (z *Harvest) AddItem(guid string) *Item {
 	return item
 }
 
+// Clone returns a deep copy of the feed, items and transmission of the harvest.
+// Reaping modifies these objects, a clone allows the harvest to be reaped again if the transaction fails.
+func (z *Harvest) Clone() (*Harvest, error) {
+
+	clone := *z
+
+	if z.Feed != nil {
+		clone.Feed = &Feed{}
+		if err := copyObject(z.Feed, clone.Feed); err != nil {
+			return nil, err
+		}
+	}
+
+	if z.Transmission != nil {
+		clone.Transmission = &Transmission{}
+		if err := copyObject(z.Transmission, clone.Transmission); err != nil {
+			return nil, err
+		}
+	}
+
+	if z.Items != nil {
+		clone.Items = make(Items, len(z.Items))
+		for i, item := range z.Items {
+			clone.Items[i] = &Item{}
+			if err := copyObject(item, clone.Items[i]); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	return &clone, nil
+
+}
+
 // Harvests is a collection of Harvest objects.
 type Harvests []*Harvest
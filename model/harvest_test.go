@@ -0,0 +1,55 @@
+package model
+
+import (
+	"testing"
+	"time"
+)
+
+func TestHarvestClone(t *testing.T) {
+
+	t.Parallel()
+
+	now := time.Now().Truncate(time.Second)
+	reaped := make(chan struct{})
+
+	harvest := &Harvest{
+		Feed:         F.New("http://localhost/feed.xml"),
+		Transmission: T.New("0000000001"),
+		RetryAfter:   now,
+		Reaped:       reaped,
+		Pushed:       true,
+	}
+	harvest.Feed.ID = "0000000001"
+	harvest.Feed.Title = "Feed"
+	harvest.AddItem("guid1").Title = "Item"
+	harvest.Transmission.ItemCount = 1
+
+	clone, err := harvest.Clone()
+	if err != nil {
+		t.Fatalf("Cannot clone harvest: %s", err.Error())
+	}
+
+	if clone.Feed == harvest.Feed || clone.Transmission == harvest.Transmission || clone.Items[0] == harvest.Items[0] {
+		t.Fatal("Clone shares objects with the original")
+	}
+	if clone.Feed.ID != harvest.Feed.ID || clone.Feed.Title != "Feed" || !clone.Feed.NextFetch.Equal(harvest.Feed.NextFetch) {
+		t.Errorf("Bad feed: %v", clone.Feed)
+	}
+	if len(clone.Items) != 1 || clone.Items[0].GUID != "guid1" || clone.Items[0].Title != "Item" {
+		t.Errorf("Bad items: %v", clone.Items)
+	}
+	if clone.Transmission.ItemCount != 1 {
+		t.Errorf("Bad transmission: %v", clone.Transmission)
+	}
+	if !clone.RetryAfter.Equal(now) || clone.Reaped != reaped || !clone.Pushed {
+		t.Errorf("Bad harvest: %v", clone)
+	}
+
+	// changes to the clone do not affect the original
+	clone.Feed.Title = "Changed"
+	clone.Items[0].ID = "0000000002"
+	if harvest.Feed.Title != "Feed" || harvest.Items[0].ID != empty {
+		t.Error("Original modified")
+	}
+
+}
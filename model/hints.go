@@ -0,0 +1,67 @@
+package model
+
+import (
+	"time"
+)
+
+// PublisherHints are the scheduling hints declared by a feed: how long it may be cached,
+// how often it is expected to be updated, and the hours and days during which it is not updated.
+type PublisherHints struct {
+	TTL          time.Duration  `json:"ttl,omitempty"`          // rss ttl
+	UpdatePeriod time.Duration  `json:"updatePeriod,omitempty"` // sy:updatePeriod divided by sy:updateFrequency
+	SkipHours    []int          `json:"skipHours,omitempty"`    // hours of the day in UTC
+	SkipDays     []time.Weekday `json:"skipDays,omitempty"`     // days of the week in UTC
+}
+
+// IsEmpty tests if the feed declared no hints at all.
+func (z *PublisherHints) IsEmpty() bool {
+	return z == nil || (z.TTL == 0 && z.UpdatePeriod == 0 && len(z.SkipHours) == 0 && len(z.SkipDays) == 0)
+}
+
+// MinInterval returns the shortest interval between fetches the publisher asks for, zero if none.
+func (z *PublisherHints) MinInterval() time.Duration {
+	if z == nil {
+		return 0
+	}
+	if z.UpdatePeriod > z.TTL {
+		return z.UpdatePeriod
+	}
+	return z.TTL
+}
+
+// Next returns the given time if it does not fall in a skipped hour or day, otherwise the start of the next hour which does.
+// If every hour is skipped, the hints are ignored and the given time is returned.
+func (z *PublisherHints) Next(t time.Time) time.Time {
+
+	if z == nil || (len(z.SkipHours) == 0 && len(z.SkipDays) == 0) {
+		return t
+	}
+
+	u := t.UTC()
+	for i := 0; i <= 7*24; i++ {
+		if !z.skipped(u) {
+			if i == 0 {
+				return t
+			}
+			return u.In(t.Location())
+		}
+		u = u.Truncate(time.Hour).Add(time.Hour)
+	}
+
+	return t
+
+}
+
+func (z *PublisherHints) skipped(t time.Time) bool {
+	for _, day := range z.SkipDays {
+		if t.Weekday() == day {
+			return true
+		}
+	}
+	for _, hour := range z.SkipHours {
+		if t.Hour() == hour {
+			return true
+		}
+	}
+	return false
+}
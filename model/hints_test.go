@@ -0,0 +1,84 @@
+package model
+
+import (
+	"testing"
+	"time"
+)
+
+func TestPublisherHintsNext(t *testing.T) {
+
+	t.Parallel()
+
+	// Friday 2016-03-04 22:30 UTC
+	friday := time.Date(2016, time.March, 4, 22, 30, 0, 0, time.UTC)
+
+	var nilHints *PublisherHints
+	if !nilHints.IsEmpty() || !nilHints.Next(friday).Equal(friday) || nilHints.MinInterval() != 0 {
+		t.Error("Nil hints not ignored")
+	}
+
+	hints := &PublisherHints{
+		SkipHours: []int{22, 23},
+		SkipDays:  []time.Weekday{time.Saturday},
+	}
+
+	// skips the remaining hours of friday and all of saturday
+	expected := time.Date(2016, time.March, 6, 0, 0, 0, 0, time.UTC)
+	if next := hints.Next(friday); !next.Equal(expected) {
+		t.Errorf("Expected %s, actual %s", expected, next)
+	}
+
+	// not skipped
+	thursday := friday.AddDate(0, 0, -1).Add(-12 * time.Hour)
+	if next := hints.Next(thursday); !next.Equal(thursday) {
+		t.Errorf("Expected %s, actual %s", thursday, next)
+	}
+
+	// skip hours are in UTC regardless of the given location
+	location := time.FixedZone("UTC+2", 2*60*60)
+	if next := hints.Next(friday.In(location)); !next.Equal(expected) {
+		t.Errorf("Expected %s, actual %s", expected, next)
+	}
+
+	// every hour skipped
+	hints.SkipDays = []time.Weekday{time.Sunday, time.Monday, time.Tuesday, time.Wednesday, time.Thursday, time.Friday, time.Saturday}
+	if next := hints.Next(friday); !next.Equal(friday) {
+		t.Errorf("Expected %s, actual %s", friday, next)
+	}
+
+}
+
+func TestFeedApplyHints(t *testing.T) {
+
+	t.Parallel()
+
+	now := time.Now().Truncate(time.Second)
+
+	feed := F.New("http://localhost/")
+	feed.NextFetch = now.Add(15 * time.Minute)
+
+	// no hints
+	feed.ApplyHints(24 * time.Hour)
+	if !feed.NextFetch.Equal(now.Add(15 * time.Minute)) {
+		t.Errorf("NextFetch changed without hints: %s", feed.NextFetch)
+	}
+
+	// the larger of ttl and update period
+	feed.Hints = &PublisherHints{TTL: time.Hour, UpdatePeriod: 2 * time.Hour}
+	if interval := feed.Hints.MinInterval(); interval != 2*time.Hour {
+		t.Errorf("Bad minimum interval: %s", interval)
+	}
+	feed.ApplyHints(24 * time.Hour)
+	if feed.NextFetch.Before(now.Add(2 * time.Hour)) {
+		t.Errorf("NextFetch not delayed: %s", feed.NextFetch)
+	}
+
+	// capped by max
+	feed.NextFetch = now
+	feed.Hints = &PublisherHints{TTL: 7 * 24 * time.Hour}
+	feed.ApplyHints(6 * time.Hour)
+	if feed.NextFetch.After(time.Now().Add(6 * time.Hour)) {
+		t.Errorf("NextFetch not capped: %s", feed.NextFetch)
+	}
+
+}
@@ -4,31 +4,112 @@ import (
 	"crypto/sha256"
 	"encoding/hex"
 	"encoding/json"
+	"strings"
 	"time"
 )
 
 const (
-	entityItem    = "Item"
-	indexItemGUID = "GUID"
+	entityItem            = "Item"
+	indexItemGUID         = "GUID"
+	indexItemExtractNext  = "ExtractNext"
+	indexItemFeedCategory = "FeedCategory"
 )
 
 var (
 	indexesItem = []string{
-		indexItemGUID,
+		indexItemExtractNext, indexItemFeedCategory, indexItemGUID,
 	}
 )
 
 // Item from a feed
 type Item struct {
-	ID      string    `json:"id"`
-	GUID    string    `json:"guid" `
-	FeedID  string    `json:"feedId"`
-	Created time.Time `json:"created,omitempty"`
-	Updated time.Time `json:"updated,omitempty"`
-	URL     string    `json:"url,omitempty"`
-	Author  string    `json:"author,omitempty"`
-	Title   string    `json:"title,omitempty"`
-	Content string    `json:"content,omitempty"`
+	ID              string     `json:"id"`
+	GUID            string     `json:"guid" `
+	FeedID          string     `json:"feedId"`
+	Created         time.Time  `json:"created,omitempty"`
+	Updated         time.Time  `json:"updated,omitempty"`
+	URL             string     `json:"url,omitempty"`
+	Author          string     `json:"author,omitempty"`
+	Title           string     `json:"title,omitempty"`
+	Categories      []string   `json:"categories,omitempty"`
+	Content         string     `json:"content,omitempty"`
+	RawContent      string     `json:"rawContent,omitempty"`      // content as published, if altered by sanitizing
+	Sanitized       string     `json:"sanitized,omitempty"`       // fingerprint of the sanitize policy applied to the content
+	FullContent     string     `json:"fullContent,omitempty"`     // article extracted from the item URL
+	RawFullContent  string     `json:"rawFullContent,omitempty"`  // article as extracted, if altered by sanitizing
+	ExtractNext     time.Time  `json:"extractNext,omitempty"`     // time of the next extraction attempt, zero if none pending
+	ExtractAttempts int        `json:"extractAttempts,omitempty"` // failed extraction attempts
+	Enclosures      Enclosures `json:"enclosures,omitempty"`
+	Episode         string     `json:"episode,omitempty"` // podcast episode number
+	Image           string     `json:"image,omitempty"`   // podcast episode artwork
+}
+
+// BestContent returns the extracted full article if requested and available, otherwise the content from the feed.
+// The article is extracted once for all subscribers, but only shown to those having chosen full text.
+func (z *Item) BestContent(fullText bool) string {
+	if fullText && len(z.FullContent) > 0 {
+		return z.FullContent
+	}
+	return z.Content
+}
+
+// CopyExtraction carries the extracted article and pending extraction state over from a previous version of the item.
+func (z *Item) CopyExtraction(item *Item) {
+	z.FullContent = item.FullContent
+	z.RawFullContent = item.RawFullContent
+	z.ExtractNext = item.ExtractNext
+	z.ExtractAttempts = item.ExtractAttempts
+}
+
+// OriginalContent returns the content as published by the feed, before sanitizing.
+func (z *Item) OriginalContent() string {
+	if len(z.RawContent) > 0 {
+		return z.RawContent
+	}
+	return z.Content
+}
+
+// Sanitize replaces the content with the sanitized original content, recording the fingerprint of the policy.
+// The original is kept if it differs so that it can be sanitized again when the policy changes.
+func (z *Item) Sanitize(fingerprint string, sanitize func(string) string) {
+	original := z.OriginalContent()
+	z.Content = sanitize(original)
+	z.RawContent = empty
+	if z.Content != original {
+		z.RawContent = original
+	}
+	z.SanitizeFullContent(sanitize)
+	z.Sanitized = fingerprint
+}
+
+// SanitizeFullContent replaces the extracted article with the sanitized article as extracted,
+// keeping the original if it differs so that it can be sanitized again when the policy changes.
+func (z *Item) SanitizeFullContent(sanitize func(string) string) {
+	original := z.RawFullContent
+	if len(original) == 0 {
+		original = z.FullContent
+	}
+	z.FullContent = empty
+	z.RawFullContent = empty
+	if len(original) > 0 {
+		z.FullContent = sanitize(original)
+		if z.FullContent != original {
+			z.RawFullContent = original
+		}
+	}
+}
+
+// HasCategory tests if the item has any of the given categories, ignoring case.
+func (z *Item) HasCategory(categories ...string) bool {
+	for _, category := range categories {
+		key := categoryKey(category)
+		for _, value := range z.Categories {
+			if key != empty && categoryKey(value) == key {
+				return true
+			}
+		}
+	}
+	return false
 }
 
 // GetID returns the unique ID for the object
@@ -40,7 +121,7 @@ func (z *Item) GetID() string {
 func (z *Item) Hash() string {
 	hash := sha256.New()
 	hash.Write([]byte(z.Author))
-	hash.Write([]byte(z.Content))
+	hash.Write([]byte(z.OriginalContent()))
 	hash.Write([]byte(z.Title))
 	hash.Write([]byte(z.URL))
 	return hex.EncodeToString(hash.Sum(nil))
@@ -55,7 +136,17 @@ func (z *Item) clear() {
 	z.URL = empty
 	z.Author = empty
 	z.Title = empty
+	z.Categories = nil
 	z.Content = empty
+	z.RawContent = empty
+	z.Sanitized = empty
+	z.FullContent = empty
+	z.RawFullContent = empty
+	z.ExtractNext = time.Time{}
+	z.ExtractAttempts = 0
+	z.Enclosures = nil
+	z.Episode = empty
+	z.Image = empty
 }
 
 func (z *Item) decode(data []byte) error {
@@ -77,6 +168,21 @@ func (z *Item) hasIncrementingID() bool {
 func (z *Item) indexes() map[string][]string {
 	result := make(map[string][]string)
 	result[indexItemGUID] = []string{z.FeedID, z.GUID}
+	if !z.ExtractNext.IsZero() {
+		result[indexItemExtractNext] = []string{keyEncodeTime(z.ExtractNext), z.ID}
+	}
+	return result
+}
+
+func (z *Item) multiIndexes() map[string][][]string {
+	result := make(map[string][][]string)
+	seen := make(map[string]bool)
+	for _, category := range z.Categories {
+		if key := categoryKey(category); key != empty && !seen[key] {
+			seen[key] = true
+			result[indexItemFeedCategory] = append(result[indexItemFeedCategory], []string{z.FeedID, key, z.ID})
+		}
+	}
 	return result
 }
 
@@ -89,6 +195,11 @@ func (z *Item) setID(tx Transaction) error {
 	return nil
 }
 
+// categoryKey normalizes a category for comparison and indexing.
+func categoryKey(category string) string {
+	return strings.ToLower(strings.Replace(strings.TrimSpace(category), chSep, " ", -1))
+}
+
 // Items is a collection Item objects
 type Items []*Item
 
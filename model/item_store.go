@@ -2,6 +2,8 @@ package model
 
 import (
 	"bytes"
+	"strings"
+	"time"
 )
 
 // I groups all item database methods
@@ -50,6 +52,85 @@ func (z *itemStore) GetForFeed(tx Transaction, feedID string) Items {
 	return items
 }
 
+// GetCategories returns the categories of the items of the given feed, normalized to lower case.
+func (z *itemStore) GetCategories(tx Transaction, feedID string) []string {
+	// index Item FeedCategory = FeedID|Category|ItemID : ItemID
+	var categories []string
+	min, max := keyMinMax(feedID)
+	c := tx.Bucket(bucketIndex, entityItem, indexItemFeedCategory).Cursor()
+	for k, _ := c.Seek(min); k != nil && bytes.Compare(k, max) <= 0; k, _ = c.Next() {
+		fields := strings.Split(string(k), chSep)
+		if len(fields) == 3 && (len(categories) == 0 || categories[len(categories)-1] != fields[1]) {
+			categories = append(categories, fields[1])
+		}
+	}
+	return categories
+}
+
+// GetForFeedCategory returns the items of the given feed having the given category, ignoring case.
+func (z *itemStore) GetForFeedCategory(tx Transaction, feedID, category string) Items {
+	items := Items{}
+	for _, itemID := range z.getIDsForFeedCategory(tx, feedID, category) {
+		if item := z.Get(tx, itemID); item != nil {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+func (z *itemStore) getIDsForFeedCategory(tx Transaction, feedID, category string) []string {
+	// index Item FeedCategory = FeedID|Category|ItemID : ItemID
+	var itemIDs []string
+	key := categoryKey(category)
+	if key == empty {
+		return itemIDs
+	}
+	min, max := keyMinMax(keyEncode(feedID, key) + chSep)
+	c := tx.Bucket(bucketIndex, entityItem, indexItemFeedCategory).Cursor()
+	for k, v := c.Seek(min); k != nil && bytes.Compare(k, max) <= 0; k, v = c.Next() {
+		itemIDs = append(itemIDs, string(v))
+	}
+	return itemIDs
+}
+
+// GetExtractable returns the items whose full article extraction is due by the given max time.
+func (z *itemStore) GetExtractable(tx Transaction, maxTime time.Time) Items {
+	// index Item ExtractNext = ExtractNext|ItemID : ItemID
+	items := Items{}
+	nxt := []byte(keyEncodeTime(maxTime.Add(1 * time.Second).Truncate(time.Second)))
+	c := tx.Bucket(bucketIndex, entityItem, indexItemExtractNext).Cursor()
+	for k, v := c.First(); k != nil && bytes.Compare(k, nxt) < 0; k, v = c.Next() {
+		if item := z.Get(tx, string(v)); item != nil {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// GetUnsanitized examines up to max items following afterID, empty to start with the first item,
+// and returns those not sanitized with the given policy fingerprint,
+// together with the ID of the last item examined, empty if no items remain.
+func (z *itemStore) GetUnsanitized(tx Transaction, fingerprint, afterID string, max int) (Items, string) {
+	items := Items{}
+	lastID := empty
+	c := tx.Bucket(bucketData, entityItem).Cursor()
+	k, v := c.First()
+	if afterID != empty {
+		if k, v = c.Seek([]byte(afterID)); k != nil && string(k) == afterID {
+			k, v = c.Next()
+		}
+	}
+	for n := 0; k != nil && n < max; k, v = c.Next() {
+		n++
+		lastID = string(k)
+		item := &Item{}
+		if err := item.decode(v); err == nil && item.Sanitized != fingerprint {
+			items = append(items, item)
+		}
+	}
+	return items, lastID
+}
+
 func (z *itemStore) GetByEntries(tx Transaction, entries Entries) Items {
 	result := Items{}
 	for _, entry := range entries {
@@ -2,6 +2,7 @@ package model
 
 import (
 	"fmt"
+	"strings"
 	"testing"
 	"time"
 )
@@ -349,3 +350,208 @@ func TestItemHashEmpty(t *testing.T) {
 	}
 
 }
+
+func TestItemGetExtractable(t *testing.T) {
+
+	t.Parallel()
+
+	db := openTestDatabase(t)
+	defer closeTestDatabase(t, db)
+
+	now := time.Now().Truncate(time.Second)
+
+	if err := db.Update(func(tx Transaction) error {
+		for i, next := range []time.Time{now.Add(-time.Minute), now.Add(time.Hour), {}} {
+			item := I.New("0000000001", fmt.Sprintf("guid%d", i))
+			item.ExtractNext = next
+			if err := I.Save(tx, item); err != nil {
+				return err
+			}
+		}
+		return nil
+	}); err != nil {
+		t.Fatalf("error adding items: %s", err.Error())
+	}
+
+	if err := db.Update(func(tx Transaction) error {
+
+		items := I.GetExtractable(tx, now)
+		if len(items) != 1 {
+			t.Fatalf("Bad extractable count, expected %d, actual %d", 1, len(items))
+		}
+		if items[0].GUID != "guid0" {
+			t.Errorf("Bad extractable item: %s", items[0].GUID)
+		}
+
+		// completed extraction removes the item from the index
+		items[0].FullContent = "full"
+		items[0].ExtractNext = time.Time{}
+		if err := I.Save(tx, items[0]); err != nil {
+			return err
+		}
+		if items := I.GetExtractable(tx, now.Add(2*time.Hour)); len(items) != 1 || items[0].GUID != "guid1" {
+			t.Errorf("Expected only guid1 to remain extractable, actual %d items", len(items))
+		}
+
+		return nil
+
+	}); err != nil {
+		t.Fatalf("error getting extractable items: %s", err.Error())
+	}
+
+}
+
+func TestItemSanitize(t *testing.T) {
+
+	t.Parallel()
+
+	strip := func(value string) string {
+		return strings.Replace(value, "<script></script>", "", -1)
+	}
+
+	item := I.New("0000000001", "guid1")
+	item.Content = "<p>Hello</p><script></script>"
+	item.FullContent = "<p>Article</p><script></script>"
+	hash := item.Hash()
+
+	item.Sanitize("1", strip)
+	if item.Content != "<p>Hello</p>" || item.RawContent != "<p>Hello</p><script></script>" || item.Sanitized != "1" {
+		t.Errorf("Bad sanitized item: %s, %s, %s", item.Content, item.RawContent, item.Sanitized)
+	}
+	if item.FullContent != "<p>Article</p>" || item.RawFullContent != "<p>Article</p><script></script>" {
+		t.Errorf("Bad sanitized article: %s, %s", item.FullContent, item.RawFullContent)
+	}
+	if item.Hash() != hash {
+		t.Error("Sanitizing changed the item hash")
+	}
+
+	// sanitized again from the original
+	item.Sanitize("2", func(value string) string { return value })
+	if item.Content != "<p>Hello</p><script></script>" || item.RawContent != empty || item.Sanitized != "2" {
+		t.Errorf("Bad sanitized item: %s, %s, %s", item.Content, item.RawContent, item.Sanitized)
+	}
+	if item.FullContent != "<p>Article</p><script></script>" || item.RawFullContent != empty {
+		t.Errorf("Bad sanitized article: %s, %s", item.FullContent, item.RawFullContent)
+	}
+
+}
+
+func TestItemGetUnsanitized(t *testing.T) {
+
+	t.Parallel()
+
+	db := openTestDatabase(t)
+	defer closeTestDatabase(t, db)
+
+	if err := db.Update(func(tx Transaction) error {
+		for i := 1; i <= 5; i++ {
+			item := I.New("0000000001", fmt.Sprintf("guid%d", i))
+			if i%2 == 0 {
+				item.Sanitized = "1"
+			}
+			if err := I.Save(tx, item); err != nil {
+				return err
+			}
+		}
+		return nil
+	}); err != nil {
+		t.Fatalf("Cannot add items: %s", err.Error())
+	}
+
+	var unsanitized Items
+	var batches int
+	if err := db.Select(func(tx Transaction) error {
+		afterID := empty
+		for {
+			items, lastID := I.GetUnsanitized(tx, "1", afterID, 2)
+			if lastID == empty {
+				return nil
+			}
+			unsanitized = append(unsanitized, items...)
+			afterID = lastID
+			batches++
+		}
+	}); err != nil {
+		t.Fatalf("Cannot get items: %s", err.Error())
+	}
+
+	if batches != 3 {
+		t.Errorf("Expected %d batches, actual %d", 3, batches)
+	}
+	if len(unsanitized) != 3 || unsanitized[0].GUID != "guid1" || unsanitized[1].GUID != "guid3" || unsanitized[2].GUID != "guid5" {
+		t.Errorf("Bad unsanitized items: %v", unsanitized)
+	}
+
+}
+
+func TestItemCategories(t *testing.T) {
+
+	t.Parallel()
+
+	db := openTestDatabase(t)
+	defer closeTestDatabase(t, db)
+
+	var itemID string
+	if err := db.Update(func(tx Transaction) error {
+		for i, categories := range [][]string{{"Go", "News"}, {"go", " go "}, {"Sports"}} {
+			item := I.New("0000000001", fmt.Sprintf("guid%d", i+1))
+			item.Categories = categories
+			if err := I.Save(tx, item); err != nil {
+				return err
+			}
+			if i == 0 {
+				itemID = item.ID
+			}
+		}
+		other := I.New("0000000002", "guid1")
+		other.Categories = []string{"Go"}
+		return I.Save(tx, other)
+	}); err != nil {
+		t.Fatalf("Cannot add items: %s", err.Error())
+	}
+
+	if err := db.Select(func(tx Transaction) error {
+		if categories := I.GetCategories(tx, "0000000001"); strings.Join(categories, ",") != "go,news,sports" {
+			t.Errorf("Bad categories: %v", categories)
+		}
+		if items := I.GetForFeedCategory(tx, "0000000001", "GO"); len(items) != 2 {
+			t.Errorf("Bad item count, expected %d, actual %d", 2, len(items))
+		}
+		if items := I.GetForFeedCategory(tx, "0000000001", ""); len(items) != 0 {
+			t.Errorf("Bad item count, expected %d, actual %d", 0, len(items))
+		}
+		return nil
+	}); err != nil {
+		t.Fatalf("Cannot select items: %s", err.Error())
+	}
+
+	// changed categories are reindexed
+	if err := db.Update(func(tx Transaction) error {
+		item := I.Get(tx, itemID)
+		item.Categories = []string{"Sports"}
+		if err := I.Save(tx, item); err != nil {
+			return err
+		}
+		if items := I.GetForFeedCategory(tx, "0000000001", "news"); len(items) != 0 {
+			t.Errorf("Stale category index: %d", len(items))
+		}
+		if items := I.GetForFeedCategory(tx, "0000000001", "sports"); len(items) != 2 {
+			t.Errorf("Bad item count, expected %d, actual %d", 2, len(items))
+		}
+		if err := I.Delete(tx, itemID); err != nil {
+			return err
+		}
+		if items := I.GetForFeedCategory(tx, "0000000001", "sports"); len(items) != 1 {
+			t.Errorf("Bad item count after delete, expected %d, actual %d", 1, len(items))
+		}
+		return nil
+	}); err != nil {
+		t.Fatalf("Cannot update items: %s", err.Error())
+	}
+
+	item := &Item{Categories: []string{"Go", "News"}}
+	if !item.HasCategory("news") || item.HasCategory("sports") || item.HasCategory() || item.HasCategory("") {
+		t.Error("Bad category match")
+	}
+
+}
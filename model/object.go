@@ -20,6 +20,7 @@ const (
 var (
 	allEntities = map[string][]string{
 		entityEntry:        indexesEntry,
+		entityFavicon:      indexesFavicon,
 		entityFeed:         indexesFeed,
 		entityGroup:        indexesGroup,
 		entityItem:         indexesItem,
@@ -39,10 +40,17 @@ type Object interface {
 	setID(Transaction) error
 }
 
+// multiIndexer is implemented by objects with indexes holding several keys per object, one for each value of a list.
+type multiIndexer interface {
+	multiIndexes() map[string][][]string
+}
+
 func getObject(entityName string) Object {
 	switch entityName {
 	case entityEntry:
 		return &Entry{}
+	case entityFavicon:
+		return &Favicon{}
 	case entityFeed:
 		return &Feed{}
 	case entityGroup:
@@ -59,6 +67,14 @@ func getObject(entityName string) Object {
 	return nil
 }
 
+func copyObject(src, dst Object) error {
+	data, err := src.encode()
+	if err != nil {
+		return err
+	}
+	return dst.decode(data)
+}
+
 func deleteObject(tx Transaction, entityName string, id string) error {
 
 	if id != empty {
@@ -77,10 +93,12 @@ func deleteObject(tx Transaction, entityName string, id string) error {
 			}
 
 			// delete indexes
-			for indexName, indexKeys := range object.indexes() {
+			for indexName, keys := range indexKeys(object) {
 				bIndex := bIndexes.Bucket(indexName)
-				if err := bIndex.Delete([]byte(keyEncode(indexKeys...))); err != nil {
-					return err
+				for _, key := range keys {
+					if err := bIndex.Delete([]byte(key)); err != nil {
+						return err
+					}
 				}
 			}
 
@@ -97,6 +115,22 @@ func deleteObject(tx Transaction, entityName string, id string) error {
 
 }
 
+// indexKeys returns the encoded keys of the object mapped by index name, including those of multiple key indexes.
+func indexKeys(object Object) map[string][]string {
+	result := make(map[string][]string)
+	for indexName, values := range object.indexes() {
+		result[indexName] = []string{keyEncode(values...)}
+	}
+	if m, ok := object.(multiIndexer); ok {
+		for indexName, valueLists := range m.multiIndexes() {
+			for _, values := range valueLists {
+				result[indexName] = append(result[indexName], keyEncode(values...))
+			}
+		}
+	}
+	return result
+}
+
 func keyEncode(values ...string) string {
 	return strings.Join(values, chSep)
 }
@@ -143,10 +177,12 @@ func saveObject(tx Transaction, entityName string, object Object) error {
 			if err := object.decode(olddata); err != nil {
 				return err
 			}
-			for indexName, indexKeys := range object.indexes() {
+			for indexName, keys := range indexKeys(object) {
 				bIndex := bIndexes.Bucket(indexName)
-				if err := bIndex.Delete([]byte(keyEncode(indexKeys...))); err != nil {
-					return err
+				for _, key := range keys {
+					if err := bIndex.Delete([]byte(key)); err != nil {
+						return err
+					}
 				}
 			}
 		} // olddata not nil
@@ -175,10 +211,12 @@ func saveObject(tx Transaction, entityName string, object Object) error {
 	}
 
 	// save new indexes
-	for indexName, indexKeys := range object.indexes() {
+	for indexName, keys := range indexKeys(object) {
 		bIndex := bIndexes.Bucket(indexName)
-		if err := bIndex.Put([]byte(keyEncode(indexKeys...)), []byte(object.GetID())); err != nil {
-			return err
+		for _, key := range keys {
+			if err := bIndex.Put([]byte(key), []byte(object.GetID())); err != nil {
+				return err
+			}
 		}
 	}
 
@@ -0,0 +1,56 @@
+package model
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"strings"
+	"time"
+)
+
+// Push holds the WebSub (PubSubHubbub) subscription of a feed advertising a hub.
+type Push struct {
+	Hub          string    `json:"hub"`
+	Topic        string    `json:"topic"`                  // self URL of the feed as advertised
+	Secret       string    `json:"secret,omitempty"`       // key of the HMAC signatures of pushed content
+	Next         time.Time `json:"next,omitempty"`         // time of the next subscription request, zero if none pending
+	Verified     time.Time `json:"verified,omitempty"`     // time the hub last confirmed the subscription
+	LeaseExpires time.Time `json:"leaseExpires,omitempty"` // end of the confirmed subscription
+	LastPush     time.Time `json:"lastPush,omitempty"`     // time content was last received from the hub
+	Missed       time.Time `json:"missed,omitempty"`       // time polling last found items which had not been pushed
+}
+
+// Active tests if the hub can be relied upon to push new items: the lease is current
+// and polling has not found any items missed by the hub since the subscription was confirmed.
+func (z *Push) Active(now time.Time) bool {
+	if z == nil || !z.LeaseExpires.After(now) {
+		return false
+	}
+	return z.Missed.IsZero() || z.Missed.Before(z.Verified) || z.Missed.Before(z.LastPush)
+}
+
+// SetHub records the WebSub hub advertised by the feed, scheduling a new subscription if the hub or topic changed.
+// An empty hub removes the subscription, as does a hub not using https: the secret of the signatures
+// of pushed content is sent to the hub and must not be disclosed.
+func (z *Feed) SetHub(hub, topic string) {
+	switch {
+	case hub == empty || !strings.HasPrefix(strings.ToLower(hub), "https://"):
+		z.Push = nil
+	case z.Push != nil && z.Push.Hub == hub && z.Push.Topic == topic:
+		// unchanged
+	default:
+		z.Push = &Push{
+			Hub:    hub,
+			Topic:  topic,
+			Secret: newSecret(),
+			Next:   time.Now().Truncate(time.Second),
+		}
+	}
+}
+
+func newSecret() string {
+	data := make([]byte, 20)
+	if _, err := rand.Read(data); err != nil {
+		return empty
+	}
+	return hex.EncodeToString(data)
+}
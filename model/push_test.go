@@ -0,0 +1,125 @@
+package model
+
+import (
+	"testing"
+	"time"
+)
+
+func TestPushActive(t *testing.T) {
+
+	t.Parallel()
+
+	now := time.Now()
+
+	var push *Push
+	if push.Active(now) {
+		t.Error("nil subscription cannot be active")
+	}
+
+	push = &Push{}
+	if push.Active(now) {
+		t.Error("unverified subscription cannot be active")
+	}
+
+	push.Verified = now.Add(-time.Hour)
+	push.LeaseExpires = now.Add(time.Hour)
+	if !push.Active(now) {
+		t.Error("verified subscription should be active")
+	}
+
+	push.Missed = now.Add(-time.Minute)
+	if push.Active(now) {
+		t.Error("subscription with missed items cannot be active")
+	}
+
+	push.LastPush = now
+	if !push.Active(now) {
+		t.Error("subscription pushing again should be active")
+	}
+
+	if push.Active(now.Add(2 * time.Hour)) {
+		t.Error("expired subscription cannot be active")
+	}
+
+}
+
+func TestFeedSetHub(t *testing.T) {
+
+	t.Parallel()
+
+	feed := F.New("http://localhost/feed")
+
+	feed.SetHub("https://hub/", "http://localhost/feed")
+	if feed.Push == nil || feed.Push.Next.IsZero() || len(feed.Push.Secret) == 0 {
+		t.Fatal("Subscription not scheduled")
+	}
+	push := feed.Push
+
+	feed.SetHub("https://hub/", "http://localhost/feed")
+	if feed.Push != push {
+		t.Error("Unchanged hub must keep the subscription")
+	}
+
+	feed.SetHub("https://otherhub/", "http://localhost/feed")
+	if feed.Push == push || feed.Push.Hub != "https://otherhub/" || feed.Push.Secret == push.Secret {
+		t.Error("Changed hub must replace the subscription")
+	}
+
+	feed.SetHub("http://otherhub/", "http://localhost/feed")
+	if feed.Push != nil {
+		t.Error("Hub without https must remove the subscription")
+	}
+
+	feed.SetHub("https://hub/", "http://localhost/feed")
+	feed.SetHub("", "")
+	if feed.Push != nil {
+		t.Error("Missing hub must remove the subscription")
+	}
+
+}
+
+func TestFeedGetPushNext(t *testing.T) {
+
+	t.Parallel()
+
+	db := openTestDatabase(t)
+	defer closeTestDatabase(t, db)
+
+	now := time.Now().Truncate(time.Second)
+
+	if err := db.Update(func(tx Transaction) error {
+		for i, next := range []time.Time{now.Add(-time.Minute), now.Add(time.Hour), {}} {
+			feed := F.New("http://localhost/feed" + string('a'+rune(i)))
+			feed.Push = &Push{Hub: "https://hub/", Next: next}
+			if err := F.Save(tx, feed); err != nil {
+				return err
+			}
+		}
+		return F.Save(tx, F.New("http://localhost/nohub"))
+	}); err != nil {
+		t.Fatalf("error adding feeds: %s", err.Error())
+	}
+
+	if err := db.Update(func(tx Transaction) error {
+
+		feeds := F.GetPushNext(tx, now)
+		if len(feeds) != 1 || feeds[0].URL != "http://localhost/feeda" {
+			t.Fatalf("Bad feeds due, expected feeda only, actual %d feeds", len(feeds))
+		}
+
+		// removing the subscription removes the feed from the index
+		feeds[0].Push = nil
+		if err := F.Save(tx, feeds[0]); err != nil {
+			return err
+		}
+		if feeds := F.GetPushNext(tx, now.Add(2*time.Hour)); len(feeds) != 1 || feeds[0].URL != "http://localhost/feedb" {
+			t.Errorf("Expected only feedb to be due, actual %d feeds", len(feeds))
+		}
+
+		return nil
+
+	}); err != nil {
+		t.Fatalf("error getting feeds: %s", err.Error())
+	}
+
+}
@@ -0,0 +1,12 @@
+package model
+
+// ScrapeRules define how items are extracted from a web page which has no feed.
+// Each rule is a selector (see package scraper); all rules except Item are relative to the item container.
+type ScrapeRules struct {
+	Item       string `json:"item"`                 // selects the item containers
+	Title      string `json:"title,omitempty"`      // defaults to the text of the link
+	Link       string `json:"link,omitempty"`       // defaults to the first anchor within the item
+	Date       string `json:"date,omitempty"`       // item published date
+	DateFormat string `json:"dateFormat,omitempty"` // Go time layout of the date, parsed like feed dates if empty
+	Content    string `json:"content,omitempty"`    // item content as HTML, defaults to no content
+}
@@ -1,8 +1,19 @@
 package model
 
+import (
+	"golang.org/x/net/context"
+)
+
 // Service standardizes the service interface.
 type Service interface {
 	Start() error
 	Stop()
 	IsRunning() bool
 }
+
+// DrainingService is a service which finishes its pending work before stopping.
+// Shutdown abandons the remaining work once the context is done and returns the context error.
+type DrainingService interface {
+	Service
+	Shutdown(ctx context.Context) error
+}
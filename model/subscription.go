@@ -27,6 +27,10 @@ type Subscription struct {
 	Notes    string    `json:"notes,omitempty"`
 	AutoRead bool      `json:"autoread,omitempty"`
 	AutoStar bool      `json:"autostar,omitempty"`
+	FullText bool      `json:"fulltext,omitempty"` // extract the full article of new items
+	// new items having any of these categories are marked read or starred, regardless of AutoRead and AutoStar
+	AutoReadCategories []string `json:"autoreadCategories,omitempty"`
+	AutoStarCategories []string `json:"autostarCategories,omitempty"`
 }
 
 // AddGroup adds the subscription to the given group.
@@ -72,6 +76,9 @@ func (z *Subscription) clear() {
 	z.Notes = empty
 	z.AutoRead = false
 	z.AutoStar = false
+	z.FullText = false
+	z.AutoReadCategories = nil
+	z.AutoStarCategories = nil
 }
 
 func (z *Subscription) decode(data []byte) error {
@@ -103,6 +110,16 @@ func (z *Subscription) setID(tx Transaction) error {
 // Subscriptions is a collection of Subscription objects.
 type Subscriptions []*Subscription
 
+// HasFullText tests if any of the subscriptions requests full article extraction.
+func (z Subscriptions) HasFullText() bool {
+	for _, subscription := range z {
+		if subscription.FullText {
+			return true
+		}
+	}
+	return false
+}
+
 func (z Subscriptions) Len() int      { return len(z) }
 func (z Subscriptions) Swap(i, j int) { z[i], z[j] = z[j], z[i] }
 func (z Subscriptions) Less(i, j int) bool {
@@ -24,29 +24,46 @@ const (
 	FetchResultClientError = "EC" // message contains error text
 	FetchResultServerError = "ES" // check http status code
 	FetchResultFeedError   = "FP" // cannot parse feed
+	FetchResultRateLimited = "RL" // too many requests, check retry after
+	FetchResultTooLarge    = "TL" // response body exceeds the maximum size
+	FetchResultBomb        = "DB" // decompressed body exceeds the maximum size
+	FetchResultTooDeep     = "TD" // feed elements nested deeper than the maximum depth
+	FetchResultTooMany     = "TE" // feed has more entries than the maximum
+	FetchResultNotFeed     = "NF" // response is not a feed, judged by content type or content
 )
 
 // Transmission represents an attempted HTTP request to a feed
 type Transmission struct {
-	ID            string        `json:"id"`
-	FeedID        string        `json:"feedId"`
-	Duration      time.Duration `json:"duration"`
-	Result        string        `json:"result,omitempty"`
-	ResultMessage string        `json:"resultMessage,omitempty"`
-	StartTime     time.Time     `json:"startTime"`
-	URL           string        `json:"url"`
-	ContentLength int           `json:"contentLength,omitempty"`
-	ContentType   string        `json:"contentType,omitempty"`
-	ETag          string        `json:"etag,omitempty"`
-	LastModified  time.Time     `json:"lastModified,omitempty"`
-	StatusCode    int           `json:"statusCode,omitempty"`
-	UsesGzip      bool          `json:"gzip,omitempty"`
-	Flavor        string        `json:"flavor,omitempty"`
-	Generator     string        `json:"generator,omitempty"`
-	Title         string        `json:"title,omitempty"`
-	LastUpdated   time.Time     `json:"lastUpdated,omitempty"`
-	ItemCount     int           `json:"itemCount,omitempty"`
-	NewItems      int           `json:"newItems,omitempty"`
+	ID             string        `json:"id"`
+	FeedID         string        `json:"feedId"`
+	Duration       time.Duration `json:"duration"`
+	Result         string        `json:"result,omitempty"`
+	ResultMessage  string        `json:"resultMessage,omitempty"`
+	StartTime      time.Time     `json:"startTime"`
+	URL            string        `json:"url"`
+	ContentLength  int           `json:"contentLength,omitempty"`
+	ContentType    string        `json:"contentType,omitempty"`
+	ETag           string        `json:"etag,omitempty"`
+	LastModified   time.Time     `json:"lastModified,omitempty"`
+	StatusCode     int           `json:"statusCode,omitempty"`
+	UsesGzip       bool          `json:"gzip,omitempty"`
+	Flavor         string        `json:"flavor,omitempty"`
+	Generator      string        `json:"generator,omitempty"`
+	Title          string        `json:"title,omitempty"`
+	LastUpdated    time.Time     `json:"lastUpdated,omitempty"`
+	ItemCount      int           `json:"itemCount,omitempty"`
+	NewItems       int           `json:"newItems,omitempty"`
+	Proxy          string        `json:"proxy,omitempty"`
+	TLSVersion     string        `json:"tlsVersion,omitempty"`
+	TLSCipherSuite string        `json:"tlsCipherSuite,omitempty"`
+	TLSSubject     string        `json:"tlsSubject,omitempty"`
+	TLSIssuer      string        `json:"tlsIssuer,omitempty"`
+	TLSInsecure    bool          `json:"tlsInsecure,omitempty"`
+	Pushed         bool          `json:"pushed,omitempty"`    // content delivered by a WebSub hub
+	Unchanged      bool          `json:"unchanged,omitempty"` // body identical to the previous one, not parsed
+	Archive        bool          `json:"archive,omitempty"`   // older page of the feed, fetched to backfill a subscription
+	Published      time.Time     `json:"published,omitempty"` // feed-level date declared by the feed
+	BadDates       []string      `json:"badDates,omitempty"`  // date values which could not be parsed
 }
 
 // GetID returns the unique ID for the object
@@ -74,6 +91,17 @@ func (z *Transmission) clear() {
 	z.LastUpdated = time.Time{}
 	z.ItemCount = 0
 	z.NewItems = 0
+	z.Proxy = empty
+	z.TLSVersion = empty
+	z.TLSCipherSuite = empty
+	z.TLSSubject = empty
+	z.TLSIssuer = empty
+	z.TLSInsecure = false
+	z.Pushed = false
+	z.Unchanged = false
+	z.Archive = false
+	z.Published = time.Time{}
+	z.BadDates = nil
 }
 
 func (z *Transmission) decode(data []byte) error {
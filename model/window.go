@@ -0,0 +1,198 @@
+package model
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+const (
+	minutesPerDay = 24 * 60
+)
+
+var (
+	weekdayNames = []string{"sun", "mon", "tue", "wed", "thu", "fri", "sat"}
+)
+
+// FetchWindow defines a time of day, optionally restricted to certain weekdays, during which a feed may be fetched.
+// If End is not after Start, the window extends past midnight into the following day.
+type FetchWindow struct {
+	Weekdays []time.Weekday `json:"weekdays,omitempty"` // all days if empty
+	Start    int            `json:"start"`              // minutes after midnight
+	End      int            `json:"end"`                // minutes after midnight, exclusive
+}
+
+// ParseFetchWindow parses a window in the form "[weekdays] hh:mm-hh:mm",
+// weekdays being a comma-separated list of days or day ranges, for example "mon-fri 06:00-20:00" or "sat,sun 08:00-12:00".
+func ParseFetchWindow(value string) (*FetchWindow, error) {
+
+	fields := strings.Fields(strings.ToLower(value))
+	if len(fields) == 0 || len(fields) > 2 {
+		return nil, fmt.Errorf("Invalid fetch window: %s", value)
+	}
+
+	window := &FetchWindow{}
+
+	if len(fields) == 2 {
+		weekdays, err := parseWeekdays(fields[0])
+		if err != nil {
+			return nil, err
+		}
+		window.Weekdays = weekdays
+	}
+
+	times := strings.Split(fields[len(fields)-1], "-")
+	if len(times) != 2 {
+		return nil, fmt.Errorf("Invalid fetch window times: %s", value)
+	}
+	start, err := parseMinutes(times[0])
+	if err != nil {
+		return nil, err
+	}
+	end, err := parseMinutes(times[1])
+	if err != nil {
+		return nil, err
+	}
+	window.Start = start
+	window.End = end
+
+	return window, nil
+
+}
+
+// Contains tests if the given time lies within the window, t must already be in the local time of the window.
+func (z *FetchWindow) Contains(t time.Time) bool {
+	minutes := t.Hour()*60 + t.Minute()
+	if z.Start < z.End {
+		return z.hasWeekday(t.Weekday()) && minutes >= z.Start && minutes < z.End
+	}
+	yesterday := (t.Weekday() + 6) % 7
+	return (z.hasWeekday(t.Weekday()) && minutes >= z.Start) || (z.hasWeekday(yesterday) && minutes < z.End)
+}
+
+// Next returns the given time if it lies within the window, otherwise the next time the window opens.
+func (z *FetchWindow) Next(t time.Time) time.Time {
+	if z.Contains(t) {
+		return t
+	}
+	for i := 0; i <= 7; i++ {
+		day := t.AddDate(0, 0, i)
+		if !z.hasWeekday(day.Weekday()) {
+			continue
+		}
+		opening := time.Date(day.Year(), day.Month(), day.Day(), z.Start/60, z.Start%60, 0, 0, t.Location())
+		if opening.After(t) {
+			return opening
+		}
+	}
+	return t
+}
+
+func (z *FetchWindow) String() string {
+	result := fmt.Sprintf("%02d:%02d-%02d:%02d", z.Start/60, z.Start%60, z.End/60, z.End%60)
+	if len(z.Weekdays) > 0 {
+		days := []string{}
+		for _, weekday := range z.Weekdays {
+			days = append(days, weekdayNames[weekday])
+		}
+		result = strings.Join(days, ",") + " " + result
+	}
+	return result
+}
+
+func (z *FetchWindow) hasWeekday(weekday time.Weekday) bool {
+	if len(z.Weekdays) == 0 {
+		return true
+	}
+	for _, value := range z.Weekdays {
+		if value == weekday {
+			return true
+		}
+	}
+	return false
+}
+
+// FetchWindows is a collection of FetchWindow objects.
+type FetchWindows []*FetchWindow
+
+// Contains tests if the given time lies within any of the windows, true if there are no windows.
+func (z FetchWindows) Contains(t time.Time) bool {
+	if len(z) == 0 {
+		return true
+	}
+	for _, window := range z {
+		if window.Contains(t) {
+			return true
+		}
+	}
+	return false
+}
+
+// Next returns the earliest time, not before t, at which any of the windows is open.
+func (z FetchWindows) Next(t time.Time) time.Time {
+	var result time.Time
+	for _, window := range z {
+		if next := window.Next(t); result.IsZero() || next.Before(result) {
+			result = next
+		}
+	}
+	if result.IsZero() {
+		return t
+	}
+	return result
+}
+
+// Strings formats the windows as strings which can be parsed by ParseFetchWindow.
+func (z FetchWindows) Strings() []string {
+	result := []string{}
+	for _, window := range z {
+		result = append(result, window.String())
+	}
+	return result
+}
+
+func parseMinutes(value string) (int, error) {
+	t, err := time.Parse("15:04", value)
+	if err != nil {
+		if value == "24:00" {
+			return minutesPerDay, nil
+		}
+		return 0, fmt.Errorf("Invalid time of day: %s", value)
+	}
+	return t.Hour()*60 + t.Minute(), nil
+}
+
+func parseWeekday(value string) (time.Weekday, error) {
+	for i, name := range weekdayNames {
+		if strings.HasPrefix(value, name) {
+			return time.Weekday(i), nil
+		}
+	}
+	return time.Sunday, fmt.Errorf("Invalid weekday: %s", value)
+}
+
+func parseWeekdays(value string) ([]time.Weekday, error) {
+	result := []time.Weekday{}
+	for _, part := range strings.Split(value, ",") {
+		days := strings.Split(part, "-")
+		first, err := parseWeekday(days[0])
+		if err != nil {
+			return nil, err
+		}
+		last := first
+		if len(days) == 2 {
+			if last, err = parseWeekday(days[1]); err != nil {
+				return nil, err
+			}
+		} else if len(days) > 2 {
+			return nil, fmt.Errorf("Invalid weekday range: %s", part)
+		}
+		for day := first; ; day = (day + 1) % 7 {
+			result = append(result, day)
+			if day == last {
+				break
+			}
+		}
+	}
+	return result, nil
+}
@@ -0,0 +1,132 @@
+package model
+
+import (
+	"testing"
+	"time"
+)
+
+func TestFetchWindowParse(t *testing.T) {
+
+	t.Parallel()
+
+	window, err := ParseFetchWindow("Mon-Fri 06:00-20:00")
+	if err != nil {
+		t.Fatalf("Cannot parse window: %s", err.Error())
+	}
+	if len(window.Weekdays) != 5 || window.Weekdays[0] != time.Monday || window.Weekdays[4] != time.Friday {
+		t.Errorf("Bad weekdays: %v", window.Weekdays)
+	}
+	if window.Start != 6*60 || window.End != 20*60 {
+		t.Errorf("Bad times: %d-%d", window.Start, window.End)
+	}
+	if value := window.String(); value != "mon,tue,wed,thu,fri 06:00-20:00" {
+		t.Errorf("Bad string: %s", value)
+	}
+
+	window, err = ParseFetchWindow("fri-mon 22:00-02:00")
+	if err != nil {
+		t.Fatalf("Cannot parse window: %s", err.Error())
+	}
+	if len(window.Weekdays) != 4 {
+		t.Errorf("Bad wrapping weekdays: %v", window.Weekdays)
+	}
+
+	for _, value := range []string{"", "06:00", "mon-fri", "xyz 06:00-20:00", "06:00-25:00", "a b c"} {
+		if _, err := ParseFetchWindow(value); err == nil {
+			t.Errorf("Expected error parsing window: %q", value)
+		}
+	}
+
+}
+
+func TestFetchWindowNext(t *testing.T) {
+
+	t.Parallel()
+
+	window, _ := ParseFetchWindow("mon-fri 06:00-20:00")
+	windows := FetchWindows{window}
+
+	wednesdayNoon := time.Date(2016, time.March, 9, 12, 0, 0, 0, time.UTC)
+	if !windows.Contains(wednesdayNoon) {
+		t.Error("Expected Wednesday noon within window")
+	}
+	if next := windows.Next(wednesdayNoon); !next.Equal(wednesdayNoon) {
+		t.Errorf("Bad next time within window: %s", next)
+	}
+
+	fridayNight := time.Date(2016, time.March, 11, 21, 0, 0, 0, time.UTC)
+	mondayMorning := time.Date(2016, time.March, 14, 6, 0, 0, 0, time.UTC)
+	if windows.Contains(fridayNight) {
+		t.Error("Expected Friday night outside window")
+	}
+	if next := windows.Next(fridayNight); !next.Equal(mondayMorning) {
+		t.Errorf("Bad next time, expected %s, actual %s", mondayMorning, next)
+	}
+
+	overnight, _ := ParseFetchWindow("fri 22:00-02:00")
+	saturdayEarly := time.Date(2016, time.March, 12, 1, 0, 0, 0, time.UTC)
+	if !overnight.Contains(saturdayEarly) {
+		t.Error("Expected Saturday 01:00 within overnight Friday window")
+	}
+
+}
+
+func TestFeedFetchWindows(t *testing.T) {
+
+	t.Parallel()
+
+	window, _ := ParseFetchWindow("mon-fri 06:00-20:00")
+
+	feed := F.New("http://localhost/")
+	feed.Windows = FetchWindows{window}
+	feed.TimeZone = "Europe/Berlin"
+
+	// 19:30 UTC is 20:30 in Berlin during winter
+	feed.NextFetch = time.Date(2016, time.March, 9, 19, 30, 0, 0, time.UTC)
+	if feed.InFetchWindow(feed.NextFetch) {
+		t.Error("Expected time to be outside of window in feed time zone")
+	}
+
+	feed.ApplyFetchWindows()
+	expected := time.Date(2016, time.March, 10, 5, 0, 0, 0, time.UTC)
+	if !feed.NextFetch.Equal(expected) {
+		t.Errorf("Bad next fetch, expected %s, actual %s", expected, feed.NextFetch.UTC())
+	}
+
+}
+
+func TestFeedGetNextPaused(t *testing.T) {
+
+	t.Parallel()
+
+	db := openTestDatabase(t)
+	defer closeTestDatabase(t, db)
+
+	err := db.Update(func(tx Transaction) error {
+		active := F.New("http://localhost/active")
+		paused := F.New("http://localhost/paused")
+		paused.Paused = true
+		if err := F.Save(tx, active); err != nil {
+			return err
+		}
+		return F.Save(tx, paused)
+	})
+	if err != nil {
+		t.Fatalf("Error adding feeds: %s", err.Error())
+	}
+
+	err = db.Select(func(tx Transaction) error {
+		feeds := F.GetNext(tx, time.Now())
+		if len(feeds) != 1 {
+			t.Fatalf("Bad feed count, expected %d, actual %d", 1, len(feeds))
+		}
+		if feeds[0].Paused {
+			t.Error("Paused feed returned by GetNext")
+		}
+		return nil
+	})
+	if err != nil {
+		t.Errorf("Error selecting feeds: %s", err.Error())
+	}
+
+}
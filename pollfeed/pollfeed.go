@@ -7,6 +7,7 @@ import (
 
 	"github.com/kwo/rakewire/logger"
 	"github.com/kwo/rakewire/model"
+	"golang.org/x/net/context"
 )
 
 var (
@@ -25,12 +26,14 @@ type Service struct {
 	database     model.Database
 	batchMax     int
 	pollInterval time.Duration
-	killsignal   chan bool
-	killed       int32
+	ctx          context.Context
+	cancel       context.CancelFunc
 	running      int32
-	runlatch     sync.WaitGroup
+	done         chan struct{} // closed when run exits
 	polling      int32
 	polllatch    sync.WaitGroup
+	lastPoll     int64 // unix nanoseconds
+	queued       int32 // polled feeds waiting for a fetcher
 }
 
 // NewService create a new service
@@ -41,7 +44,6 @@ func NewService(cfg *Configuration, database model.Database) *Service {
 		batchMax:     cfg.BatchMax,
 		database:     database,
 		pollInterval: time.Duration(cfg.IntervalSeconds) * time.Second,
-		killsignal:   make(chan bool),
 	}
 
 }
@@ -53,8 +55,9 @@ func (z *Service) Start() error {
 	log.Infof("batch max: %d", z.batchMax)
 	log.Infof("interval:  %s", z.pollInterval.String())
 
+	z.ctx, z.cancel = context.WithCancel(context.Background())
+	z.done = make(chan struct{})
 	z.setRunning(true)
-	z.runlatch.Add(1)
 	go z.run()
 	log.Infof("started")
 	return nil
@@ -62,18 +65,30 @@ func (z *Service) Start() error {
 
 // Stop service
 func (z *Service) Stop() {
+	z.Shutdown(context.Background())
+}
+
+// Shutdown stops polling and abandons the feeds of the current poll not yet accepted by a fetcher.
+// The output is closed once the poll has returned.
+func (z *Service) Shutdown(ctx context.Context) error {
 
 	if !z.IsRunning() {
 		log.Debugf("service already stopped, exiting...")
-		return
+		return nil
 	}
 
 	log.Debugf("stopping...")
-	log.Debugf("killing...")
-	z.kill()
-	log.Debugf("waiting on latch")
-	z.runlatch.Wait()
-	log.Infof("stopped")
+	z.cancel()
+
+	select {
+	case <-z.done:
+		log.Infof("stopped")
+		return nil
+	case <-ctx.Done():
+		log.Infof("stop timed out: %s", ctx.Err().Error())
+		return ctx.Err()
+	}
+
 }
 
 func (z *Service) run() {
@@ -98,7 +113,7 @@ run:
 			} else {
 				log.Debugf("Polling still in progress, skipping")
 			}
-		case <-z.killsignal:
+		case <-z.ctx.Done():
 			break run
 		}
 	}
@@ -109,13 +124,15 @@ run:
 	close(z.Output)
 
 	z.setRunning(false)
-	z.runlatch.Done()
+	close(z.done)
 	log.Debugf("run exited")
 
 }
 
 func (z *Service) poll(t time.Time) {
 
+	atomic.StoreInt64(&z.lastPoll, time.Now().UnixNano())
+
 	err := z.database.Select(func(tx model.Transaction) error {
 
 		// get next feeds
@@ -131,10 +148,18 @@ func (z *Service) poll(t time.Time) {
 			log.Infof("polling feeds: %d", numFeeds)
 		}
 
-		// send to output
-		for i := 0; i < len(feeds) && !z.isKilled(); i++ {
-			z.Output <- feeds[i]
+		// send to output, feeds not accepted before shutdown are polled again after restart
+		atomic.StoreInt32(&z.queued, int32(len(feeds)))
+	send:
+		for _, feed := range feeds {
+			select {
+			case z.Output <- feed:
+				atomic.AddInt32(&z.queued, -1)
+			case <-z.ctx.Done():
+				break send
+			}
 		}
+		atomic.StoreInt32(&z.queued, 0)
 
 		z.setPolling(false)
 		z.polllatch.Done()
@@ -149,6 +174,19 @@ func (z *Service) poll(t time.Time) {
 
 }
 
+// Activity reports the time of the last poll and how many polled feeds wait for a fetcher.
+func (z *Service) Activity() *model.PollActivity {
+	result := &model.PollActivity{
+		Running: z.IsRunning(),
+		Polling: z.isPolling(),
+		Queued:  int(atomic.LoadInt32(&z.queued)),
+	}
+	if lastPoll := atomic.LoadInt64(&z.lastPoll); lastPoll != 0 {
+		result.LastPoll = time.Unix(0, lastPoll)
+	}
+	return result
+}
+
 // IsRunning status of the service
 func (z *Service) IsRunning() bool {
 	return atomic.LoadInt32(&z.running) != 0
@@ -159,19 +197,9 @@ func (z *Service) setRunning(running bool) {
 		atomic.StoreInt32(&z.running, 1)
 	} else {
 		atomic.StoreInt32(&z.running, 0)
-		atomic.StoreInt32(&z.killed, 0)
 	}
 }
 
-func (z *Service) kill() {
-	z.killsignal <- true
-	atomic.StoreInt32(&z.killed, 1)
-}
-
-func (z *Service) isKilled() bool {
-	return atomic.LoadInt32(&z.killed) != 0
-}
-
 func (z *Service) isPolling() bool {
 	return atomic.LoadInt32(&z.polling) != 0
 }
@@ -1,12 +1,14 @@
 package pollfeed
 
 import (
+	"fmt"
 	"io/ioutil"
 	"os"
 	"testing"
 	"time"
 
 	"github.com/kwo/rakewire/model"
+	"golang.org/x/net/context"
 )
 
 func TestInterfaceService(t *testing.T) {
@@ -36,6 +38,9 @@ func TestPoll(t *testing.T) {
 		t.Error("Polling service is not running")
 	}
 	time.Sleep(100 * time.Millisecond)
+	if activity := pf.Activity(); activity.LastPoll.IsZero() || activity.Queued != 0 {
+		t.Errorf("Bad activity, last poll: %s, queued: %d", activity.LastPoll, activity.Queued)
+	}
 	pf.Stop()
 	if pf.IsRunning() {
 		t.Error("Polling service is still running")
@@ -43,6 +48,48 @@ func TestPoll(t *testing.T) {
 
 }
 
+func TestShutdownBlocked(t *testing.T) {
+
+	database := openTestDatabase(t)
+	defer closeTestDatabase(t, database)
+
+	// due feeds without a fetcher to accept them
+	err := database.Update(func(tx model.Transaction) error {
+		for i := 0; i < 3; i++ {
+			if err := model.F.Save(tx, model.F.New(fmt.Sprintf("http://localhost/feed%d.xml", i))); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("Cannot save feeds: %s", err.Error())
+	}
+
+	pf := NewService(&Configuration{BatchMax: 10, IntervalSeconds: 1}, database)
+	pf.Start()
+
+	for i := 0; i < 100 && pf.Activity().Queued == 0; i++ {
+		time.Sleep(10 * time.Millisecond)
+	}
+	if queued := pf.Activity().Queued; queued != 3 {
+		t.Fatalf("Bad queue depth, expected %d, actual %d", 3, queued)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	if err := pf.Shutdown(ctx); err != nil {
+		t.Fatalf("Cannot shutdown: %s", err.Error())
+	}
+	if pf.IsRunning() {
+		t.Error("Polling service is still running")
+	}
+	if _, ok := <-pf.Output; ok {
+		t.Error("Output not closed")
+	}
+
+}
+
 func openTestDatabase(t *testing.T) model.Database {
 
 	f, err := ioutil.TempFile("", "bolt-")
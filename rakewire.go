@@ -96,18 +96,171 @@ func main() {
 					EnvVar: "RAKEWIRE_FETCH_WORKERS",
 					Usage:  "fetcher workers",
 				},
+				cli.IntFlag{
+					Name:   "fetch.maxsizekb",
+					Value:  10240,
+					EnvVar: "RAKEWIRE_FETCH_MAXSIZEKB",
+					Usage:  "maximum size of a response as transferred, 0 for no limit",
+				},
+				cli.IntFlag{
+					Name:   "fetch.maxdecompressedkb",
+					Value:  51200,
+					EnvVar: "RAKEWIRE_FETCH_MAXDECOMPRESSEDKB",
+					Usage:  "maximum size of a decompressed response, 0 for no limit",
+				},
+				cli.IntFlag{
+					Name:   "fetch.maxdepth",
+					Value:  64,
+					EnvVar: "RAKEWIRE_FETCH_MAXDEPTH",
+					Usage:  "maximum nesting of feed elements, 0 for no limit",
+				},
+				cli.IntFlag{
+					Name:   "fetch.maxentries",
+					Value:  5000,
+					EnvVar: "RAKEWIRE_FETCH_MAXENTRIES",
+					Usage:  "maximum number of entries in a feed, 0 for no limit",
+				},
+				cli.IntFlag{
+					Name:   "fetch.backfillmaxitems",
+					Value:  500,
+					EnvVar: "RAKEWIRE_FETCH_BACKFILLMAXITEMS",
+					Usage:  "maximum number of older items imported for a new subscription, 0 to disable backfill",
+				},
+				cli.IntFlag{
+					Name:   "fetch.backfilldelaysecs",
+					Value:  5,
+					EnvVar: "RAKEWIRE_FETCH_BACKFILLDELAYSECS",
+					Usage:  "minimum time between backfill requests to the same host",
+				},
+				cli.StringSliceFlag{
+					Name:   "fetch.fileroot",
+					EnvVar: "RAKEWIRE_FETCH_FILEROOT",
+					Usage:  "directory from which file:// feeds added by admins may be read, polled like any feed, file feeds are disabled if none",
+				},
+				cli.StringSliceFlag{
+					Name:   "fetch.exec",
+					EnvVar: "RAKEWIRE_FETCH_EXEC",
+					Usage:  "command producing the exec://<name> feed on stdout, as name=command [args], exec feeds are added by admins",
+				},
+				cli.StringFlag{
+					Name:   "fetch.proxy",
+					EnvVar: "RAKEWIRE_FETCH_PROXY",
+					Usage:  "proxy URL (http, https or socks5) used by the fetcher, defaults to HTTP_PROXY/HTTPS_PROXY",
+				},
+				cli.StringFlag{
+					Name:   "fetch.tlsca",
+					EnvVar: "RAKEWIRE_FETCH_TLSCA",
+					Usage:  "PEM file of additional certificate authorities trusted by the fetcher",
+				},
+				cli.StringFlag{
+					Name:   "fetch.tlscert",
+					EnvVar: "RAKEWIRE_FETCH_TLSCERT",
+					Usage:  "TLS client certificate file used by the fetcher",
+				},
+				cli.StringFlag{
+					Name:   "fetch.tlskey",
+					EnvVar: "RAKEWIRE_FETCH_TLSKEY",
+					Usage:  "TLS client key file used by the fetcher",
+				},
 				cli.IntFlag{
 					Name:   "poll.batchmax",
 					Value:  10,
 					EnvVar: "RAKEWIRE_POLL_BATCHMAX",
 					Usage:  "maximum number of feeds to poll at once",
 				},
+				cli.IntFlag{
+					Name:   "fulltext.workers",
+					Value:  2,
+					EnvVar: "RAKEWIRE_FULLTEXT_WORKERS",
+					Usage:  "full article extraction workers",
+				},
+				cli.IntFlag{
+					Name:   "fulltext.intervalsecs",
+					Value:  15,
+					EnvVar: "RAKEWIRE_FULLTEXT_INTERVALSECS",
+					Usage:  "how often to look for articles to extract",
+				},
+				cli.IntFlag{
+					Name:   "fulltext.hostdelaysecs",
+					Value:  10,
+					EnvVar: "RAKEWIRE_FULLTEXT_HOSTDELAYSECS",
+					Usage:  "minimum time between article requests to the same host",
+				},
+				cli.IntFlag{
+					Name:   "favicon.intervalsecs",
+					Value:  300,
+					EnvVar: "RAKEWIRE_FAVICON_INTERVALSECS",
+					Usage:  "how often to look for feeds without a current icon",
+				},
+				cli.IntFlag{
+					Name:   "favicon.maxagehours",
+					Value:  168,
+					EnvVar: "RAKEWIRE_FAVICON_MAXAGEHOURS",
+					Usage:  "how long feed icons are kept before being retrieved again",
+				},
+				cli.StringFlag{
+					Name:   "websub.callback",
+					EnvVar: "RAKEWIRE_WEBSUB_CALLBACK",
+					Usage:  "public URL of the WebSub callback endpoint, defaults to https://<host>/websub/",
+				},
+				cli.IntFlag{
+					Name:   "websub.intervalsecs",
+					Value:  60,
+					EnvVar: "RAKEWIRE_WEBSUB_INTERVALSECS",
+					Usage:  "how often to look for hub subscriptions to request or renew",
+				},
+				cli.IntFlag{
+					Name:   "websub.leasesecs",
+					Value:  864000,
+					EnvVar: "RAKEWIRE_WEBSUB_LEASESECS",
+					Usage:  "requested duration of hub subscriptions",
+				},
 				cli.IntFlag{
 					Name:   "poll.intervalsecs",
 					Value:  5,
 					EnvVar: "RAKEWIRE_POLL_INTERVALSECS",
 					Usage:  "how often to poll feeds",
 				},
+				cli.IntFlag{
+					Name:   "schedule.minintervalsecs",
+					Value:  300,
+					EnvVar: "RAKEWIRE_SCHEDULE_MININTERVALSECS",
+					Usage:  "minimum time between fetches of a feed",
+				},
+				cli.IntFlag{
+					Name:   "schedule.maxintervalsecs",
+					Value:  21600,
+					EnvVar: "RAKEWIRE_SCHEDULE_MAXINTERVALSECS",
+					Usage:  "maximum time between fetches of a feed",
+				},
+				cli.IntFlag{
+					Name:   "reap.batchmax",
+					Value:  50,
+					EnvVar: "RAKEWIRE_REAP_BATCHMAX",
+					Usage:  "maximum number of feeds saved in one transaction",
+				},
+				cli.IntFlag{
+					Name:   "reap.batchwindowms",
+					Value:  200,
+					EnvVar: "RAKEWIRE_REAP_BATCHWINDOWMS",
+					Usage:  "how long to collect fetched feeds before saving them",
+				},
+				cli.BoolFlag{
+					Name:   "sanitize.stripimages",
+					EnvVar: "RAKEWIRE_SANITIZE_STRIPIMAGES",
+					Usage:  "remove all images from the content of items",
+				},
+				cli.StringSliceFlag{
+					Name:   "sanitize.iframehost",
+					EnvVar: "RAKEWIRE_SANITIZE_IFRAMEHOST",
+					Usage:  "host whose iframes are kept in the content of items, for example www.youtube.com",
+				},
+				cli.IntFlag{
+					Name:   "shutdown.timeoutsecs",
+					Value:  30,
+					EnvVar: "RAKEWIRE_SHUTDOWN_TIMEOUTSECS",
+					Usage:  "time allowed to finish in-flight fetches on shutdown",
+				},
 			},
 			Action: cmd.Start,
 		},
@@ -124,6 +277,62 @@ func main() {
 			},
 			Action: cmd.Check,
 		},
+		{
+			Name:      "validate",
+			Usage:     "parse a feed and report its problems, without a database",
+			ArgsUsage: "<url|file>",
+			Action:    cmd.Validate,
+			Flags: []cli.Flag{
+				cli.BoolFlag{
+					Name:  "json",
+					Usage: "print the report as JSON",
+				},
+				cli.IntFlag{
+					Name:   "fetch.maxsizekb",
+					Value:  10240,
+					EnvVar: "RAKEWIRE_FETCH_MAXSIZEKB",
+					Usage:  "maximum size of a response as transferred, 0 for no limit",
+				},
+				cli.IntFlag{
+					Name:   "fetch.maxdecompressedkb",
+					Value:  51200,
+					EnvVar: "RAKEWIRE_FETCH_MAXDECOMPRESSEDKB",
+					Usage:  "maximum size of a decompressed response, 0 for no limit",
+				},
+				cli.IntFlag{
+					Name:   "fetch.maxdepth",
+					Value:  64,
+					EnvVar: "RAKEWIRE_FETCH_MAXDEPTH",
+					Usage:  "maximum nesting of feed elements, 0 for no limit",
+				},
+				cli.IntFlag{
+					Name:   "fetch.maxentries",
+					Value:  5000,
+					EnvVar: "RAKEWIRE_FETCH_MAXENTRIES",
+					Usage:  "maximum number of entries in a feed, 0 for no limit",
+				},
+				cli.StringFlag{
+					Name:   "fetch.proxy",
+					EnvVar: "RAKEWIRE_FETCH_PROXY",
+					Usage:  "proxy URL (http, https or socks5), defaults to HTTP_PROXY/HTTPS_PROXY",
+				},
+				cli.StringFlag{
+					Name:   "fetch.tlsca",
+					EnvVar: "RAKEWIRE_FETCH_TLSCA",
+					Usage:  "PEM file of additional trusted certificate authorities",
+				},
+				cli.StringFlag{
+					Name:   "fetch.tlscert",
+					EnvVar: "RAKEWIRE_FETCH_TLSCERT",
+					Usage:  "TLS client certificate file",
+				},
+				cli.StringFlag{
+					Name:   "fetch.tlskey",
+					EnvVar: "RAKEWIRE_FETCH_TLSKEY",
+					Usage:  "TLS client key file",
+				},
+			},
+		},
 		{
 			Name:      "useradd",
 			Usage:     "add user",
@@ -171,12 +380,27 @@ func main() {
 				},
 			},
 			Subcommands: []cli.Command{
+				{
+					Name:   "activity",
+					Usage:  "show live fetcher activity (admin only)",
+					Action: remote.Activity,
+				},
 				{
 					Name:      "entries",
 					Aliases:   []string{"e"},
 					Usage:     "list entries",
 					ArgsUsage: "[feed url]",
 					Action:    remote.EntryList,
+					Flags: []cli.Flag{
+						cli.StringFlag{
+							Name:  "category",
+							Usage: "list only the entries having this category",
+						},
+						cli.BoolFlag{
+							Name:  "enclosures",
+							Usage: "list the enclosures of each entry",
+						},
+					},
 				},
 				{
 					Name:   "groups",
@@ -189,6 +413,120 @@ func main() {
 					ArgsUsage: "<feed url> <guid>",
 					Action:    remote.EntryStar,
 				},
+				{
+					Name:      "details",
+					Usage:     "show feed metadata and publisher hints",
+					ArgsUsage: "<url>",
+					Action:    remote.FeedDetails,
+				},
+				{
+					Name:      "validate",
+					Usage:     "fetch a feed and report its problems, without saving anything",
+					ArgsUsage: "<url>",
+					Action:    remote.FeedValidate,
+					Flags: []cli.Flag{
+						cli.BoolFlag{
+							Name:  "json",
+							Usage: "print the report as JSON",
+						},
+					},
+				},
+				{
+					Name:      "refresh",
+					Usage:     "fetch a feed immediately",
+					ArgsUsage: "<url>",
+					Action:    remote.FeedRefresh,
+					Flags: []cli.Flag{
+						cli.BoolFlag{
+							Name:  "all-errors",
+							Usage: "refresh all subscribed feeds with an error status",
+						},
+					},
+				},
+				{
+					Name:      "scrape",
+					Usage:     "preview the items extracted from a web page, subscribe if groups are given",
+					ArgsUsage: "<url> [group[,group]] [title]",
+					Action:    remote.FeedScrape,
+					Flags: []cli.Flag{
+						cli.StringFlag{
+							Name:  "item",
+							Usage: "selector of the item containers, e.g. \"article.post\"",
+						},
+						cli.StringFlag{
+							Name:  "title",
+							Usage: "selector of the item title, defaults to the link text",
+						},
+						cli.StringFlag{
+							Name:  "link",
+							Usage: "selector of the item link, e.g. \"h2 > a@href\"",
+						},
+						cli.StringFlag{
+							Name:  "date",
+							Usage: "selector of the item date, e.g. \"time@datetime\"",
+						},
+						cli.StringFlag{
+							Name:  "date-format",
+							Usage: "Go time layout of the item date, e.g. \"January 2, 2006\"",
+						},
+						cli.StringFlag{
+							Name:  "content",
+							Usage: "selector of the item content",
+						},
+						cli.BoolFlag{
+							Name:  "g, groups",
+							Usage: "add groups if they do not exist",
+						},
+					},
+				},
+				{
+					Name:      "schedule",
+					Usage:     "show or change the fetch schedule and connection settings of a feed",
+					ArgsUsage: "<url>",
+					Action:    remote.FeedSchedule,
+					Flags: []cli.Flag{
+						cli.StringFlag{
+							Name:  "interval",
+							Usage: "fixed fetch interval, e.g. 30m, or auto (admin only)",
+						},
+						cli.StringSliceFlag{
+							Name:  "window",
+							Usage: "add a fetch window, e.g. \"mon-fri 06:00-20:00\" (admin only)",
+						},
+						cli.BoolFlag{
+							Name:  "clear-windows",
+							Usage: "remove all fetch windows (admin only)",
+						},
+						cli.StringFlag{
+							Name:  "timezone",
+							Usage: "time zone of the fetch windows, e.g. Europe/Berlin (admin only)",
+						},
+						cli.StringFlag{
+							Name:  "datezone",
+							Usage: "time zone of feed dates without a zone, e.g. Europe/Berlin (admin only)",
+						},
+						cli.BoolFlag{
+							Name:  "pause",
+							Usage: "stop fetching the feed (admin only)",
+						},
+						cli.BoolFlag{
+							Name:  "resume",
+							Usage: "resume fetching the feed (admin only)",
+						},
+						cli.StringFlag{
+							Name:  "proxy",
+							Usage: "proxy URL (http, https or socks5) used for the feed, or default (admin only)",
+						},
+						cli.BoolFlag{
+							Name:  "insecure",
+							Usage: "skip verification of the TLS certificate of the feed (admin only)",
+						},
+						cli.BoolFlag{
+							Name:  "verify",
+							Usage: "verify the TLS certificate of the feed again (admin only)",
+						},
+					},
+				},
 				{
 					Name:   "status",
 					Usage:  "get instance status",
@@ -224,6 +562,22 @@ func main() {
 							Name:  "autostar",
 							Usage: "mark subscription as autostar",
 						},
+						cli.StringSliceFlag{
+							Name:  "autoread-category",
+							Usage: "mark new entries having this category as read",
+						},
+						cli.StringSliceFlag{
+							Name:  "autostar-category",
+							Usage: "mark new entries having this category as starred",
+						},
+						cli.BoolFlag{
+							Name:  "fulltext",
+							Usage: "extract the full article of new items",
+						},
+						cli.IntFlag{
+							Name:  "backfill",
+							Usage: "import up to this many older items from the feed archive, for a new subscription",
+						},
 					},
 				},
 				{
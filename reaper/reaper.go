@@ -1,34 +1,83 @@
 package reaper
 
 import (
+	"fmt"
 	"sync"
 	"sync/atomic"
 	"time"
 
 	"github.com/kwo/rakewire/logger"
 	"github.com/kwo/rakewire/model"
+	"github.com/kwo/rakewire/sanitize"
+	"golang.org/x/net/context"
+)
+
+const (
+	// maxServerDelay caps how far into the future a server may push the next fetch.
+	maxServerDelay = 24 * time.Hour
+	// rateWindow is the period over which the reaping rate is measured.
+	rateWindow = time.Minute
+	// resanitizeBatch is the number of stored items examined per transaction when sanitizing them again.
+	resanitizeBatch = 200
 )
 
 var (
 	log = logger.New("reaper")
 )
 
+// Configuration contains all parameters for the Reaper service
+type Configuration struct {
+	MinIntervalSeconds int              // minimum time between fetches of a feed
+	MaxIntervalSeconds int              // maximum time between fetches of a feed
+	BatchMax           int              // maximum number of harvests saved in one transaction
+	BatchWindowMillis  int              // how long to collect harvests before saving them
+	Sanitize           *sanitize.Policy // applied to the content of items, nil for the default policy
+}
+
 // Service for saving fetch responses back to the database
 type Service struct {
-	Input      chan *model.Harvest
-	database   model.Database
-	killsignal chan bool
-	running    int32
-	runlatch   sync.WaitGroup
+	Input       chan *model.Harvest
+	database    model.Database
+	minInterval time.Duration
+	maxInterval time.Duration
+	batchMax    int
+	batchWindow time.Duration
+	policy      *sanitize.Policy
+	fingerprint string        // of the policy
+	killsignal  chan struct{} // closed to abandon the pending harvests
+	quit        chan struct{} // closed by run to stop sanitizing stored items
+	resanitized chan struct{} // closed when resanitize exits
+	running     int32
+	done        chan struct{} // closed when run exits
+	statsLock   sync.Mutex
+	reaped      []time.Time // completion times within the rate window
+	lastReaped  time.Time
+	total       int64
+	batches     int64
+	lastBatch   int
+	lastCommit  time.Duration
+	commits     time.Duration // sum of all commit durations
+	isolated    int64
+	failed      int64
 }
 
 // NewService create a new service
-func NewService(database model.Database) *Service {
+func NewService(cfg *Configuration, database model.Database) *Service {
+
+	policy := cfg.Sanitize
+	if policy == nil {
+		policy = &sanitize.Policy{}
+	}
 
 	return &Service{
-		Input:      make(chan *model.Harvest),
-		database:   database,
-		killsignal: make(chan bool),
+		Input:       make(chan *model.Harvest),
+		database:    database,
+		minInterval: time.Duration(cfg.MinIntervalSeconds) * time.Second,
+		maxInterval: time.Duration(cfg.MaxIntervalSeconds) * time.Second,
+		batchMax:    cfg.BatchMax,
+		batchWindow: time.Duration(cfg.BatchWindowMillis) * time.Millisecond,
+		policy:      policy,
+		fingerprint: policy.Fingerprint(),
 	}
 
 }
@@ -36,25 +85,49 @@ func NewService(database model.Database) *Service {
 // Start Service
 func (z *Service) Start() error {
 	log.Debugf("starting...")
+	log.Infof("min interval: %s", z.minInterval.String())
+	log.Infof("max interval: %s", z.maxInterval.String())
+	log.Infof("batch max:    %d", z.batchMax)
+	log.Infof("batch window: %s", z.batchWindow.String())
+	log.Infof("sanitize:     %s", z.fingerprint)
+	z.killsignal = make(chan struct{})
+	z.done = make(chan struct{})
+	z.quit = make(chan struct{})
+	z.resanitized = make(chan struct{})
 	z.setRunning(true)
-	z.runlatch.Add(1)
 	go z.run()
+	go z.resanitize()
 	log.Infof("started")
 	return nil
 }
 
-// Stop service
+// Stop service, waits for the fetch service to close the input.
 func (z *Service) Stop() {
+	z.Shutdown(context.Background())
+}
+
+// Shutdown reaps the pending harvests until the fetch service closes the input.
+// Once the context is done, the remaining harvests are abandoned.
+func (z *Service) Shutdown(ctx context.Context) error {
 
 	if !z.IsRunning() {
 		log.Debugf("service already stopped, exiting...")
-		return
+		return nil
 	}
 
 	log.Debugf("stopping...")
-	z.killsignal <- true
-	z.runlatch.Wait()
-	log.Infof("stopped")
+	select {
+	case <-z.done:
+		log.Infof("stopped")
+		return nil
+	case <-ctx.Done():
+		log.Infof("abandoning pending harvests: %s", ctx.Err().Error())
+		close(z.killsignal)
+		<-z.done
+		log.Infof("stopped")
+		return ctx.Err()
+	}
+
 }
 
 // IsRunning status of the service
@@ -70,6 +143,71 @@ func (z *Service) setRunning(running bool) {
 	}
 }
 
+// Activity reports the reaping rate, batch sizes and commit latency.
+func (z *Service) Activity() *model.ReapActivity {
+
+	z.statsLock.Lock()
+	defer z.statsLock.Unlock()
+
+	z.expireReaped(time.Now())
+	activity := &model.ReapActivity{
+		Running:    z.IsRunning(),
+		LastReaped: z.lastReaped,
+		PerMinute:  len(z.reaped),
+		Total:      z.total,
+		Batches:    z.batches,
+		LastBatch:  z.lastBatch,
+		LastCommit: z.lastCommit,
+		Isolated:   z.isolated,
+		Failed:     z.failed,
+	}
+	if z.batches > 0 {
+		activity.AvgBatch = float64(z.total-z.failed) / float64(z.batches)
+		activity.AvgCommit = z.commits / time.Duration(z.batches)
+	}
+	return activity
+
+}
+
+func (z *Service) recordCommit(size int, elapsed time.Duration) {
+	z.statsLock.Lock()
+	defer z.statsLock.Unlock()
+	z.batches++
+	z.lastBatch = size
+	z.lastCommit = elapsed
+	z.commits += elapsed
+}
+
+func (z *Service) recordFailed() {
+	z.statsLock.Lock()
+	defer z.statsLock.Unlock()
+	z.failed++
+}
+
+func (z *Service) recordIsolated() {
+	z.statsLock.Lock()
+	defer z.statsLock.Unlock()
+	z.isolated++
+}
+
+func (z *Service) recordReaped(now time.Time) {
+	z.statsLock.Lock()
+	defer z.statsLock.Unlock()
+	z.expireReaped(now)
+	z.reaped = append(z.reaped, now)
+	z.lastReaped = now
+	z.total++
+}
+
+// expireReaped drops the completion times older than the rate window, the caller must hold the lock.
+func (z *Service) expireReaped(now time.Time) {
+	i := 0
+	for i < len(z.reaped) && now.Sub(z.reaped[i]) >= rateWindow {
+		i++
+	}
+	z.reaped = z.reaped[i:]
+}
+
 func (z *Service) run() {
 
 	log.Debugf("run starting...")
@@ -77,131 +215,446 @@ func (z *Service) run() {
 run:
 	for {
 		select {
-		case harvest := <-z.Input:
-			z.reapHarvest(harvest)
+		case harvest, ok := <-z.Input:
+			if !ok {
+				break run
+			}
+			batch, open := z.collect(harvest)
+			z.reapBatch(batch)
+			if !open {
+				break run
+			}
 		case <-z.killsignal:
 			break run
 		}
 	}
 
-	close(z.Input)
+	close(z.quit)
+	<-z.resanitized
 
 	z.setRunning(false)
-	z.runlatch.Done()
+	close(z.done)
 	log.Debugf("run exited")
 
 }
 
-func (z *Service) reapHarvest(harvest *model.Harvest) {
+// collect gathers the harvests arriving within the batch window, up to the batch maximum.
+// It returns false if the input has been closed or the service killed.
+func (z *Service) collect(harvest *model.Harvest) (model.Harvests, bool) {
 
-	err := z.database.Update(func(tx model.Transaction) error {
+	batch := model.Harvests{harvest}
+	if z.batchWindow <= 0 || len(batch) >= z.batchMax {
+		return batch, true
+	}
 
-		dbItems := z.getDatabaseItems(tx, harvest.Items).GroupByGUID()
+	timer := time.NewTimer(z.batchWindow)
+	defer timer.Stop()
 
-		// setIDs, check dates for new items
-		var mostRecent time.Time
-		newItems := model.Items{}
-		for _, item := range harvest.Items {
+	for len(batch) < z.batchMax {
+		select {
+		case harvest, ok := <-z.Input:
+			if !ok {
+				return batch, false
+			}
+			batch = append(batch, harvest)
+		case <-timer.C:
+			return batch, true
+		case <-z.killsignal:
+			return batch, false
+		}
+	}
 
-			if dbItem, ok := dbItems[item.GUID]; !ok {
+	return batch, true
 
-				// new item
-				newItems = append(newItems, item)
-				now := time.Now()
+}
 
-				// prevent items marked with a future date
-				if item.Created.IsZero() || item.Created.After(now) {
-					item.Created = now
+// reapBatch saves the harvests in a single transaction.
+// If the transaction fails, each harvest is reaped again in a transaction of its own,
+// so that one bad harvest does not lose the others.
+func (z *Service) reapBatch(batch model.Harvests) {
+
+	if len(batch) > 1 {
+
+		// reap clones, the originals remain untouched if the transaction fails
+		clones := make(model.Harvests, len(batch))
+		for i, harvest := range batch {
+			clone, err := harvest.Clone()
+			if err != nil {
+				log.Infof("Cannot clone harvest %s: %s", harvest.Feed.URL, err.Error())
+				clones = nil
+				break
+			}
+			clones[i] = clone
+		}
+
+		if clones != nil {
+			startTime := time.Now()
+			err := z.database.Update(func(tx model.Transaction) error {
+				for _, clone := range clones {
+					if err := z.reapHarvest(tx, clone); err != nil {
+						return fmt.Errorf("%s: %s", clone.Feed.URL, err.Error())
+					}
 				}
-				if item.Updated.IsZero() || item.Updated.After(now) {
-					item.Updated = item.Created
+				return nil
+			})
+			if err == nil {
+				z.recordCommit(len(batch), time.Since(startTime))
+				for i, harvest := range batch {
+					*harvest = *clones[i]
+					logHarvest(harvest)
+					z.finishHarvest(harvest)
 				}
+				return
+			}
+			log.Infof("Error saving batch of %d feeds, saving each alone: %s", len(batch), err.Error())
+		}
 
-			} else {
+	}
 
-				// old item
-				item.ID = dbItem.ID
+	for _, harvest := range batch {
+		if len(batch) > 1 {
+			z.recordIsolated()
+		}
+		startTime := time.Now()
+		err := z.database.Update(func(tx model.Transaction) error {
+			return z.reapHarvest(tx, harvest)
+		})
+		if err == nil {
+			z.recordCommit(1, time.Since(startTime))
+			logHarvest(harvest)
+		} else {
+			log.Infof("Error processing feed: %s", err.Error())
+			z.recordFailed()
+		}
+		z.finishHarvest(harvest)
+	}
 
-				// set if zero and prevent from creeping forward
-				if item.Created.IsZero() || item.Created.After(dbItem.Created) {
-					item.Created = dbItem.Created
-				}
+}
 
-				// set if zero and prevent from creeping forward
-				if item.Updated.IsZero() {
-					item.Updated = dbItem.Updated
-				} else if item.Updated.After(dbItem.Updated) && item.Hash() == dbItem.Hash() {
-					item.Updated = dbItem.Updated
-				}
+// finishHarvest notifies a waiting refresh that the harvest has been reaped.
+func (z *Service) finishHarvest(harvest *model.Harvest) {
+	z.recordReaped(time.Now())
+	if harvest.Reaped != nil {
+		close(harvest.Reaped)
+	}
+}
+
+func logHarvest(harvest *model.Harvest) {
+	log.Infof("%2s  %3d  %s  %3d/%-3d  %s  %s", harvest.Feed.Status, harvest.Transmission.StatusCode, harvest.Feed.LastUpdated.Local().Format("02.01.06 15:04"), harvest.Transmission.NewItems, harvest.Transmission.ItemCount, harvest.Feed.URL, harvest.Feed.StatusMessage)
+}
+
+// reapHarvest merges the harvest with the stored items and saves it within the transaction.
+func (z *Service) reapHarvest(tx model.Transaction, harvest *model.Harvest) error {
+
+	if harvest.Archive {
+		return z.reapArchive(tx, harvest)
+	}
 
+	dbItems := z.getDatabaseItems(tx, harvest.Items).GroupByGUID()
+	fullText := model.S.GetForFeed(tx, harvest.Feed.ID).HasFullText()
+
+	// setIDs, check dates for new items
+	var mostRecent time.Time
+	newItems := model.Items{}
+	for _, item := range harvest.Items {
+
+		if dbItem, ok := dbItems[item.GUID]; !ok {
+
+			// new item
+			newItems = append(newItems, item)
+			now := time.Now()
+
+			// only dates supplied by the feed tell when it publishes, not the defaults below
+			dated := !item.Created.IsZero() && !item.Created.After(now)
+
+			// prevent items marked with a future date
+			if item.Created.IsZero() || item.Created.After(now) {
+				item.Created = now
+			}
+			if item.Updated.IsZero() || item.Updated.After(now) {
+				item.Updated = item.Created
+			}
+
+			// learn publishing frequency
+			if dated {
+				harvest.Feed.AddPublication(item.Created)
 			}
 
-			if item.Updated.After(mostRecent) {
-				mostRecent = item.Updated
+			// queue for full article extraction
+			if fullText && len(item.URL) > 0 {
+				item.ExtractNext = now
 			}
 
-		} // loop items
+		} else {
+
+			// old item
+			mergeItem(item, dbItem)
 
-		harvest.Transmission.LastUpdated = mostRecent
+		}
 
-		// only bump up LastUpdated if mostRecent is after previous time
-		// lastUpdated can move forward if no new items, if an existing item has been updated
-		if mostRecent.After(harvest.Feed.LastUpdated) {
-			harvest.Feed.LastUpdated = mostRecent
+		if item.Updated.After(mostRecent) {
+			mostRecent = item.Updated
 		}
 
-		if harvest.Transmission.Result == model.FetchResultOK {
-			if harvest.Feed.LastUpdated.IsZero() {
+	} // loop items
+
+	harvest.Transmission.LastUpdated = mostRecent
+
+	// only bump up LastUpdated if mostRecent is after previous time
+	// lastUpdated can move forward if no new items, if an existing item has been updated
+	if mostRecent.After(harvest.Feed.LastUpdated) {
+		harvest.Feed.LastUpdated = mostRecent
+	}
+
+	if harvest.Transmission.Result == model.FetchResultOK {
+		if harvest.Feed.LastUpdated.IsZero() {
+			// fall back to the date declared by the feed, unless in the future
+			if published := harvest.Transmission.Published; !published.IsZero() && !published.After(time.Now()) {
+				harvest.Feed.LastUpdated = published
+			} else {
 				harvest.Feed.LastUpdated = time.Now() // only if new items?
 			}
 		}
+	}
 
-		harvest.Transmission.ItemCount = len(harvest.Items)
-		harvest.Transmission.NewItems = len(newItems)
+	harvest.Transmission.ItemCount = len(harvest.Items)
+	harvest.Transmission.NewItems = len(newItems)
+
+	for _, item := range harvest.Items {
+		item.Sanitize(z.fingerprint, z.policy.Sanitize)
+	}
 
-		switch harvest.Feed.Status {
-		case model.FetchResultOK:
-			harvest.Feed.UpdateFetchTime(harvest.Feed.LastUpdated)
-		case model.FetchResultRedirect:
-			harvest.Feed.AdjustFetchTime(1 * time.Second)
-		default: // errors
-			harvest.Feed.UpdateFetchTime(harvest.Feed.StatusSince)
+	now := time.Now()
+	if push := harvest.Feed.Push; push != nil {
+		if harvest.Pushed {
+			push.LastPush = now
+		} else if len(newItems) > 0 && push.Active(now) {
+			// the hub failed to deliver these items: poll normally and subscribe again
+			log.Infof("hub missed %d items of %s", len(newItems), harvest.Feed.URL)
+			push.Missed = now
+			push.Next = now.Truncate(time.Second)
 		}
+	}
+
+	// pushed content does not alter the polling schedule
+	if !harvest.Pushed {
+		z.scheduleFeed(harvest, now)
+	}
+
+	// save transmission
+	if err := model.T.Save(tx, harvest.Transmission); err != nil {
+		log.Debugf("Cannot save transmission %s: %s", harvest.Transmission.URL, err.Error())
+		return err
+	}
+
+	// save items
+	if err := model.I.SaveAll(tx, harvest.Items); err != nil {
+		log.Debugf("Cannot save items %s: %s", harvest.Feed.URL, err.Error())
+		return err
+	}
+
+	// save feed
+	if err := model.F.Save(tx, harvest.Feed); err != nil {
+		log.Debugf("Cannot save feed %s: %s", harvest.Feed.URL, err.Error())
+		return err
+	}
+
+	// save entries
+	if err := model.E.AddItems(tx, newItems); err != nil {
+		log.Debugf("Cannot save entries %s: %s", harvest.Feed.URL, err.Error())
+		return err
+	}
+
+	return z.addBackfillEntries(tx, harvest)
+
+}
+
+// mergeItem takes the ID, extraction and dates of the stored item over to the harvested one.
+func mergeItem(item, dbItem *model.Item) {
+
+	item.ID = dbItem.ID
+	item.CopyExtraction(dbItem)
+
+	// set if zero and prevent from creeping forward
+	if item.Created.IsZero() || item.Created.After(dbItem.Created) {
+		item.Created = dbItem.Created
+	}
+
+	// set if zero and prevent from creeping forward
+	if item.Updated.IsZero() {
+		item.Updated = dbItem.Updated
+	} else if item.Updated.After(dbItem.Updated) && item.Hash() == dbItem.Hash() {
+		item.Updated = dbItem.Updated
+	}
+
+}
+
+// reapArchive saves the items of an older page of a feed and records the transmission, leaving the feed untouched.
+// The items become entries of the backfilling subscriber only, other subscribers are not flooded with old items.
+func (z *Service) reapArchive(tx model.Transaction, harvest *model.Harvest) error {
+
+	dbItems := z.getDatabaseItems(tx, harvest.Items).GroupByGUID()
+
+	var newItems int
+	var mostRecent time.Time
+	now := time.Now()
+	for _, item := range harvest.Items {
+
+		if dbItem, ok := dbItems[item.GUID]; !ok {
+			newItems++
+			if item.Created.IsZero() || item.Created.After(now) {
+				item.Created = now
+			}
+			if item.Updated.IsZero() || item.Updated.After(now) {
+				item.Updated = item.Created
+			}
+		} else {
+			mergeItem(item, dbItem)
+		}
+
+		if item.Updated.After(mostRecent) {
+			mostRecent = item.Updated
+		}
+
+		item.Sanitize(z.fingerprint, z.policy.Sanitize)
+
+	}
+
+	harvest.Transmission.LastUpdated = mostRecent
+	harvest.Transmission.ItemCount = len(harvest.Items)
+	harvest.Transmission.NewItems = newItems
+
+	if err := model.T.Save(tx, harvest.Transmission); err != nil {
+		log.Debugf("Cannot save transmission %s: %s", harvest.Transmission.URL, err.Error())
+		return err
+	}
 
-		// save transmission
-		if err := model.T.Save(tx, harvest.Transmission); err != nil {
-			log.Debugf("Cannot save transmission %s: %s", harvest.Transmission.URL, err.Error())
-			return err
+	if err := model.I.SaveAll(tx, harvest.Items); err != nil {
+		log.Debugf("Cannot save items %s: %s", harvest.Transmission.URL, err.Error())
+		return err
+	}
+
+	return z.addBackfillEntries(tx, harvest)
+
+}
+
+// addBackfillEntries creates entries for all items of the harvest for the subscription of the backfilling user, if any.
+func (z *Service) addBackfillEntries(tx model.Transaction, harvest *model.Harvest) error {
+
+	if harvest.BackfillUser == "" {
+		return nil
+	}
+
+	for _, subscription := range model.S.GetForFeed(tx, harvest.Feed.ID) {
+		if subscription.UserID == harvest.BackfillUser {
+			if err := model.E.AddItemsForSubscription(tx, subscription, harvest.Items); err != nil {
+				log.Debugf("Cannot save entries %s: %s", harvest.Transmission.URL, err.Error())
+				return err
+			}
 		}
+	}
+
+	return nil
+
+}
+
+// resanitize sanitizes the stored items again from their original content if they were sanitized with a different policy.
+func (z *Service) resanitize() {
+
+	defer close(z.resanitized)
+
+	var total int
+	afterID := ""
+	for {
 
-		// save items
-		if err := model.I.SaveAll(tx, harvest.Items); err != nil {
-			log.Debugf("Cannot save items %s: %s", harvest.Feed.URL, err.Error())
-			return err
+		select {
+		case <-z.quit:
+			log.Debugf("resanitize stopped after %d items", total)
+			return
+		default:
 		}
 
-		// save feed
-		if err := model.F.Save(tx, harvest.Feed); err != nil {
-			log.Debugf("Cannot save feed %s: %s", harvest.Feed.URL, err.Error())
-			return err
+		var items model.Items
+		var lastID string
+		if err := z.database.Select(func(tx model.Transaction) error {
+			items, lastID = model.I.GetUnsanitized(tx, z.fingerprint, afterID, resanitizeBatch)
+			return nil
+		}); err != nil {
+			log.Infof("Cannot select items to sanitize: %s", err.Error())
+			return
 		}
 
-		// save entries
-		if err := model.E.AddItems(tx, newItems); err != nil {
-			log.Debugf("Cannot save entries %s: %s", harvest.Feed.URL, err.Error())
-			return err
+		if lastID == "" {
+			if total > 0 {
+				log.Infof("sanitized %d stored items", total)
+			}
+			return
 		}
+		afterID = lastID
 
-		log.Infof("%2s  %3d  %s  %3d/%-3d  %s  %s", harvest.Feed.Status, harvest.Transmission.StatusCode, harvest.Feed.LastUpdated.Local().Format("02.01.06 15:04"), harvest.Transmission.NewItems, harvest.Transmission.ItemCount, harvest.Feed.URL, harvest.Feed.StatusMessage)
+		if len(items) == 0 {
+			continue
+		}
 
-		return nil
+		// reload within the update, the reaper may have saved the item in the meantime
+		err := z.database.Update(func(tx model.Transaction) error {
+			for _, stale := range items {
+				item := model.I.Get(tx, stale.ID)
+				if item == nil || item.Sanitized == z.fingerprint {
+					continue
+				}
+				item.Sanitize(z.fingerprint, z.policy.Sanitize)
+				if err := model.I.Save(tx, item); err != nil {
+					return err
+				}
+			}
+			return nil
+		})
+		if err != nil {
+			log.Infof("Cannot sanitize stored items: %s", err.Error())
+			return
+		}
+		total += len(items)
+
+	}
+
+}
 
-	})
+// scheduleFeed sets the next fetch time of the feed, slowing down polling of feeds whose hub pushes new items
+// and of feeds whose publisher asks to be fetched less often.
+func (z *Service) scheduleFeed(harvest *model.Harvest, now time.Time) {
+
+	switch harvest.Feed.Status {
+	case model.FetchResultOK:
+		if harvest.Feed.Interval > 0 {
+			harvest.Feed.AdjustFetchTime(harvest.Feed.Interval)
+		} else if harvest.Feed.Push.Active(now) && z.maxInterval > 0 {
+			harvest.Feed.AdjustFetchTime(z.maxInterval)
+		} else {
+			harvest.Feed.AdaptFetchTime(z.minInterval, z.maxInterval)
+			harvest.Feed.ApplyHints(z.hintLimit())
+		}
+	case model.FetchResultRedirect:
+		harvest.Feed.AdjustFetchTime(1 * time.Second)
+	default: // errors
+		harvest.Feed.UpdateFetchTime(harvest.Feed.StatusSince)
+	}
 
-	if err != nil {
-		log.Infof("Error processing feed: %s", err.Error())
+	// never fetch earlier than the server asks, unless following a redirect
+	if harvest.Feed.Status != model.FetchResultRedirect {
+		harvest.Feed.DelayFetchTime(harvest.NotBefore(), maxServerDelay)
 	}
 
+	harvest.Feed.ApplyFetchWindows()
+
+}
+
+// hintLimit returns how far into the future publisher hints may postpone the next fetch.
+func (z *Service) hintLimit() time.Duration {
+	if z.maxInterval > 0 {
+		return z.maxInterval
+	}
+	return maxServerDelay
 }
 
 func (z *Service) getDatabaseItems(tx model.Transaction, items model.Items) model.Items {
@@ -0,0 +1,250 @@
+package reaper
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/kwo/rakewire/model"
+)
+
+func TestInterfaceService(t *testing.T) {
+
+	var s model.Service = &Service{}
+	if s == nil {
+		t.Fatal("Does not implement model.Service interface.")
+	}
+
+}
+
+func TestReapBatch(t *testing.T) {
+
+	t.Parallel()
+
+	database := openTestDatabase(t)
+	defer closeTestDatabase(t, database)
+
+	z := newTestService(database)
+	batch := model.Harvests{
+		newTestHarvest(t, database, "http://localhost/feed1.xml"),
+		newTestHarvest(t, database, "http://localhost/feed2.xml"),
+		newTestHarvest(t, database, "http://localhost/feed3.xml"),
+	}
+
+	z.reapBatch(batch)
+
+	assertReaped(t, batch)
+	for _, harvest := range batch {
+		assertSaved(t, database, harvest, true)
+	}
+
+	activity := z.Activity()
+	if activity.Batches != 1 || activity.LastBatch != 3 || activity.AvgBatch != 3 {
+		t.Errorf("Bad batches: %d, last %d, average %f", activity.Batches, activity.LastBatch, activity.AvgBatch)
+	}
+	if activity.LastCommit <= 0 || activity.AvgCommit != activity.LastCommit {
+		t.Errorf("Bad commit latency: last %s, average %s", activity.LastCommit, activity.AvgCommit)
+	}
+	if activity.Total != 3 || activity.PerMinute != 3 || activity.Isolated != 0 || activity.Failed != 0 {
+		t.Errorf("Bad counts: total %d, per minute %d, isolated %d, failed %d", activity.Total, activity.PerMinute, activity.Isolated, activity.Failed)
+	}
+
+}
+
+func TestReapBatchIsolated(t *testing.T) {
+
+	t.Parallel()
+
+	database := openTestDatabase(t)
+	defer closeTestDatabase(t, database)
+
+	z := newTestService(database)
+	batch := model.Harvests{
+		newTestHarvest(t, database, "http://localhost/feed1.xml"),
+		newTestHarvest(t, database, "http://localhost/feed2.xml"),
+		newTestHarvest(t, database, "http://localhost/feed3.xml"),
+	}
+	poisoned := batch[1]
+	poisoned.Feed.URL = "" // the feed URL index key cannot be empty
+
+	z.reapBatch(batch)
+
+	assertReaped(t, batch)
+	assertSaved(t, database, batch[0], true)
+	assertSaved(t, database, poisoned, false)
+	assertSaved(t, database, batch[2], true)
+
+	activity := z.Activity()
+	if activity.Isolated != 3 || activity.Failed != 1 || activity.Total != 3 {
+		t.Errorf("Bad counts: isolated %d, failed %d, total %d", activity.Isolated, activity.Failed, activity.Total)
+	}
+	if activity.Batches != 2 || activity.LastBatch != 1 || activity.AvgBatch != 1 {
+		t.Errorf("Bad batches: %d, last %d, average %f", activity.Batches, activity.LastBatch, activity.AvgBatch)
+	}
+
+}
+
+func TestReapPublications(t *testing.T) {
+
+	t.Parallel()
+
+	database := openTestDatabase(t)
+	defer closeTestDatabase(t, database)
+
+	z := newTestService(database)
+
+	// undated items do not teach the publishing frequency
+	harvest := newTestHarvest(t, database, "http://localhost/feed1.xml")
+	z.reapBatch(model.Harvests{harvest})
+	if harvest.Feed.Frequency != nil {
+		t.Errorf("Undated items recorded as publications: %v", harvest.Feed.Frequency)
+	}
+
+	harvest = newTestHarvest(t, database, "http://localhost/feed2.xml")
+	harvest.Items[0].Created = time.Now().Add(-time.Hour)
+	harvest.Items[1].Created = time.Now().Add(time.Hour) // in the future
+	z.reapBatch(model.Harvests{harvest})
+	var publications float64
+	if harvest.Feed.Frequency != nil {
+		for _, count := range harvest.Feed.Frequency.Counts {
+			publications += count
+		}
+	}
+	if publications != 1 {
+		t.Errorf("Bad publication count, expected %d, actual %f", 1, publications)
+	}
+
+}
+
+func TestCollect(t *testing.T) {
+
+	t.Parallel()
+
+	database := openTestDatabase(t)
+	defer closeTestDatabase(t, database)
+
+	z := NewService(&Configuration{
+		MinIntervalSeconds: 300,
+		MaxIntervalSeconds: 3600,
+		BatchMax:           2,
+		BatchWindowMillis:  1000,
+	}, database)
+	if err := z.Start(); err != nil {
+		t.Fatalf("Cannot start service: %s", err.Error())
+	}
+
+	batch := model.Harvests{
+		newTestHarvest(t, database, "http://localhost/feed1.xml"),
+		newTestHarvest(t, database, "http://localhost/feed2.xml"),
+		newTestHarvest(t, database, "http://localhost/feed3.xml"),
+	}
+	for _, harvest := range batch {
+		z.Input <- harvest
+	}
+	close(z.Input)
+	z.Stop()
+
+	assertReaped(t, batch)
+	activity := z.Activity()
+	if activity.Running || activity.Batches != 2 || activity.LastBatch != 1 || activity.Total != 3 {
+		t.Errorf("Bad activity: running %t, batches %d, last %d, total %d", activity.Running, activity.Batches, activity.LastBatch, activity.Total)
+	}
+
+}
+
+func newTestService(database model.Database) *Service {
+	return NewService(&Configuration{MinIntervalSeconds: 300, MaxIntervalSeconds: 3600, BatchMax: 10}, database)
+}
+
+// newTestHarvest saves a new feed with the given URL and returns a harvest of two items of the feed.
+func newTestHarvest(t *testing.T, database model.Database, url string) *model.Harvest {
+
+	feed := model.F.New(url)
+	if err := database.Update(func(tx model.Transaction) error {
+		return model.F.Save(tx, feed)
+	}); err != nil {
+		t.Fatalf("Cannot save feed: %s", err.Error())
+	}
+
+	harvest := &model.Harvest{
+		Feed:         feed,
+		Transmission: model.T.New(feed.ID),
+		Reaped:       make(chan struct{}),
+	}
+	harvest.Transmission.URL = url
+	harvest.Transmission.StartTime = time.Now()
+	harvest.Transmission.Result = model.FetchResultOK
+	for i := 1; i <= 2; i++ {
+		item := model.I.New(feed.ID, fmt.Sprintf("%s#%d", url, i))
+		item.Title = fmt.Sprintf("Item %d", i)
+		harvest.Items = append(harvest.Items, item)
+	}
+
+	return harvest
+
+}
+
+func assertReaped(t *testing.T, harvests model.Harvests) {
+	for _, harvest := range harvests {
+		select {
+		case <-harvest.Reaped:
+		default:
+			t.Errorf("Harvest not reaped: %s", harvest.Transmission.URL)
+		}
+	}
+}
+
+// assertSaved tests if the items and transmission of the harvest have been saved or not.
+func assertSaved(t *testing.T, database model.Database, harvest *model.Harvest, expected bool) {
+
+	feedID := harvest.Transmission.FeedID
+	var items, transmissions int
+	if err := database.Select(func(tx model.Transaction) error {
+		items = len(model.I.GetForFeed(tx, feedID))
+		transmissions = len(model.T.GetForFeed(tx, feedID, time.Hour))
+		return nil
+	}); err != nil {
+		t.Fatalf("Cannot select items: %s", err.Error())
+	}
+
+	if expected && (items != 2 || transmissions != 1) {
+		t.Errorf("Harvest not saved: %s, items %d, transmissions %d", harvest.Transmission.URL, items, transmissions)
+	} else if !expected && (items != 0 || transmissions != 0) {
+		t.Errorf("Harvest saved: %s, items %d, transmissions %d", harvest.Transmission.URL, items, transmissions)
+	}
+
+}
+
+func openTestDatabase(t *testing.T) model.Database {
+
+	f, err := ioutil.TempFile("", "bolt-")
+	if err != nil {
+		t.Fatalf("Cannot acquire temp file: %s", err.Error())
+	}
+	f.Close()
+	location := f.Name()
+
+	boltDB, err := model.Instance.Open(location)
+	if err != nil {
+		t.Fatalf("Cannot open database: %s", err.Error())
+	}
+
+	return boltDB
+
+}
+
+func closeTestDatabase(t *testing.T, d model.Database) {
+
+	location := d.Location()
+
+	if err := model.Instance.Close(d); err != nil {
+		t.Errorf("Cannot close database: %s", err.Error())
+	}
+
+	if err := os.Remove(location); err != nil {
+		t.Errorf("Cannot remove temp file: %s", err.Error())
+	}
+
+}
@@ -0,0 +1,278 @@
+// Package sanitize removes scripts, event handlers, foreign frames and tracking pixels from the HTML content of items,
+// keeping only allowlisted elements and attributes.
+package sanitize
+
+import (
+	"bytes"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+const (
+	// version is part of the policy fingerprint, increment when the allowlists change so that content is sanitized again.
+	version = "1"
+	// iframeSandbox restricts the embedded iframes from allowed hosts.
+	iframeSandbox = "allow-scripts allow-same-origin allow-popups allow-presentation"
+	// linkRel is added to all links.
+	linkRel = "nofollow noopener noreferrer"
+)
+
+var (
+	// allowedElements maps the elements which are kept to their permitted attributes, in addition to globalAttributes.
+	allowedElements = map[string][]string{
+		"a": {"href"}, "abbr": nil, "acronym": nil, "address": nil, "article": nil, "aside": nil,
+		"audio": {"src", "controls", "preload"}, "b": nil, "bdi": nil, "bdo": nil, "big": nil, "blockquote": {"cite"},
+		"br": nil, "caption": nil, "center": nil, "cite": nil, "code": nil, "col": {"span"}, "colgroup": {"span"},
+		"dd": nil, "del": {"cite", "datetime"}, "details": {"open"}, "dfn": nil, "div": nil, "dl": nil, "dt": nil,
+		"em": nil, "figcaption": nil, "figure": nil, "h1": nil, "h2": nil, "h3": nil, "h4": nil, "h5": nil, "h6": nil,
+		"hr": nil, "i": nil, "img": {"src", "alt", "width", "height"}, "ins": {"cite", "datetime"}, "kbd": nil,
+		"li": {"value"}, "mark": nil, "ol": {"start", "reversed", "type"}, "p": nil, "pre": nil, "q": {"cite"},
+		"rp": nil, "rt": nil, "ruby": nil, "s": nil, "samp": nil, "section": nil, "small": nil,
+		"source": {"src", "type"}, "span": nil, "strike": nil, "strong": nil, "sub": nil, "summary": nil,
+		"sup": nil, "table": nil, "tbody": nil, "td": {"colspan", "rowspan"}, "tfoot": nil,
+		"th": {"colspan", "rowspan", "scope"}, "thead": nil, "time": {"datetime"}, "tr": nil, "tt": nil, "u": nil,
+		"ul": nil, "var": nil, "video": {"src", "controls", "poster", "width", "height", "preload"},
+	}
+	// droppedElements are removed together with their content, all other unknown elements are replaced by their content.
+	droppedElements = map[string]bool{
+		"applet": true, "base": true, "button": true, "canvas": true, "embed": true, "frame": true, "frameset": true,
+		"head": true, "iframe": true, "input": true, "link": true, "math": true, "meta": true, "noembed": true,
+		"noframes": true, "noscript": true, "object": true, "option": true, "param": true, "plaintext": true,
+		"script": true, "select": true, "style": true, "svg": true, "template": true, "textarea": true,
+		"title": true, "xmp": true,
+	}
+	globalAttributes = []string{"title", "lang", "dir"}
+	// urlAttributes are only kept if they use one of the allowed schemes or are relative.
+	urlAttributes    = map[string]bool{"cite": true, "href": true, "poster": true, "src": true}
+	allowedSchemes   = map[string]bool{"": true, "http": true, "https": true}
+	iframeAttributes = []string{"src", "width", "height", "allowfullscreen", "title"}
+	bodyContext      = &html.Node{Type: html.ElementNode, Data: "body", DataAtom: atom.Body}
+)
+
+// Policy defines the options of the sanitizer. The zero value keeps images and removes all iframes.
+type Policy struct {
+	StripImages bool     // remove all images
+	IframeHosts []string // keep iframes whose source is on one of these hosts, for example www.youtube.com
+}
+
+// Fingerprint identifies the policy and the version of the allowlists,
+// content sanitized with a different fingerprint should be sanitized again from the original.
+func (z *Policy) Fingerprint() string {
+	hosts := make([]string, 0, len(z.IframeHosts))
+	for _, host := range z.IframeHosts {
+		hosts = append(hosts, strings.ToLower(strings.TrimSpace(host)))
+	}
+	sort.Strings(hosts)
+	return version + ";images=" + strconv.FormatBool(!z.StripImages) + ";iframes=" + strings.Join(hosts, ",")
+}
+
+// Sanitize returns the given HTML fragment with everything removed which is not explicitly allowed.
+func (z *Policy) Sanitize(content string) string {
+
+	if strings.TrimSpace(content) == "" {
+		return content
+	}
+
+	nodes, err := html.ParseFragment(strings.NewReader(content), bodyContext)
+	if err != nil {
+		// the parser recovers from malformed markup, this only happens if reading fails
+		return html.EscapeString(content)
+	}
+
+	var buf bytes.Buffer
+	for _, n := range nodes {
+		for _, clean := range z.clean(n) {
+			if err := html.Render(&buf, clean); err != nil {
+				return html.EscapeString(content)
+			}
+		}
+	}
+
+	return buf.String()
+
+}
+
+// clean returns the sanitized copies of the node: none if the node is removed,
+// the node itself if allowed, or its children if only the element is removed.
+func (z *Policy) clean(n *html.Node) []*html.Node {
+
+	switch n.Type {
+	case html.TextNode:
+		return []*html.Node{{Type: html.TextNode, Data: n.Data}}
+	case html.ElementNode:
+		// handled below
+	default: // comments, doctypes
+		return nil
+	}
+
+	name := n.Data
+	if n.Namespace != "" {
+		// foreign content, svg and math
+		return nil
+	}
+
+	if name == "iframe" {
+		if iframe := z.cleanIframe(n); iframe != nil {
+			return []*html.Node{iframe}
+		}
+		return nil
+	}
+
+	if droppedElements[name] {
+		return nil
+	}
+
+	if name == "img" && (z.StripImages || isTrackingPixel(n)) {
+		return nil
+	}
+
+	allowed, ok := allowedElements[name]
+	if !ok {
+		return z.cleanChildren(n)
+	}
+
+	e := &html.Node{Type: html.ElementNode, Data: name, DataAtom: n.DataAtom}
+	for _, attr := range n.Attr {
+		if attr.Namespace != "" || (!contains(allowed, attr.Key) && !contains(globalAttributes, attr.Key)) {
+			continue
+		}
+		if name == "video" && attr.Key == "poster" && z.StripImages {
+			continue
+		}
+		if urlAttributes[attr.Key] {
+			value, ok := cleanURL(attr.Val, name == "a" && attr.Key == "href")
+			if !ok {
+				continue
+			}
+			attr.Val = value
+		}
+		e.Attr = append(e.Attr, html.Attribute{Key: attr.Key, Val: attr.Val})
+	}
+
+	switch name {
+	case "a":
+		if hasAttr(e, "href") {
+			e.Attr = append(e.Attr, html.Attribute{Key: "rel", Val: linkRel})
+		}
+	case "img":
+		if !hasAttr(e, "src") {
+			return nil
+		}
+	}
+
+	for _, c := range z.cleanChildren(n) {
+		e.AppendChild(c)
+	}
+
+	return []*html.Node{e}
+
+}
+
+func (z *Policy) cleanChildren(n *html.Node) []*html.Node {
+	var result []*html.Node
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		result = append(result, z.clean(c)...)
+	}
+	return result
+}
+
+// cleanIframe returns a sandboxed copy of an iframe from an allowed host, without its content, nil otherwise.
+func (z *Policy) cleanIframe(n *html.Node) *html.Node {
+
+	var src string
+	for _, attr := range n.Attr {
+		if attr.Namespace == "" && attr.Key == "src" {
+			src = strings.TrimSpace(attr.Val)
+		}
+	}
+
+	u, err := url.Parse(src)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || !z.allowsIframeHost(u.Hostname()) {
+		return nil
+	}
+
+	e := &html.Node{Type: html.ElementNode, Data: "iframe", DataAtom: atom.Iframe}
+	for _, attr := range n.Attr {
+		if attr.Namespace == "" && contains(iframeAttributes, attr.Key) {
+			if attr.Key == "src" {
+				attr.Val = u.String()
+			}
+			e.Attr = append(e.Attr, html.Attribute{Key: attr.Key, Val: attr.Val})
+		}
+	}
+	e.Attr = append(e.Attr, html.Attribute{Key: "sandbox", Val: iframeSandbox})
+
+	return e
+
+}
+
+func (z *Policy) allowsIframeHost(host string) bool {
+	for _, allowed := range z.IframeHosts {
+		if host != "" && strings.EqualFold(strings.TrimSpace(allowed), host) {
+			return true
+		}
+	}
+	return false
+}
+
+// cleanURL returns the normalized URL if it is relative or uses an allowed scheme.
+// Control characters, which browsers ignore within a scheme, make the URL invalid.
+func cleanURL(value string, link bool) (string, bool) {
+	value = strings.TrimSpace(value)
+	u, err := url.Parse(value)
+	if err != nil {
+		return "", false
+	}
+	scheme := strings.ToLower(u.Scheme)
+	if !allowedSchemes[scheme] && !(link && scheme == "mailto") {
+		return "", false
+	}
+	if scheme == "" {
+		// a colon before the path, query or fragment is a scheme the URL parser did not recognize
+		prefix := value
+		if i := strings.IndexAny(value, "/?#"); i >= 0 {
+			prefix = value[:i]
+		}
+		if strings.Contains(prefix, ":") {
+			return "", false
+		}
+	}
+	return value, true
+}
+
+// isTrackingPixel detects images no larger than one pixel in either dimension.
+func isTrackingPixel(n *html.Node) bool {
+	for _, attr := range n.Attr {
+		if attr.Key != "width" && attr.Key != "height" {
+			continue
+		}
+		value := strings.TrimSuffix(strings.TrimSpace(strings.ToLower(attr.Val)), "px")
+		if size, err := strconv.Atoi(value); err == nil && size <= 1 {
+			return true
+		}
+	}
+	return false
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+func hasAttr(n *html.Node, key string) bool {
+	for _, attr := range n.Attr {
+		if attr.Key == key {
+			return true
+		}
+	}
+	return false
+}
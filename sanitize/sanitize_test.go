@@ -0,0 +1,221 @@
+package sanitize
+
+import (
+	"strings"
+	"testing"
+
+	"golang.org/x/net/html"
+)
+
+// xssVectors are markup variants which attempt to run script in the reader.
+var xssVectors = []string{
+	`<script>alert(1)</script>`,
+	`<SCRIPT SRC=http://evil.example/xss.js></SCRIPT>`,
+	`<img src=x onerror=alert(1)>`,
+	`<IMG SRC="x" ONERROR="alert(1)">`,
+	`<img src="javascript:alert(1)">`,
+	"<IMG SRC=`javascript:alert(1)`>",
+	`<img """><script>alert(1)</script>">`,
+	`<img src=x onerror=alert(1)//`,
+	`<a href="javascript:alert(1)">x</a>`,
+	`<a href="JaVaScRiPt:alert(1)">x</a>`,
+	`<a href=" javascript:alert(1)">x</a>`,
+	`<a href="jav	ascript:alert(1)">x</a>`,
+	`<a href="jav&#x09;ascript:alert(1)">x</a>`,
+	`<a href="jav&#x0A;ascript:alert(1)">x</a>`,
+	`<a href="&#x01;javascript:alert(1)">x</a>`,
+	`<a href="&#106;&#97;&#118;&#97;&#115;&#99;&#114;&#105;&#112;&#116;&#58;alert(1)">x</a>`,
+	`<a href="&#0000106avascript:alert(1)">x</a>`,
+	`<a href="vbscript:msgbox(1)">x</a>`,
+	`<a href="data:text/html;base64,PHNjcmlwdD5hbGVydCgxKTwvc2NyaXB0Pg==">x</a>`,
+	`<a href="http://example.com/" onclick="alert(1)" onmouseover="alert(1)">x</a>`,
+	`<a href="http://example.com/" target="_blank" style="position:fixed">x</a>`,
+	`<svg onload=alert(1)>`,
+	`<svg><script>alert(1)</script></svg>`,
+	`<svg><a xlink:href="javascript:alert(1)"><text>x</text></a></svg>`,
+	`<math><mtext><table><mglyph><style><img src=x onerror=alert(1)>`,
+	`<math><mi xlink:href="javascript:alert(1)">x</mi></math>`,
+	`<iframe src="javascript:alert(1)"></iframe>`,
+	`<iframe src="https://evil.example/"></iframe>`,
+	`<iframe srcdoc="<script>alert(1)</script>"></iframe>`,
+	`<body onload=alert(1)>`,
+	`<div style="background:url(javascript:alert(1))">x</div>`,
+	`<style>@import 'http://evil.example/xss.css';</style>`,
+	`<object data="javascript:alert(1)"></object>`,
+	`<embed src="javascript:alert(1)">`,
+	`<form action="javascript:alert(1)"><input type=submit><button formaction=javascript:alert(1)>x</button></form>`,
+	`<meta http-equiv="refresh" content="0;url=javascript:alert(1)">`,
+	`<base href="javascript:alert(1)//">`,
+	`<link rel=stylesheet href="http://evil.example/xss.css">`,
+	`<noscript><p title="</noscript><img src=x onerror=alert(1)>"></noscript>`,
+	`<!--<img src=x onerror=alert(1)>-->`,
+	`<!--[if gte IE 4]><script>alert(1)</script><![endif]-->`,
+	`<scr<script>ipt>alert(1)</scr</script>ipt>`,
+	`<<script>script>alert(1)<</script>/script>`,
+	`<details open ontoggle=alert(1)>x</details>`,
+	`<video poster="javascript:alert(1)" src="javascript:alert(1)"></video>`,
+	`<audio src=x onerror=alert(1)></audio>`,
+	`<table background="javascript:alert(1)"><tr><td>x</td></tr></table>`,
+	`<textarea><script>alert(1)</script></textarea>`,
+	`<title><script>alert(1)</script></title>`,
+	`<template><script>alert(1)</script></template>`,
+	`<xmp><script>alert(1)</script></xmp>`,
+	`<plaintext><script>alert(1)</script>`,
+	`<div id="x" class="y" data-x="1" formaction="javascript:alert(1)">x</div>`,
+	`<blockquote cite="javascript:alert(1)">x</blockquote>`,
+	`<p>unclosed <b>bold <i>italic`,
+	`</p></div><script>alert(1)</script>`,
+	`<a href="http://example.com/"><img src="http://example.com/x.png" onload="alert(1)"></a>`,
+}
+
+func TestXSSVectors(t *testing.T) {
+
+	t.Parallel()
+
+	policies := []*Policy{
+		{},
+		{StripImages: true},
+		{IframeHosts: []string{"www.youtube.com"}},
+	}
+
+	for _, policy := range policies {
+		for _, vector := range xssVectors {
+			result := policy.Sanitize(vector)
+			assertSafe(t, vector, result)
+			// sanitizing is idempotent
+			if again := policy.Sanitize(result); again != result {
+				t.Errorf("Not idempotent: %s\n  first:  %s\n  second: %s", vector, result, again)
+			}
+		}
+	}
+
+}
+
+func TestSanitize(t *testing.T) {
+
+	t.Parallel()
+
+	policy := &Policy{}
+
+	tests := []struct {
+		content  string
+		expected string
+	}{
+		{``, ``},
+		{`plain text`, `plain text`},
+		{`<p>Hello <b>World</b></p>`, `<p>Hello <b>World</b></p>`},
+		{`<p>unclosed <b>bold`, `<p>unclosed <b>bold</b></p>`},
+		{`1 < 2 & 3 > 2`, `1 &lt; 2 &amp; 3 &gt; 2`},
+		{`<script>alert(1)</script><p>after</p>`, `<p>after</p>`},
+		{`<custom>kept <b>content</b></custom>`, `kept <b>content</b>`},
+		{`<a href="http://example.com/" onclick="alert(1)" class="x">link</a>`, `<a href="http://example.com/" rel="nofollow noopener noreferrer">link</a>`},
+		{`<a href="javascript:alert(1)">link</a>`, `<a>link</a>`},
+		{`<a href="mailto:a@example.com">mail</a>`, `<a href="mailto:a@example.com" rel="nofollow noopener noreferrer">mail</a>`},
+		{`<a href="/relative">rel</a>`, `<a href="/relative" rel="nofollow noopener noreferrer">rel</a>`},
+		{`<img src="http://example.com/a.png" alt="A" style="border:0">`, `<img src="http://example.com/a.png" alt="A"/>`},
+		{`<img src="mailto:a@example.com">`, ``},
+		{`<img src="http://tracker.example/p.gif" width="1" height="1">`, ``},
+		{`<img src="http://tracker.example/p.gif" width="0px">`, ``},
+		{`<img src="http://example.com/b.png" width="100">`, `<img src="http://example.com/b.png" width="100"/>`},
+		{`<iframe src="https://www.youtube.com/embed/x"></iframe>`, ``},
+		{`<div title="a&quot;b" lang="en">x</div>`, `<div title="a&#34;b" lang="en">x</div>`},
+		{`<!-- comment --><p>x</p>`, `<p>x</p>`},
+	}
+
+	for _, test := range tests {
+		if result := policy.Sanitize(test.content); result != test.expected {
+			t.Errorf("Sanitize %s\n  expected: %s\n  actual:   %s", test.content, test.expected, result)
+		}
+	}
+
+}
+
+func TestPolicyOptions(t *testing.T) {
+
+	t.Parallel()
+
+	policy := &Policy{
+		StripImages: true,
+		IframeHosts: []string{"www.youtube.com", " Player.Vimeo.com "},
+	}
+
+	tests := []struct {
+		content  string
+		expected string
+	}{
+		{`<p><img src="http://example.com/a.png">text</p>`, `<p>text</p>`},
+		{`<video src="http://example.com/v.mp4" poster="http://example.com/p.png" controls></video>`, `<video src="http://example.com/v.mp4" controls=""></video>`},
+		{`<iframe src="https://www.youtube.com/embed/x" width="560" onload="alert(1)" allowfullscreen>fallback</iframe>`, `<iframe src="https://www.youtube.com/embed/x" width="560" allowfullscreen="" sandbox="allow-scripts allow-same-origin allow-popups allow-presentation"></iframe>`},
+		{`<iframe src="https://player.vimeo.com/video/1"></iframe>`, `<iframe src="https://player.vimeo.com/video/1" sandbox="allow-scripts allow-same-origin allow-popups allow-presentation"></iframe>`},
+		{`<iframe src="https://www.youtube.com.evil.example/embed/x"></iframe>`, ``},
+		{`<iframe src="javascript://www.youtube.com/%0Aalert(1)"></iframe>`, ``},
+		{`<iframe src="//www.youtube.com/embed/x"></iframe>`, ``},
+	}
+
+	for _, test := range tests {
+		if result := policy.Sanitize(test.content); result != test.expected {
+			t.Errorf("Sanitize %s\n  expected: %s\n  actual:   %s", test.content, test.expected, result)
+		}
+	}
+
+}
+
+func TestFingerprint(t *testing.T) {
+
+	t.Parallel()
+
+	a := (&Policy{IframeHosts: []string{"b.example", "A.example"}}).Fingerprint()
+	b := (&Policy{IframeHosts: []string{"a.example", "b.example"}}).Fingerprint()
+	if a != b {
+		t.Errorf("Equivalent policies differ: %s, %s", a, b)
+	}
+
+	if c := (&Policy{IframeHosts: []string{"a.example", "b.example"}, StripImages: true}).Fingerprint(); c == b {
+		t.Errorf("Different policies are equal: %s", c)
+	}
+
+	if d := (&Policy{}).Fingerprint(); d == b {
+		t.Errorf("Different policies are equal: %s", d)
+	}
+
+}
+
+// assertSafe parses the sanitized content as a browser would and fails on any element, attribute or URL which can run script.
+func assertSafe(t *testing.T, vector, result string) {
+
+	nodes, err := html.ParseFragment(strings.NewReader(result), bodyContext)
+	if err != nil {
+		t.Errorf("Cannot parse result of %s: %s", vector, err.Error())
+		return
+	}
+
+	var check func(n *html.Node)
+	check = func(n *html.Node) {
+		switch n.Type {
+		case html.CommentNode:
+			t.Errorf("Comment in result of %s: %s", vector, result)
+		case html.ElementNode:
+			if _, ok := allowedElements[n.Data]; (!ok && n.Data != "iframe") || n.Namespace != "" {
+				t.Errorf("Element %s in result of %s: %s", n.Data, vector, result)
+			}
+			for _, attr := range n.Attr {
+				key := strings.ToLower(attr.Key)
+				value := strings.ToLower(attr.Val)
+				if strings.HasPrefix(key, "on") || key == "style" || key == "srcdoc" || key == "formaction" || key == "id" || key == "class" {
+					t.Errorf("Attribute %s in result of %s: %s", key, vector, result)
+				}
+				if urlAttributes[key] && (strings.Contains(value, "script:") || strings.HasPrefix(value, "data:")) {
+					t.Errorf("Attribute %s=%s in result of %s: %s", key, attr.Val, vector, result)
+				}
+			}
+		}
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			check(c)
+		}
+	}
+
+	for _, n := range nodes {
+		check(n)
+	}
+
+}
@@ -0,0 +1,187 @@
+// Package scraper extracts feed entries from web pages which do not publish a feed.
+package scraper
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/kwo/rakewire/feedparser"
+	"github.com/kwo/rakewire/model"
+	"golang.org/x/net/html"
+)
+
+const (
+	// Flavor identifies feeds produced by the scraper.
+	Flavor          = "scrape"
+	defaultLinkRule = "a@href"
+)
+
+var (
+	// ErrNoItems indicates that the item rule did not match any elements.
+	ErrNoItems = errors.New("No items found")
+)
+
+// Scraper extracts entries from web pages according to a set of rules.
+type Scraper struct {
+	Location   *time.Location // of dates without a zone, UTC if nil
+	item       *Selector
+	title      *Selector
+	link       *Selector
+	date       *Selector
+	dateFormat string
+	content    *Selector
+}
+
+// New compiles the given rules into a Scraper.
+func New(rules *model.ScrapeRules) (*Scraper, error) {
+
+	if rules == nil || len(strings.TrimSpace(rules.Item)) == 0 {
+		return nil, errors.New("Missing item rule")
+	}
+
+	z := &Scraper{dateFormat: rules.DateFormat}
+
+	compile := func(name, value string) (*Selector, error) {
+		if len(strings.TrimSpace(value)) == 0 {
+			return nil, nil
+		}
+		selector, err := ParseSelector(value)
+		if err != nil {
+			return nil, fmt.Errorf("Invalid %s rule: %s", name, err.Error())
+		}
+		return selector, nil
+	}
+
+	var err error
+	if z.item, err = compile("item", rules.Item); err != nil {
+		return nil, err
+	}
+	link := rules.Link
+	if len(strings.TrimSpace(link)) == 0 {
+		link = defaultLinkRule
+	}
+	if z.link, err = compile("link", link); err != nil {
+		return nil, err
+	}
+	if len(z.link.Attr) == 0 {
+		z.link.Attr = "href"
+	}
+	if z.title, err = compile("title", rules.Title); err != nil {
+		return nil, err
+	}
+	if z.date, err = compile("date", rules.Date); err != nil {
+		return nil, err
+	}
+	if z.content, err = compile("content", rules.Content); err != nil {
+		return nil, err
+	}
+
+	return z, nil
+
+}
+
+// Scrape parses the web page and returns the extracted entries as a feed.
+// Entry IDs are the absolute entry links; items without a link are skipped.
+func (z *Scraper) Scrape(reader io.Reader, base string) (*feedparser.Feed, error) {
+
+	doc, err := html.Parse(reader)
+	if err != nil {
+		return nil, err
+	}
+
+	baseURL, err := url.Parse(base)
+	if err != nil {
+		return nil, err
+	}
+	if href := mustParseSelector("base@href").Value(doc); len(href) > 0 {
+		if u, err := url.Parse(href); err == nil {
+			baseURL = baseURL.ResolveReference(u)
+		}
+	}
+
+	feed := &feedparser.Feed{
+		Flavor:        Flavor,
+		LinkAlternate: base,
+		Links:         map[string]string{"alternate": base},
+		Title:         mustParseSelector("title").Value(doc),
+	}
+
+	items := z.item.Select(doc)
+	if len(items) == 0 {
+		return nil, ErrNoItems
+	}
+
+	seen := make(map[string]bool)
+	for _, item := range items {
+
+		href := z.link.Value(item)
+		if len(href) == 0 {
+			continue
+		}
+		u, err := url.Parse(href)
+		if err != nil {
+			continue
+		}
+		link := baseURL.ResolveReference(u).String()
+		if seen[link] {
+			continue
+		}
+		seen[link] = true
+
+		entry := &feedparser.Entry{
+			ID:            link,
+			LinkAlternate: link,
+			Links:         map[string]string{"alternate": link},
+		}
+
+		if z.title != nil {
+			entry.Title = z.title.Value(item)
+		} else if n := z.link.First(item); n != nil {
+			entry.Title = getText(n)
+		}
+
+		if z.date != nil {
+			entry.Created = z.parseDate(z.date.Value(item))
+			entry.Updated = entry.Created
+		}
+
+		if z.content != nil {
+			entry.Content = feedparser.RewriteContentWithAbsoluteURLs(baseURL.String(), z.content.HTML(item))
+		}
+
+		feed.Entries = append(feed.Entries, entry)
+
+	}
+
+	return feed, nil
+
+}
+
+func mustParseSelector(value string) *Selector {
+	selector, err := ParseSelector(value)
+	if err != nil {
+		panic(err)
+	}
+	return selector
+}
+
+// parseDate parses the value using the date format of the rules or, if none, like a feed date; zero if the value cannot be parsed.
+func (z *Scraper) parseDate(value string) time.Time {
+	location := z.Location
+	if location == nil {
+		location = time.UTC
+	}
+	if len(z.dateFormat) > 0 {
+		t, err := time.ParseInLocation(z.dateFormat, strings.TrimSpace(value), location)
+		if err != nil {
+			return time.Time{}
+		}
+		return t
+	}
+	t, _ := feedparser.ParseTime(value, location)
+	return t
+}
@@ -0,0 +1,132 @@
+package scraper
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/kwo/rakewire/model"
+)
+
+func TestScrape(t *testing.T) {
+
+	t.Parallel()
+
+	page := `<html>
+<head><title>News</title></head>
+<body>
+	<ul class="news">
+		<li><a href="/news/1">First story</a> <span class="date">March 1, 2016</span><div class="body"><p>Hello <img src="/img.png"></p></div></li>
+		<li><a href="http://other.example.com/2">Second story</a> <span class="date">sometime</span></li>
+		<li><a href="/news/1">Duplicate</a></li>
+		<li><span>No link</span></li>
+	</ul>
+</body>
+</html>`
+
+	s, err := New(&model.ScrapeRules{
+		Item:       "ul.news li",
+		Date:       ".date",
+		DateFormat: "January 2, 2006",
+		Content:    ".body",
+	})
+	if err != nil {
+		t.Fatalf("Cannot compile rules: %s", err.Error())
+	}
+
+	feed, err := s.Scrape(strings.NewReader(page), "http://example.com/news/")
+	if err != nil {
+		t.Fatalf("Cannot scrape page: %s", err.Error())
+	}
+
+	if feed.Title != "News" {
+		t.Errorf("Bad feed title: %s", feed.Title)
+	}
+	if feed.Flavor != Flavor {
+		t.Errorf("Bad feed flavor: %s", feed.Flavor)
+	}
+	if len(feed.Entries) != 2 {
+		t.Fatalf("Bad entry count, expected %d, actual %d", 2, len(feed.Entries))
+	}
+
+	entry := feed.Entries[0]
+	if entry.ID != "http://example.com/news/1" || entry.LinkAlternate != entry.ID {
+		t.Errorf("Bad entry ID or link: %s %s", entry.ID, entry.LinkAlternate)
+	}
+	if entry.Title != "First story" {
+		t.Errorf("Bad entry title: %s", entry.Title)
+	}
+	if !entry.Created.Equal(time.Date(2016, time.March, 1, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("Bad entry date: %s", entry.Created)
+	}
+	if entry.Content != `<p>Hello <img src="http://example.com/img.png"/></p>` {
+		t.Errorf("Bad entry content: %s", entry.Content)
+	}
+
+	entry = feed.Entries[1]
+	if entry.ID != "http://other.example.com/2" {
+		t.Errorf("Bad entry ID: %s", entry.ID)
+	}
+	if !entry.Created.IsZero() {
+		t.Errorf("Expected zero date for unparsable value, actual: %s", entry.Created)
+	}
+
+	// rules which do not match
+	s, _ = New(&model.ScrapeRules{Item: "article"})
+	if _, err := s.Scrape(strings.NewReader(page), "http://example.com/"); err != ErrNoItems {
+		t.Errorf("Expected no items error, actual: %v", err)
+	}
+
+}
+
+func TestNew(t *testing.T) {
+
+	t.Parallel()
+
+	if _, err := New(nil); err == nil {
+		t.Error("Expected error for missing rules")
+	}
+	if _, err := New(&model.ScrapeRules{Title: "h2"}); err == nil {
+		t.Error("Expected error for missing item rule")
+	}
+	if _, err := New(&model.ScrapeRules{Item: "li", Date: "span >"}); err == nil {
+		t.Error("Expected error for invalid date rule")
+	}
+
+}
+
+func TestParseDate(t *testing.T) {
+
+	t.Parallel()
+
+	s, err := New(&model.ScrapeRules{Item: "li"})
+	if err != nil {
+		t.Fatalf("Cannot compile rules: %s", err.Error())
+	}
+
+	expected := time.Date(2016, time.March, 1, 0, 0, 0, 0, time.UTC)
+	for _, value := range []string{"2016-03-01", "March 1, 2016", "1 Mar 2016", "2016-03-01T00:00:00Z", "01.03.2016", "1. März 2016", "Tue, 01 Mar 2016 01:00:00 MEZ"} {
+		if actual := s.parseDate(value); !actual.Equal(expected) {
+			t.Errorf("Bad date for %s: %s", value, actual)
+		}
+	}
+	if actual := s.parseDate("sometime"); !actual.IsZero() {
+		t.Errorf("Expected zero date for unparsable value, actual: %s", actual)
+	}
+
+	// dates without a zone are in the location of the feed
+	berlin, err := time.LoadLocation("Europe/Berlin")
+	if err != nil {
+		t.Skipf("Cannot load time zone: %s", err.Error())
+	}
+	s.Location = berlin
+	if actual := s.parseDate("2016-03-01 01:00"); !actual.Equal(expected) {
+		t.Errorf("Bad date in location: %s", actual)
+	}
+
+	s, _ = New(&model.ScrapeRules{Item: "li", DateFormat: "01/02/06"})
+	if actual := s.parseDate("03/01/16"); !actual.Equal(expected) {
+		t.Errorf("Bad date for custom layout: %s", actual)
+	}
+
+}
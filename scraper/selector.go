@@ -0,0 +1,326 @@
+package scraper
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+
+	"golang.org/x/net/html"
+)
+
+// Selector selects HTML elements using a subset of CSS selector syntax:
+// element names, *, #id, .class, [attr] and [attr=value], combined by descendant (space) and child (>) combinators.
+// A leading > restricts the first element to the children of the root.
+// A selector may end with @attr in order to extract the named attribute rather than the text of the element;
+// a selector consisting only of @attr extracts the attribute of the root itself.
+type Selector struct {
+	parts []*compound
+	Attr  string
+}
+
+type compound struct {
+	tag     string
+	id      string
+	classes []string
+	attrs   []*attrMatch
+	child   bool // must be a direct child of the element matched by the previous compound
+}
+
+type attrMatch struct {
+	key      string
+	value    string
+	hasValue bool
+}
+
+// ParseSelector parses the given selector.
+func ParseSelector(value string) (*Selector, error) {
+
+	selector := &Selector{}
+	value = strings.TrimSpace(value)
+
+	if i := attrIndex(value); i != -1 {
+		selector.Attr = strings.ToLower(strings.TrimSpace(value[i+1:]))
+		if len(selector.Attr) == 0 || strings.ContainsAny(selector.Attr, " \t\n>[]#.") {
+			return nil, fmt.Errorf("Invalid attribute in selector: %s", value)
+		}
+		value = value[:i]
+	}
+
+	child := false
+	for pos := 0; pos < len(value); {
+		switch value[pos] {
+		case ' ', '\t', '\n':
+			pos++
+		case '>':
+			if child {
+				return nil, fmt.Errorf("Invalid combinator in selector: %s", value)
+			}
+			child = true
+			pos++
+		default:
+			c, n, err := parseCompound(value[pos:])
+			if err != nil {
+				return nil, err
+			}
+			c.child = child
+			child = false
+			selector.parts = append(selector.parts, c)
+			pos += n
+		}
+	}
+
+	if child {
+		return nil, fmt.Errorf("Selector cannot end with a combinator: %s", value)
+	}
+	if len(selector.parts) == 0 && len(selector.Attr) == 0 {
+		return nil, fmt.Errorf("Empty selector")
+	}
+
+	return selector, nil
+
+}
+
+// Select returns all elements below root matching the selector, in document order.
+// If the selector has no element parts, root itself is returned.
+func (z *Selector) Select(root *html.Node) []*html.Node {
+	if len(z.parts) == 0 {
+		return []*html.Node{root}
+	}
+	result := []*html.Node{}
+	var walk func(n *html.Node)
+	walk = func(n *html.Node) {
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			if c.Type == html.ElementNode && z.matchAt(len(z.parts)-1, c, root) {
+				result = append(result, c)
+			}
+			walk(c)
+		}
+	}
+	walk(root)
+	return result
+}
+
+// First returns the first element below root matching the selector, nil if none.
+func (z *Selector) First(root *html.Node) *html.Node {
+	if nodes := z.Select(root); len(nodes) > 0 {
+		return nodes[0]
+	}
+	return nil
+}
+
+// Value returns the attribute, or text, of the first element matching the selector.
+func (z *Selector) Value(root *html.Node) string {
+	n := z.First(root)
+	if n == nil {
+		return ""
+	}
+	if len(z.Attr) > 0 {
+		return strings.TrimSpace(getAttr(n, z.Attr))
+	}
+	return getText(n)
+}
+
+// HTML returns the attribute, or inner HTML, of the first element matching the selector.
+func (z *Selector) HTML(root *html.Node) string {
+	n := z.First(root)
+	if n == nil {
+		return ""
+	}
+	if len(z.Attr) > 0 {
+		return strings.TrimSpace(getAttr(n, z.Attr))
+	}
+	return getInnerHTML(n)
+}
+
+func (z *Selector) matchAt(i int, n, root *html.Node) bool {
+
+	part := z.parts[i]
+	if !part.matches(n) {
+		return false
+	}
+
+	if i == 0 {
+		return !part.child || n.Parent == root
+	}
+
+	if part.child {
+		return n.Parent != nil && n.Parent != root && z.matchAt(i-1, n.Parent, root)
+	}
+
+	for p := n.Parent; p != nil && p != root; p = p.Parent {
+		if z.matchAt(i-1, p, root) {
+			return true
+		}
+	}
+
+	return false
+
+}
+
+func (z *compound) matches(n *html.Node) bool {
+
+	if len(z.tag) > 0 && n.Data != z.tag {
+		return false
+	}
+
+	if len(z.id) > 0 && getAttr(n, "id") != z.id {
+		return false
+	}
+
+	if len(z.classes) > 0 {
+		classes := strings.Fields(getAttr(n, "class"))
+		for _, class := range z.classes {
+			if !contains(classes, class) {
+				return false
+			}
+		}
+	}
+
+	for _, attr := range z.attrs {
+		value, ok := lookupAttr(n, attr.key)
+		if !ok || (attr.hasValue && value != attr.value) {
+			return false
+		}
+	}
+
+	return true
+
+}
+
+func parseCompound(s string) (*compound, int, error) {
+
+	c := &compound{}
+	i := 0
+
+	name := func() string {
+		start := i
+		for i < len(s) && isNameChar(s[i]) {
+			i++
+		}
+		return s[start:i]
+	}
+
+	if s[0] == '*' {
+		i++
+	} else {
+		c.tag = strings.ToLower(name())
+	}
+
+Loop:
+	for i < len(s) {
+		switch s[i] {
+		case '#':
+			i++
+			if c.id = name(); len(c.id) == 0 {
+				return nil, i, fmt.Errorf("Missing id in selector: %s", s)
+			}
+		case '.':
+			i++
+			class := name()
+			if len(class) == 0 {
+				return nil, i, fmt.Errorf("Missing class in selector: %s", s)
+			}
+			c.classes = append(c.classes, class)
+		case '[':
+			end := strings.IndexByte(s[i:], ']')
+			if end == -1 {
+				return nil, i, fmt.Errorf("Unclosed attribute in selector: %s", s)
+			}
+			attr := &attrMatch{key: strings.ToLower(strings.TrimSpace(s[i+1 : i+end]))}
+			if j := strings.IndexByte(attr.key, '='); j != -1 {
+				attr.value = strings.Trim(strings.TrimSpace(s[i+1 : i+end][j+1:]), `"'`)
+				attr.key = strings.TrimSpace(attr.key[:j])
+				attr.hasValue = true
+			}
+			if len(attr.key) == 0 {
+				return nil, i, fmt.Errorf("Missing attribute name in selector: %s", s)
+			}
+			c.attrs = append(c.attrs, attr)
+			i += end + 1
+		case ' ', '\t', '\n', '>':
+			break Loop
+		default:
+			return nil, i, fmt.Errorf("Unexpected character %q in selector: %s", s[i], s)
+		}
+	}
+
+	if i == 0 {
+		return nil, i, fmt.Errorf("Invalid selector: %s", s)
+	}
+
+	return c, i, nil
+
+}
+
+// attrIndex returns the index of the @ introducing the extracted attribute, ignoring those within brackets.
+func attrIndex(value string) int {
+	depth := 0
+	for i := len(value) - 1; i >= 0; i-- {
+		switch value[i] {
+		case ']':
+			depth++
+		case '[':
+			depth--
+		case '@':
+			if depth == 0 {
+				return i
+			}
+		}
+	}
+	return -1
+}
+
+func isNameChar(c byte) bool {
+	return c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' || c == '-' || c == '_'
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+func getAttr(n *html.Node, key string) string {
+	value, _ := lookupAttr(n, key)
+	return value
+}
+
+func lookupAttr(n *html.Node, key string) (string, bool) {
+	for _, attr := range n.Attr {
+		if attr.Key == key {
+			return attr.Val, true
+		}
+	}
+	return "", false
+}
+
+// getText returns the text content of the node with whitespace collapsed.
+func getText(n *html.Node) string {
+	buf := &bytes.Buffer{}
+	var walk func(n *html.Node)
+	walk = func(n *html.Node) {
+		if n.Type == html.ElementNode && (n.Data == "script" || n.Data == "style") {
+			return
+		}
+		if n.Type == html.TextNode {
+			buf.WriteString(n.Data)
+			buf.WriteString(" ")
+		}
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			walk(c)
+		}
+	}
+	walk(n)
+	return strings.Join(strings.Fields(buf.String()), " ")
+}
+
+func getInnerHTML(n *html.Node) string {
+	buf := &bytes.Buffer{}
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		html.Render(buf, c)
+	}
+	return strings.TrimSpace(buf.String())
+}
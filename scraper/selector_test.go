@@ -0,0 +1,95 @@
+package scraper
+
+import (
+	"strings"
+	"testing"
+
+	"golang.org/x/net/html"
+)
+
+const testPage = `<html>
+<head><title>Test Page</title></head>
+<body>
+	<div id="main">
+		<article class="post featured"><h2><a href="/one">One</a></h2><p>First</p></article>
+		<article class="post"><h2><a href="/two">Two</a></h2><div><p>Second</p></div></article>
+		<aside class="post"><a href="/three" rel="nofollow">Three</a></aside>
+	</div>
+</body>
+</html>`
+
+func TestParseSelector(t *testing.T) {
+
+	t.Parallel()
+
+	good := []string{"a", "*", "div#main", "article.post.featured", "a[href]", `a[rel="nofollow"]`, "div > article h2", "> h2", "a@href", "@href", `a[href="mailto:x@example.com"]@title`}
+	for _, value := range good {
+		if _, err := ParseSelector(value); err != nil {
+			t.Errorf("Cannot parse selector %s: %s", value, err.Error())
+		}
+	}
+
+	bad := []string{"", "div >", "div > > a", "a@", "div#", "a.", "a[href", "a:first", "a[]"}
+	for _, value := range bad {
+		if _, err := ParseSelector(value); err == nil {
+			t.Errorf("Expected invalid selector: %s", value)
+		}
+	}
+
+	selector, _ := ParseSelector(`a[href="mailto:x@example.com"]@title`)
+	if selector.Attr != "title" {
+		t.Errorf("Bad attribute, expected %s, actual %s", "title", selector.Attr)
+	}
+
+}
+
+func TestSelect(t *testing.T) {
+
+	t.Parallel()
+
+	doc, err := html.Parse(strings.NewReader(testPage))
+	if err != nil {
+		t.Fatalf("Cannot parse page: %s", err.Error())
+	}
+
+	counts := map[string]int{
+		"article":               2,
+		".post":                 3,
+		"article.post.featured": 1,
+		"#main > article":       2,
+		"body > article":        0,
+		"div article h2 a":      2,
+		"a[rel=nofollow]":       1,
+		"a[href]":               3,
+		"DIV P":                 2,
+		"div > p":               1,
+	}
+	for value, expected := range counts {
+		selector, err := ParseSelector(value)
+		if err != nil {
+			t.Fatalf("Cannot parse selector %s: %s", value, err.Error())
+		}
+		if actual := len(selector.Select(doc)); actual != expected {
+			t.Errorf("Bad match count for %s, expected %d, actual %d", value, expected, actual)
+		}
+	}
+
+	// relative to an element
+	post := mustParseSelector("article.post").Select(doc)[1]
+	if value := mustParseSelector("> h2 a@href").Value(post); value != "/two" {
+		t.Errorf("Bad relative value: %s", value)
+	}
+	if value := mustParseSelector("> p").Value(post); value != "" {
+		t.Errorf("Expected no direct child paragraph, actual: %s", value)
+	}
+	if value := mustParseSelector("@class").Value(post); value != "post" {
+		t.Errorf("Bad root attribute: %s", value)
+	}
+	if value := mustParseSelector("div").HTML(post); value != "<p>Second</p>" {
+		t.Errorf("Bad inner HTML: %s", value)
+	}
+	if value := mustParseSelector("title").Value(doc); value != "Test Page" {
+		t.Errorf("Bad text: %s", value)
+	}
+
+}
@@ -0,0 +1,404 @@
+// Package websub subscribes to the WebSub (PubSubHubbub) hubs of feeds and receives the content they push.
+package websub
+
+import (
+	"crypto/hmac"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"path"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/kwo/rakewire/logger"
+	"github.com/kwo/rakewire/model"
+	"golang.org/x/net/context"
+)
+
+const (
+	// Path is the path of the callback endpoint, followed by the feed ID.
+	Path = "/websub/"
+
+	hContentType    = "Content-Type"
+	hSignature      = "X-Hub-Signature"
+	hUserAgent      = "User-Agent"
+	maxContentSize  = 5 * 1024 * 1024 // larger pushes are rejected
+	verifyTimeout   = time.Hour       // wait for the hub to verify a subscription before requesting it again
+	retryDelay      = 6 * time.Hour   // wait after a failed subscription request
+	deniedDelay     = 7 * 24 * time.Hour
+	modeDenied      = "denied"
+	modeSubscribe   = "subscribe"
+	modeUnsubscribe = "unsubscribe"
+)
+
+var (
+	// ErrRestart indicates that the service cannot be started because it is already running.
+	ErrRestart = errors.New("The service is already started")
+	// ErrBadSignature indicates that pushed content is not signed with the secret of the subscription.
+	ErrBadSignature = errors.New("Invalid signature")
+	// ErrInsecureHub indicates that the hub does not use https, the secret of the signatures would be disclosed.
+	ErrInsecureHub = errors.New("Hub does not use https")
+	log            = logger.New("websub")
+)
+
+// Pusher passes pushed content on to be reaped.
+type Pusher interface {
+	Push(feed *model.Feed, body []byte) error
+}
+
+// Configuration contains all parameters for the WebSub service
+type Configuration struct {
+	CallbackURL     string // public URL of the callback endpoint, the feed ID is appended
+	IntervalSeconds int    // how often to look for subscriptions to request or renew
+	LeaseSeconds    int    // requested subscription duration, the hub may choose another
+	TimeoutSeconds  int
+	UserAgent       string
+	Client          *http.Client // carries the proxy and TLS settings of the fetcher, nil for a plain client
+}
+
+// Service maintains hub subscriptions and serves the callback endpoint
+type Service struct {
+	sync.Mutex
+	database     model.Database
+	pusher       Pusher
+	client       *http.Client
+	callbackURL  string
+	pollInterval time.Duration
+	lease        time.Duration
+	userAgent    string
+	running      bool
+	killsignal   chan bool
+	latch        sync.WaitGroup
+}
+
+// NewService creates a new websub service
+func NewService(cfg *Configuration, database model.Database, pusher Pusher) *Service {
+	callbackURL := cfg.CallbackURL
+	if !strings.HasSuffix(callbackURL, "/") {
+		callbackURL += "/"
+	}
+	client := cfg.Client
+	if client == nil {
+		client = &http.Client{Timeout: time.Duration(cfg.TimeoutSeconds) * time.Second}
+	}
+	return &Service{
+		database:     database,
+		pusher:       pusher,
+		client:       client,
+		callbackURL:  callbackURL,
+		pollInterval: time.Duration(cfg.IntervalSeconds) * time.Second,
+		lease:        time.Duration(cfg.LeaseSeconds) * time.Second,
+		userAgent:    cfg.UserAgent,
+	}
+}
+
+// Start service
+func (z *Service) Start() error {
+
+	z.Lock()
+	defer z.Unlock()
+	if z.running {
+		log.Debugf("service already started, exiting...")
+		return ErrRestart
+	}
+
+	log.Infof("starting...")
+	log.Infof("callback: %s", z.callbackURL)
+	log.Infof("interval: %s", z.pollInterval.String())
+	log.Infof("lease:    %s", z.lease.String())
+
+	z.killsignal = make(chan bool)
+
+	z.latch.Add(1)
+	go z.run()
+
+	z.running = true
+	log.Infof("started")
+
+	return nil
+
+}
+
+// Stop service
+func (z *Service) Stop() {
+
+	z.Lock()
+	if !z.running {
+		z.Unlock()
+		log.Debugf("service already stopped, exiting...")
+		return
+	}
+	z.Unlock()
+
+	log.Debugf("stopping...")
+	close(z.killsignal)
+	z.latch.Wait()
+
+	z.Lock()
+	z.running = false
+	z.Unlock()
+	log.Infof("stopped")
+
+}
+
+// IsRunning indicated if the service is active or not.
+func (z *Service) IsRunning() bool {
+	z.Lock()
+	defer z.Unlock()
+	return z.running
+}
+
+func (z *Service) run() {
+
+	log.Debugf("run starting...")
+
+	ticker := time.NewTicker(z.pollInterval)
+
+run:
+	for {
+		select {
+		case tick := <-ticker.C:
+			z.poll(tick)
+		case <-z.killsignal:
+			break run
+		}
+	}
+
+	ticker.Stop()
+
+	z.latch.Done()
+	log.Debugf("run exited")
+
+}
+
+// poll requests the subscriptions which are new, unverified or about to expire.
+func (z *Service) poll(now time.Time) {
+
+	var feeds model.Feeds
+	if err := z.database.Select(func(tx model.Transaction) error {
+		feeds = model.F.GetPushNext(tx, now)
+		return nil
+	}); err != nil {
+		log.Infof("Error polling feeds: %s", err.Error())
+		return
+	}
+
+	for _, feed := range feeds {
+		select {
+		case <-z.killsignal:
+			return
+		default:
+		}
+		err := z.subscribe(feed)
+		z.update(feed.ID, func(push *model.Push) {
+			if err == nil {
+				// the hub verifies asynchronously, retry if it does not
+				push.Next = time.Now().Add(verifyTimeout).Truncate(time.Second)
+			} else {
+				log.Infof("Cannot subscribe to %s at %s: %s", push.Topic, push.Hub, err.Error())
+				push.Next = time.Now().Add(retryDelay).Truncate(time.Second)
+			}
+		})
+	}
+
+}
+
+func (z *Service) subscribe(feed *model.Feed) error {
+
+	if !strings.HasPrefix(strings.ToLower(feed.Push.Hub), "https://") {
+		return ErrInsecureHub
+	}
+
+	form := url.Values{}
+	form.Set("hub.callback", z.callbackURL+feed.ID)
+	form.Set("hub.mode", modeSubscribe)
+	form.Set("hub.topic", feed.Push.Topic)
+	form.Set("hub.secret", feed.Push.Secret)
+	if z.lease > 0 {
+		form.Set("hub.lease_seconds", strconv.Itoa(int(z.lease.Seconds())))
+	}
+
+	req, err := http.NewRequest(http.MethodPost, feed.Push.Hub, strings.NewReader(form.Encode()))
+	if err != nil {
+		return err
+	}
+	req.Header.Set(hContentType, "application/x-www-form-urlencoded")
+	req.Header.Set(hUserAgent, z.userAgent)
+
+	rsp, err := z.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer rsp.Body.Close()
+
+	if rsp.StatusCode != http.StatusAccepted && rsp.StatusCode != http.StatusNoContent {
+		message, _ := ioutil.ReadAll(io.LimitReader(rsp.Body, 512))
+		return fmt.Errorf("%s %s", rsp.Status, strings.TrimSpace(string(message)))
+	}
+
+	log.Debugf("requested subscription to %s at %s", feed.Push.Topic, feed.Push.Hub)
+
+	return nil
+
+}
+
+// update applies fn to the subscription of the feed, if the feed still has one.
+func (z *Service) update(feedID string, fn func(push *model.Push)) {
+	err := z.database.Update(func(tx model.Transaction) error {
+		feed := model.F.Get(tx, feedID)
+		if feed == nil || feed.Push == nil {
+			return nil
+		}
+		fn(feed.Push)
+		return model.F.Save(tx, feed)
+	})
+	if err != nil {
+		log.Infof("Error saving subscription of feed %s: %s", feedID, err.Error())
+	}
+}
+
+// ServeHTTPC serves the callback endpoint: GET requests verify the intent of the subscriber,
+// POST requests deliver new content.
+func (z *Service) ServeHTTPC(ctx context.Context, w http.ResponseWriter, r *http.Request) {
+
+	feedID := path.Base(r.URL.Path)
+
+	var feed *model.Feed
+	z.database.Select(func(tx model.Transaction) error {
+		feed = model.F.Get(tx, feedID)
+		return nil
+	})
+
+	switch r.Method {
+	case http.MethodGet:
+		z.verify(w, r, feed)
+	case http.MethodPost:
+		z.receive(w, r, feed)
+	default:
+		http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+	}
+
+}
+
+func (z *Service) verify(w http.ResponseWriter, r *http.Request, feed *model.Feed) {
+
+	query := r.URL.Query()
+	mode := query.Get("hub.mode")
+	topic := query.Get("hub.topic")
+	challenge := query.Get("hub.challenge")
+
+	wanted := feed != nil && feed.Push != nil && feed.Push.Topic == topic
+
+	switch {
+
+	case mode == modeSubscribe && wanted:
+		lease := z.lease
+		if seconds, err := strconv.Atoi(query.Get("hub.lease_seconds")); err == nil && seconds > 0 {
+			lease = time.Duration(seconds) * time.Second
+		}
+		z.update(feed.ID, func(push *model.Push) {
+			now := time.Now().Truncate(time.Second)
+			push.Verified = now
+			push.LeaseExpires = now.Add(lease)
+			push.Next = now.Add(lease * 9 / 10) // renew before the lease expires
+		})
+		log.Debugf("subscription to %s verified, lease %s", topic, lease)
+		w.Write([]byte(challenge))
+
+	case mode == modeUnsubscribe && !wanted:
+		w.Write([]byte(challenge))
+
+	case mode == modeDenied && wanted:
+		log.Infof("subscription to %s denied: %s", topic, query.Get("hub.reason"))
+		z.update(feed.ID, func(push *model.Push) {
+			push.LeaseExpires = time.Time{}
+			push.Next = time.Now().Add(deniedDelay).Truncate(time.Second)
+		})
+		w.WriteHeader(http.StatusOK)
+
+	default:
+		http.NotFound(w, r)
+
+	}
+
+}
+
+func (z *Service) receive(w http.ResponseWriter, r *http.Request, feed *model.Feed) {
+
+	if feed == nil || feed.Push == nil {
+		// unknown subscription: ask the hub to stop delivering
+		http.Error(w, http.StatusText(http.StatusGone), http.StatusGone)
+		return
+	}
+
+	body, err := ioutil.ReadAll(io.LimitReader(r.Body, maxContentSize+1))
+	if err != nil || len(body) > maxContentSize {
+		http.Error(w, http.StatusText(http.StatusRequestEntityTooLarge), http.StatusRequestEntityTooLarge)
+		return
+	}
+
+	// content with a bad signature must be acknowledged but ignored
+	if err := checkSignature(feed.Push.Secret, r.Header.Get(hSignature), body); err != nil {
+		log.Infof("Ignoring content pushed for %s: %s", feed.URL, err.Error())
+		w.WriteHeader(http.StatusAccepted)
+		return
+	}
+
+	if err := z.pusher.Push(feed, body); err != nil {
+		log.Infof("Cannot process content pushed for %s: %s", feed.URL, err.Error())
+	}
+
+	w.WriteHeader(http.StatusAccepted)
+
+}
+
+// checkSignature validates the X-Hub-Signature header (method=hexdigest) against the HMAC of the body.
+func checkSignature(secret, header string, body []byte) error {
+
+	if secret == "" {
+		return nil
+	}
+
+	parts := strings.SplitN(header, "=", 2)
+	if len(parts) != 2 {
+		return ErrBadSignature
+	}
+
+	var fn func() hash.Hash
+	switch strings.ToLower(parts[0]) {
+	case "sha1":
+		fn = sha1.New
+	case "sha256":
+		fn = sha256.New
+	case "sha384":
+		fn = sha512.New384
+	case "sha512":
+		fn = sha512.New
+	default:
+		return ErrBadSignature
+	}
+
+	signature, err := hex.DecodeString(parts[1])
+	if err != nil {
+		return ErrBadSignature
+	}
+
+	mac := hmac.New(fn, []byte(secret))
+	mac.Write(body)
+	if !hmac.Equal(signature, mac.Sum(nil)) {
+		return ErrBadSignature
+	}
+
+	return nil
+
+}
@@ -0,0 +1,195 @@
+package websub
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/kwo/rakewire/model"
+	"golang.org/x/net/context"
+)
+
+type testPusher struct {
+	sync.Mutex
+	bodies []string
+}
+
+func (z *testPusher) Push(feed *model.Feed, body []byte) error {
+	z.Lock()
+	defer z.Unlock()
+	z.bodies = append(z.bodies, string(body))
+	return nil
+}
+
+func TestInterfaceService(t *testing.T) {
+
+	var s model.Service = &Service{}
+	if s == nil {
+		t.Fatal("Does not implement model.Service interface.")
+	}
+
+}
+
+func TestSubscription(t *testing.T) {
+
+	var requests []url.Values
+	hub := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		r.ParseForm()
+		requests = append(requests, r.PostForm)
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	defer hub.Close()
+
+	database := openTestDatabase(t)
+	defer closeTestDatabase(t, database)
+
+	now := time.Now()
+	var feed *model.Feed
+	if err := database.Update(func(tx model.Transaction) error {
+		feed = model.F.New("http://localhost/feed")
+		feed.SetHub(hub.URL, "http://localhost/feed")
+		return model.F.Save(tx, feed)
+	}); err != nil {
+		t.Fatalf("Cannot save feed: %s", err.Error())
+	}
+
+	pusher := &testPusher{}
+	z := NewService(&Configuration{CallbackURL: "https://localhost/websub", IntervalSeconds: 3600, LeaseSeconds: 3600, TimeoutSeconds: 5, Client: hub.Client()}, database, pusher)
+	z.killsignal = make(chan bool)
+
+	// subscription request
+	z.poll(now)
+	if len(requests) != 1 {
+		t.Fatalf("Bad request count, expected %d, actual %d", 1, len(requests))
+	}
+	form := requests[0]
+	if form.Get("hub.mode") != "subscribe" || form.Get("hub.topic") != "http://localhost/feed" || form.Get("hub.lease_seconds") != "3600" {
+		t.Errorf("Bad subscription request: %v", form)
+	}
+	if callback := form.Get("hub.callback"); callback != "https://localhost/websub/"+feed.ID {
+		t.Errorf("Bad callback: %s", callback)
+	}
+	if form.Get("hub.secret") != feed.Push.Secret {
+		t.Error("Secret not sent")
+	}
+
+	// not due again until the verification timeout
+	z.poll(now)
+	if len(requests) != 1 {
+		t.Errorf("Subscription requested again before verification timeout")
+	}
+
+	// verification of intent
+	if rsp := serve(z, "GET", "/websub/"+feed.ID+"?hub.mode=subscribe&hub.topic=http://other/feed&hub.challenge=abc", "", ""); rsp.Code != http.StatusNotFound {
+		t.Errorf("Expected unknown topic to be refused, actual %d", rsp.Code)
+	}
+	if rsp := serve(z, "GET", "/websub/"+feed.ID+"?hub.mode=subscribe&hub.topic=http://localhost/feed&hub.challenge=abc&hub.lease_seconds=7200", "", ""); rsp.Code != http.StatusOK || rsp.Body.String() != "abc" {
+		t.Errorf("Bad verification response: %d %s", rsp.Code, rsp.Body.String())
+	}
+
+	database.Select(func(tx model.Transaction) error {
+		feed = model.F.Get(tx, feed.ID)
+		return nil
+	})
+	if !feed.Push.Active(time.Now()) {
+		t.Error("Subscription not active after verification")
+	}
+	if expected := feed.Push.Verified.Add(7200 * 9 / 10 * time.Second); !feed.Push.Next.Equal(expected) {
+		t.Errorf("Bad renewal time, expected %s, actual %s", expected, feed.Push.Next)
+	}
+
+	// content delivery
+	body := "<feed/>"
+	mac := hmac.New(sha256.New, []byte(feed.Push.Secret))
+	mac.Write([]byte(body))
+	signature := "sha256=" + hex.EncodeToString(mac.Sum(nil))
+
+	if rsp := serve(z, "POST", "/websub/"+feed.ID, body, "sha256=00"); rsp.Code != http.StatusAccepted {
+		t.Errorf("Bad signature must be acknowledged, actual %d", rsp.Code)
+	}
+	if rsp := serve(z, "POST", "/websub/"+feed.ID, body, signature); rsp.Code != http.StatusAccepted {
+		t.Errorf("Bad delivery response: %d", rsp.Code)
+	}
+	if rsp := serve(z, "POST", "/websub/9999999999", body, signature); rsp.Code != http.StatusGone {
+		t.Errorf("Expected unknown feed to be gone, actual %d", rsp.Code)
+	}
+	if len(pusher.bodies) != 1 || pusher.bodies[0] != body {
+		t.Errorf("Expected exactly the signed content to be pushed, actual %v", pusher.bodies)
+	}
+
+}
+
+func TestCheckSignature(t *testing.T) {
+
+	t.Parallel()
+
+	body := []byte("content")
+
+	if err := checkSignature("", "", body); err != nil {
+		t.Errorf("Subscriptions without secret need no signature: %s", err.Error())
+	}
+
+	for _, header := range []string{"", "sha256", "md5=00", "sha1=zz", "sha1=00"} {
+		if err := checkSignature("secret", header, body); err != ErrBadSignature {
+			t.Errorf("Expected bad signature for %q, actual %v", header, err)
+		}
+	}
+
+	mac := hmac.New(sha256.New, []byte("secret"))
+	mac.Write(body)
+	if err := checkSignature("secret", "SHA256="+hex.EncodeToString(mac.Sum(nil)), body); err != nil {
+		t.Errorf("Expected valid signature: %s", err.Error())
+	}
+
+}
+
+func serve(z *Service, method, target, body, signature string) *httptest.ResponseRecorder {
+	r := httptest.NewRequest(method, target, strings.NewReader(body))
+	if signature != "" {
+		r.Header.Set(hSignature, signature)
+	}
+	w := httptest.NewRecorder()
+	z.ServeHTTPC(context.Background(), w, r)
+	return w
+}
+
+func openTestDatabase(t *testing.T) model.Database {
+
+	f, err := ioutil.TempFile("", "bolt-")
+	if err != nil {
+		t.Fatalf("Cannot acquire temp file: %s", err.Error())
+	}
+	f.Close()
+	location := f.Name()
+
+	boltDB, err := model.Instance.Open(location)
+	if err != nil {
+		t.Fatalf("Cannot open database: %s", err.Error())
+	}
+
+	return boltDB
+
+}
+
+func closeTestDatabase(t *testing.T, d model.Database) {
+
+	location := d.Location()
+
+	if err := model.Instance.Close(d); err != nil {
+		t.Errorf("Cannot close database: %s", err.Error())
+	}
+
+	if err := os.Remove(location); err != nil {
+		t.Errorf("Cannot remove temp file: %s", err.Error())
+	}
+
+}